@@ -21,6 +21,7 @@ import (
 
 	controllerv1beta1 "github.com/percona-platform/dbaas-api/gen/controller"
 	"github.com/percona/pmm/version"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 	"google.golang.org/grpc/grpclog"
@@ -28,8 +29,10 @@ import (
 
 	_ "github.com/percona-platform/dbaas-controller/catalog" // load messages.
 	"github.com/percona-platform/dbaas-controller/service/cluster"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient"
 	"github.com/percona-platform/dbaas-controller/service/logs"
 	"github.com/percona-platform/dbaas-controller/service/operator"
+	"github.com/percona-platform/dbaas-controller/service/versionservice"
 	"github.com/percona-platform/dbaas-controller/utils/app"
 	"github.com/percona-platform/dbaas-controller/utils/logger"
 	"github.com/percona-platform/dbaas-controller/utils/servers"
@@ -56,6 +59,17 @@ func main() {
 
 	l.Infof("Starting...")
 
+	k8sclient.DefaultBackend = k8sclient.Backend(flags.KubeBackend)
+	k8sclient.DefaultPool = k8sclient.NewPool(flags.KubePoolSize, flags.KubePoolIdleTimeout)
+	prometheus.MustRegister(k8sclient.DefaultPool)
+	go k8sclient.DefaultPool.Run(ctx)
+
+	versionservice.Default = versionservice.New(&versionservice.Opts{
+		URL:      flags.VersionServiceURL,
+		File:     flags.VersionServiceFile,
+		CacheTTL: flags.VersionServiceCacheTTL,
+	})
+
 	// Setup grpc server
 	grpclog.SetLoggerV2(l.GRPCLogger())
 
@@ -68,6 +82,7 @@ func main() {
 
 	i18nPrinter := message.NewPrinter(language.English)
 	controllerv1beta1.RegisterXtraDBClusterAPIServer(gRPCServer.GetUnderlyingServer(), cluster.NewXtraDBClusterService(i18nPrinter))
+	controllerv1beta1.RegisterXtraDBClusterBackupAPIServer(gRPCServer.GetUnderlyingServer(), cluster.NewXtraDBClusterBackupService(i18nPrinter))
 	controllerv1beta1.RegisterPSMDBClusterAPIServer(gRPCServer.GetUnderlyingServer(), cluster.NewPSMDBClusterService(i18nPrinter))
 	controllerv1beta1.RegisterKubernetesClusterAPIServer(gRPCServer.GetUnderlyingServer(), cluster.NewKubernetesClusterService(i18nPrinter))
 	controllerv1beta1.RegisterLogsAPIServer(gRPCServer.GetUnderlyingServer(), logs.NewService(i18nPrinter))
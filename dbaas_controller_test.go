@@ -44,6 +44,7 @@ func TestImports(t *testing.T) {
 
 	// just to add to packages.dot
 	constraints["github.com/percona-platform/dbaas-controller/service/cluster"] = constraint{}
+	constraints["github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubeapi"] = constraint{}
 
 	allImports := make(map[string]map[string]struct{})
 	for path, c := range constraints {
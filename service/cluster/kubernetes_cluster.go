@@ -64,7 +64,10 @@ func (k KubernetesClusterService) CheckKubernetesClusterConnection(ctx context.C
 	l := logger.Get(ctx)
 	l = l.WithField("component", "kubernetesClusterService")
 
-	operators, err := k8Client.CheckOperators(ctx)
+	// there is no field for the platform in the response yet, so it's only logged
+	l.Infof("Kubernetes cluster platform: %s.", k8Client.GetKubernetesClusterType(ctx))
+
+	operators, err := k8Client.CheckOperators(ctx, k8sclient.DefaultNamespace)
 	if err != nil {
 		l.Error(err)
 		return resp, nil
@@ -93,7 +96,8 @@ func (k KubernetesClusterService) GetResources(ctx context.Context, req *control
 	// Get cluster type
 	clusterType := k8sClient.GetKubernetesClusterType(ctx)
 	var volumes *common.PersistentVolumeList
-	if clusterType == k8sclient.AmazonEKSClusterType {
+	switch clusterType {
+	case k8sclient.AmazonEKSClusterType, k8sclient.GoogleGKEClusterType, k8sclient.AzureAKSClusterType:
 		volumes, err = k8sClient.GetPersistentVolumes(ctx)
 		if err != nil {
 			return nil, status.Error(codes.Internal, err.Error())
@@ -26,7 +26,9 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/percona-platform/dbaas-controller/service/k8sclient"
+	"github.com/percona-platform/dbaas-controller/service/versionservice"
 	"github.com/percona-platform/dbaas-controller/utils/convertors"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
 )
 
 //nolint:gochecknoglobals
@@ -60,7 +62,7 @@ func (s *PSMDBClusterService) ListPSMDBClusters(ctx context.Context, req *contro
 	}
 	defer client.Cleanup() //nolint:errcheck
 
-	PSMDBClusters, err := client.ListPSMDBClusters(ctx)
+	PSMDBClusters, err := client.ListPSMDBClusters(ctx, k8sclient.DefaultNamespace)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -143,6 +145,21 @@ func (s *PSMDBClusterService) CreatePSMDBCluster(ctx context.Context, req *contr
 		params.Replicaset.ComputeResources = computeResources(req.Params.Replicaset.ComputeResources)
 	}
 
+	// images that are not requested are taken from version service for the installed operator,
+	// default images of k8sclient are used if the operator can't be checked
+	var operator k8sclient.Operator
+	if operators, err := client.CheckOperators(ctx, k8sclient.DefaultNamespace); err == nil {
+		operator = operators.Psmdb
+	} else {
+		logger.Get(ctx).Warnf("Cannot check operators, using default images: %s.", err)
+	}
+	images := versionservice.Default.RecommendedImages(ctx, versionservice.ProductPSMDBOperator, operator,
+		versionservice.ComponentMongod, versionservice.ComponentBackup)
+	if params.Image == "" {
+		params.Image = images[versionservice.ComponentMongod]
+	}
+	params.BackupImage = images[versionservice.ComponentBackup]
+
 	err = client.CreatePSMDBCluster(ctx, params)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
@@ -198,7 +215,7 @@ func (s *PSMDBClusterService) DeletePSMDBCluster(ctx context.Context, req *contr
 	}
 	defer client.Cleanup() //nolint:errcheck
 
-	err = client.DeletePSMDBCluster(ctx, req.Name)
+	err = client.DeletePSMDBCluster(ctx, k8sclient.DefaultNamespace, req.Name)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -213,7 +230,7 @@ func (s *PSMDBClusterService) RestartPSMDBCluster(ctx context.Context, req *cont
 	}
 	defer client.Cleanup() //nolint:errcheck
 
-	err = client.RestartPSMDBCluster(ctx, req.Name)
+	err = client.RestartPSMDBCluster(ctx, k8sclient.DefaultNamespace, req.Name)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -228,7 +245,7 @@ func (s *PSMDBClusterService) GetPSMDBClusterCredentials(ctx context.Context, re
 	}
 	defer client.Cleanup() //nolint:errcheck
 
-	cluster, err := client.GetPSMDBClusterCredentials(ctx, req.Name)
+	cluster, err := client.GetPSMDBClusterCredentials(ctx, k8sclient.DefaultNamespace, req.Name)
 	if err != nil {
 		if errors.Is(err, k8sclient.ErrPSMDBClusterNotReady) {
 			return nil, status.Error(codes.FailedPrecondition, err.Error())
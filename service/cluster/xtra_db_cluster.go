@@ -27,7 +27,9 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/percona-platform/dbaas-controller/service/k8sclient"
+	"github.com/percona-platform/dbaas-controller/service/versionservice"
 	"github.com/percona-platform/dbaas-controller/utils/convertors"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
 )
 
 //nolint:gochecknoglobals
@@ -78,7 +80,7 @@ func (s *XtraDBClusterService) ListXtraDBClusters(ctx context.Context, req *cont
 	}
 	defer client.Cleanup() //nolint:errcheck
 
-	xtradbClusters, err := client.ListXtraDBClusters(ctx)
+	xtradbClusters, err := client.ListXtraDBClusters(ctx, k8sclient.DefaultNamespace)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -188,6 +190,28 @@ func (s *XtraDBClusterService) CreateXtraDBCluster(ctx context.Context, req *con
 			Password:      req.Pmm.Password,
 		}
 	}
+
+	// images that are not requested are taken from version service for the installed operator,
+	// default images of k8sclient are used if the operator can't be checked
+	var operator k8sclient.Operator
+	if operators, err := client.CheckOperators(ctx, k8sclient.DefaultNamespace); err == nil {
+		operator = operators.Xtradb
+	} else {
+		logger.Get(ctx).Warnf("Cannot check operators, using default images: %s.", err)
+	}
+	images := versionservice.Default.RecommendedImages(ctx, versionservice.ProductPXCOperator, operator,
+		versionservice.ComponentPXC, versionservice.ComponentProxySQL, versionservice.ComponentHAProxy, versionservice.ComponentBackup)
+	if params.PXC.Image == "" {
+		params.PXC.Image = images[versionservice.ComponentPXC]
+	}
+	if params.ProxySQL != nil && params.ProxySQL.Image == "" {
+		params.ProxySQL.Image = images[versionservice.ComponentProxySQL]
+	}
+	if params.HAProxy != nil && params.HAProxy.Image == "" {
+		params.HAProxy.Image = images[versionservice.ComponentHAProxy]
+	}
+	params.BackupImage = images[versionservice.ComponentBackup]
+
 	err = client.CreateXtraDBCluster(ctx, params)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
@@ -256,7 +280,7 @@ func (s *XtraDBClusterService) DeleteXtraDBCluster(ctx context.Context, req *con
 	}
 	defer client.Cleanup() //nolint:errcheck
 
-	err = client.DeleteXtraDBCluster(ctx, req.Name)
+	err = client.DeleteXtraDBCluster(ctx, k8sclient.DefaultNamespace, req.Name)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -271,7 +295,7 @@ func (s *XtraDBClusterService) RestartXtraDBCluster(ctx context.Context, req *co
 	}
 	defer client.Cleanup() //nolint:errcheck
 
-	err = client.RestartXtraDBCluster(ctx, req.Name)
+	err = client.RestartXtraDBCluster(ctx, k8sclient.DefaultNamespace, req.Name)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -286,7 +310,7 @@ func (s XtraDBClusterService) GetXtraDBClusterCredentials(ctx context.Context, r
 	}
 	defer client.Cleanup() //nolint:errcheck
 
-	cluster, err := client.GetXtraDBClusterCredentials(ctx, req.Name)
+	cluster, err := client.GetXtraDBClusterCredentials(ctx, k8sclient.DefaultNamespace, req.Name)
 	if err != nil {
 		if errors.Is(err, k8sclient.ErrXtraDBClusterNotReady) {
 			return nil, status.Error(codes.FailedPrecondition, err.Error())
@@ -0,0 +1,120 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cluster
+
+import (
+	"context"
+
+	controllerv1beta1 "github.com/percona-platform/dbaas-api/gen/controller"
+	"golang.org/x/text/message"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient"
+)
+
+//nolint:gochecknoglobals
+// pxcBackupStatesMap matches backup states to XtraDB backup states.
+var pxcBackupStatesMap = map[k8sclient.BackupState]controllerv1beta1.XtraDBBackupState{
+	k8sclient.BackupStateInvalid:   controllerv1beta1.XtraDBBackupState_XTRA_DB_BACKUP_STATE_INVALID,
+	k8sclient.BackupStateRunning:   controllerv1beta1.XtraDBBackupState_XTRA_DB_BACKUP_STATE_RUNNING,
+	k8sclient.BackupStateSucceeded: controllerv1beta1.XtraDBBackupState_XTRA_DB_BACKUP_STATE_SUCCEEDED,
+	k8sclient.BackupStateFailed:    controllerv1beta1.XtraDBBackupState_XTRA_DB_BACKUP_STATE_FAILED,
+}
+
+// XtraDBClusterBackupService implements methods of gRPC server and other business logic related to XtraDB cluster backups.
+type XtraDBClusterBackupService struct {
+	p *message.Printer
+}
+
+// NewXtraDBClusterBackupService returns new XtraDBClusterBackupService instance.
+func NewXtraDBClusterBackupService(p *message.Printer) *XtraDBClusterBackupService {
+	return &XtraDBClusterBackupService{p: p}
+}
+
+// ListXtraDBClusterBackups returns a list of XtraDB cluster backups.
+func (s *XtraDBClusterBackupService) ListXtraDBClusterBackups(ctx context.Context, req *controllerv1beta1.ListXtraDBClusterBackupsRequest) (*controllerv1beta1.ListXtraDBClusterBackupsResponse, error) {
+	client, err := k8sclient.New(ctx, req.KubeAuth.Kubeconfig)
+	if err != nil {
+		return nil, status.Error(codes.Internal, s.p.Sprintf("Cannot initialize K8s client: %s", err))
+	}
+	defer client.Cleanup() //nolint:errcheck
+
+	backups, err := client.ListXtraDBClusterBackups(ctx, k8sclient.DefaultNamespace, "")
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &controllerv1beta1.ListXtraDBClusterBackupsResponse{
+		Backups: make([]*controllerv1beta1.ListXtraDBClusterBackupsResponse_Backup, len(backups)),
+	}
+	for i, backup := range backups {
+		res.Backups[i] = &controllerv1beta1.ListXtraDBClusterBackupsResponse_Backup{
+			ClusterName: backup.ClusterName,
+			BackupName:  backup.Name,
+			State:       pxcBackupStatesMap[backup.State],
+		}
+		if !backup.StartTime.IsZero() {
+			res.Backups[i].StartTime = timestamppb.New(backup.StartTime)
+		}
+		if !backup.FinishTime.IsZero() {
+			res.Backups[i].FinishTime = timestamppb.New(backup.FinishTime)
+		}
+	}
+
+	return res, nil
+}
+
+// CreateXtraDBClusterBackup makes a new on-demand backup of XtraDB cluster.
+func (s *XtraDBClusterBackupService) CreateXtraDBClusterBackup(ctx context.Context, req *controllerv1beta1.CreateXtraDBClusterBackupRequest) (*controllerv1beta1.CreateXtraDBClusterBackupResponse, error) {
+	client, err := k8sclient.New(ctx, req.KubeAuth.Kubeconfig)
+	if err != nil {
+		return nil, status.Error(codes.Internal, s.p.Sprintf("Cannot initialize K8s client: %s", err))
+	}
+	defer client.Cleanup() //nolint:errcheck
+
+	err = client.CreateXtraDBClusterBackup(ctx, &k8sclient.XtraDBBackupParams{
+		Namespace:   k8sclient.DefaultNamespace,
+		ClusterName: req.ClusterName,
+		Name:        req.BackupName,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return new(controllerv1beta1.CreateXtraDBClusterBackupResponse), nil
+}
+
+// DeleteXtraDBClusterBackup deletes XtraDB cluster backup.
+func (s *XtraDBClusterBackupService) DeleteXtraDBClusterBackup(ctx context.Context, req *controllerv1beta1.DeleteXtraDBClusterBackupRequest) (*controllerv1beta1.DeleteXtraDBClusterBackupResponse, error) {
+	client, err := k8sclient.New(ctx, req.KubeAuth.Kubeconfig)
+	if err != nil {
+		return nil, status.Error(codes.Internal, s.p.Sprintf("Cannot initialize K8s client: %s", err))
+	}
+	defer client.Cleanup() //nolint:errcheck
+
+	err = client.DeleteXtraDBClusterBackup(ctx, k8sclient.DefaultNamespace, req.BackupName)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return new(controllerv1beta1.DeleteXtraDBClusterBackupResponse), nil
+}
+
+// Check interface.
+var (
+	_ controllerv1beta1.XtraDBClusterBackupAPIServer = (*XtraDBClusterBackupService)(nil)
+)
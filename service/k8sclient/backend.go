@@ -0,0 +1,66 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubeapi"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+)
+
+// Backend represents the way K8sClient talks to Kubernetes API server.
+type Backend string
+
+const (
+	// BackendNative talks to Kubernetes API server in-process.
+	BackendNative Backend = "native"
+	// BackendKubectl runs kubectl binary for every request.
+	BackendKubectl Backend = "kubectl"
+)
+
+// DefaultBackend is used by New. It is set once on startup.
+var DefaultBackend = BackendNative //nolint:gochecknoglobals
+
+// kubeClient is implemented by all backends. Empty namespace means the default
+// namespace of kubeconfig context. Run accepts kubectl arguments,
+// native backend supports only the subset of commands used by K8sClient.
+// Watch and Stream block until the watch or the output is closed, or ctx is canceled.
+type kubeClient interface {
+	Get(ctx context.Context, namespace, kind, name string, res interface{}) error
+	Apply(ctx context.Context, namespace string, res interface{}) error
+	Delete(ctx context.Context, namespace string, res interface{}) error
+	Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error)
+	Watch(ctx context.Context, namespace, kind, selector string, events chan<- common.WatchEvent) error
+	Stream(ctx context.Context, args []string, w io.Writer) error
+	Cleanup() error
+}
+
+// newKubeClient returns client for given backend.
+func newKubeClient(ctx context.Context, kubeconfig string, backend Backend) (kubeClient, error) {
+	switch backend {
+	case BackendNative:
+		return kubeapi.NewKubeAPI(ctx, kubeconfig)
+	case BackendKubectl:
+		return kubectl.NewKubeCtl(ctx, kubeconfig)
+	}
+	return nil, errors.Errorf("unknown Kubernetes client backend %q", backend)
+}
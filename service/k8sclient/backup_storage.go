@@ -0,0 +1,107 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+const (
+	pxcBackupS3SecretNameTmpl   = "dbaas-%s-pxc-backup-s3-secrets"
+	psmdbBackupS3SecretNameTmpl = "dbaas-%s-psmdb-backup-s3-secrets"
+
+	// Both operators expect S3 credentials under these keys.
+	s3AccessKeyIDKey     = "AWS_ACCESS_KEY_ID"
+	s3SecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+// BackupStorageS3 contains parameters of S3 compatible backup storage, for example AWS S3 or MinIO.
+type BackupStorageS3 struct {
+	Bucket string
+	// Prefix is a path inside the bucket, it is used by PSMDB clusters only.
+	Prefix string
+	Region string
+	// EndpointURL should be set for S3 compatible storages other than AWS S3.
+	EndpointURL     string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// validate checks that all required parameters are set.
+func (s *BackupStorageS3) validate() error {
+	if s.Bucket == "" {
+		return errors.New("S3 bucket should be set")
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return errors.New("S3 access key ID and secret access key should be set")
+	}
+	return nil
+}
+
+// pxcSpec returns XtraDB cluster backup storage that uses credentials from a given secret.
+func (s *BackupStorageS3) pxcSpec(secretName string) *pxc.BackupStorageSpec {
+	return &pxc.BackupStorageSpec{
+		Type: pxc.BackupStorageS3,
+		S3: pxc.BackupStorageS3Spec{
+			Bucket:            s.Bucket,
+			CredentialsSecret: secretName,
+			Region:            s.Region,
+			EndpointURL:       s.EndpointURL,
+		},
+	}
+}
+
+// psmdbSpec returns PSMDB cluster backup storage that uses credentials from a given secret.
+func (s *BackupStorageS3) psmdbSpec(secretName string) psmdb.BackupStorageSpec {
+	return psmdb.BackupStorageSpec{
+		Type: psmdb.BackupStorageS3,
+		S3: psmdb.BackupStorageS3Spec{
+			Bucket:            s.Bucket,
+			Prefix:            s.Prefix,
+			Region:            s.Region,
+			EndpointURL:       s.EndpointURL,
+			CredentialsSecret: secretName,
+		},
+	}
+}
+
+// createBackupS3Secret creates or updates secret with S3 credentials.
+func (c *K8sClient) createBackupS3Secret(ctx context.Context, namespace, secretName string, s *BackupStorageS3) error {
+	err := c.CreateSecret(ctx, namespace, secretName, map[string][]byte{
+		s3AccessKeyIDKey:     []byte(s.AccessKeyID),
+		s3SecretAccessKeyKey: []byte(s.SecretAccessKey),
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot create secret for S3 backup storage")
+	}
+	return nil
+}
+
+// deleteBackupS3Secret deletes secret with S3 credentials if it exists.
+func (c *K8sClient) deleteBackupS3Secret(ctx context.Context, namespace, secretName string) error {
+	err := c.deleteSecret(ctx, namespace, secretName)
+	if err != nil && !errors.Is(err, kubectl.ErrNotFound) {
+		return err
+	}
+	return nil
+}
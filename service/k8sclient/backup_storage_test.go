@@ -0,0 +1,64 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+func TestBackupStorageS3(t *testing.T) {
+	t.Parallel()
+
+	s3 := &BackupStorageS3{
+		Bucket:          "backups",
+		Prefix:          "cluster",
+		Region:          "us-east-1",
+		EndpointURL:     "https://minio.local:9000",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+	}
+	assert.NoError(t, s3.validate())
+
+	assert.Equal(t, &pxc.BackupStorageSpec{
+		Type: pxc.BackupStorageS3,
+		S3: pxc.BackupStorageS3Spec{
+			Bucket:            "backups",
+			CredentialsSecret: "s3-secret",
+			Region:            "us-east-1",
+			EndpointURL:       "https://minio.local:9000",
+		},
+	}, s3.pxcSpec("s3-secret"))
+
+	assert.Equal(t, psmdb.BackupStorageSpec{
+		Type: psmdb.BackupStorageS3,
+		S3: psmdb.BackupStorageS3Spec{
+			Bucket:            "backups",
+			Prefix:            "cluster",
+			Region:            "us-east-1",
+			EndpointURL:       "https://minio.local:9000",
+			CredentialsSecret: "s3-secret",
+		},
+	}, s3.psmdbSpec("s3-secret"))
+
+	assert.EqualError(t, (&BackupStorageS3{AccessKeyID: "key", SecretAccessKey: "secret"}).validate(), "S3 bucket should be set")
+	assert.EqualError(t, (&BackupStorageS3{Bucket: "backups", AccessKeyID: "key"}).validate(), "S3 access key ID and secret access key should be set")
+}
@@ -0,0 +1,296 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+// ProxyType is a type of proxy in front of Percona XtraDB cluster.
+type ProxyType string
+
+const (
+	// ProxyTypeNone means the cluster has no proxy.
+	ProxyTypeNone ProxyType = ""
+	// ProxyTypeProxySQL means ProxySQL is used.
+	ProxyTypeProxySQL ProxyType = "proxysql"
+	// ProxyTypeHAProxy means HAProxy is used.
+	ProxyTypeHAProxy ProxyType = "haproxy"
+)
+
+// ClusterPMM contains PMM client settings of a cluster.
+type ClusterPMM struct {
+	Enabled bool
+	// ServerHost is PMM server address.
+	ServerHost string
+	Image      string
+}
+
+// Endpoint is a network address of a cluster Kubernetes service.
+type Endpoint struct {
+	Service string
+	// Type is Kubernetes service type: ClusterIP, NodePort or LoadBalancer.
+	Type string
+	// Host is load balancer address, it is empty for other service types.
+	Host string
+	Port int32
+	// NodePort is set for NodePort and LoadBalancer services.
+	NodePort int32
+}
+
+// XtraDBClusterDetails contains full spec and status of Percona XtraDB cluster.
+type XtraDBClusterDetails struct {
+	XtraDBCluster
+	CRVersion string
+	ProxyType ProxyType
+	PMM       ClusterPMM
+	// BackupSchedules is empty if there are no scheduled backups.
+	BackupSchedules []BackupSchedule
+	// BackupStorages contains names of backup storages sorted by name.
+	BackupStorages []string
+	// Host is the cluster address reported by the operator.
+	Host      string
+	Endpoints []Endpoint
+}
+
+// PSMDBClusterDetails contains full spec and status of Percona Server for MongoDB cluster.
+type PSMDBClusterDetails struct {
+	PSMDBCluster
+	CRVersion string
+	Image     string
+	PMM       ClusterPMM
+	// BackupEnabled is true if backup agents run in the cluster.
+	BackupEnabled bool
+	// BackupTasks is empty if there are no scheduled backups.
+	BackupTasks []PSMDBBackupTask
+	// BackupStorages contains names of backup storages sorted by name.
+	BackupStorages []string
+	// Host is the cluster address reported by the operator.
+	Host      string
+	Endpoints []Endpoint
+}
+
+// GetXtraDBCluster returns full spec and status of Percona XtraDB cluster with a given name.
+// Cluster which custom resource is deleted but pods are not is returned in deleting state.
+// Empty namespace means the default one.
+func (c *K8sClient) GetXtraDBCluster(ctx context.Context, namespace, name string) (*XtraDBClusterDetails, error) {
+	var cluster pxc.PerconaXtraDBCluster
+	err := c.kube.Get(ctx, namespace, string(perconaXtraDBClusterKind), name, &cluster)
+	if err != nil && !errors.Is(err, kubectl.ErrNotFound) {
+		return nil, errors.Wrapf(err, "cannot get XtraDB cluster %s", name)
+	}
+	pods, podsErr := c.GetPods(ctx, namespace, "-l", "app.kubernetes.io/instance="+name)
+
+	if err != nil {
+		if podsErr != nil || len(pods.Items) == 0 {
+			return nil, errors.Wrapf(ErrNotFound, "XtraDB cluster %s", name)
+		}
+		return &XtraDBClusterDetails{
+			XtraDBCluster: XtraDBCluster{
+				Name:          name,
+				State:         ClusterStateDeleting,
+				PXC:           new(PXC),
+				DetailedState: []appStatus{},
+			},
+		}, nil
+	}
+	if podsErr != nil {
+		c.l.Warnf("Cannot get pods of XtraDB cluster %s: %s.", name, podsErr)
+		pods = new(common.PodList)
+	}
+
+	clusters := []XtraDBCluster{c.getXtraDBCluster(&cluster, pods.Items)}
+	restores, err := c.ListXtraDBClusterRestores(ctx, namespace, name)
+	if err != nil {
+		c.l.Warnf("Cannot get XtraDB cluster restores: %s.", err)
+	}
+	setXtraDBRestoreStates(clusters, restores)
+
+	res := &XtraDBClusterDetails{
+		XtraDBCluster:   clusters[0],
+		CRVersion:       cluster.Spec.CRVersion,
+		BackupSchedules: []BackupSchedule{},
+		BackupStorages:  []string{},
+		Host:            cluster.Status.Host,
+		Endpoints:       c.getClusterEndpoints(ctx, namespace, name),
+	}
+	res.PXC.Image = cluster.Spec.PXC.Image
+	switch {
+	case cluster.Spec.ProxySQL != nil && cluster.Spec.ProxySQL.Enabled:
+		res.ProxyType = ProxyTypeProxySQL
+		if res.ProxySQL != nil {
+			res.ProxySQL.Image = cluster.Spec.ProxySQL.Image
+		}
+	case cluster.Spec.HAProxy != nil && cluster.Spec.HAProxy.Enabled:
+		res.ProxyType = ProxyTypeHAProxy
+		if res.HAProxy != nil {
+			res.HAProxy.Image = cluster.Spec.HAProxy.Image
+		}
+	}
+	if cluster.Spec.PMM != nil {
+		res.PMM = ClusterPMM{
+			Enabled:    cluster.Spec.PMM.Enabled,
+			ServerHost: cluster.Spec.PMM.ServerHost,
+			Image:      cluster.Spec.PMM.Image,
+		}
+	}
+	if cluster.Spec.Backup != nil {
+		for _, schedule := range cluster.Spec.Backup.Schedule {
+			res.BackupSchedules = append(res.BackupSchedules, BackupSchedule{
+				Name:        schedule.Name,
+				Schedule:    schedule.Schedule,
+				Keep:        schedule.Keep,
+				StorageName: schedule.StorageName,
+			})
+		}
+		for storage := range cluster.Spec.Backup.Storages {
+			res.BackupStorages = append(res.BackupStorages, storage)
+		}
+		sort.Strings(res.BackupStorages)
+	}
+	return res, nil
+}
+
+// GetPSMDBCluster returns full spec and status of Percona Server for MongoDB cluster with a given name.
+// Cluster which custom resource is deleted but pods are not is returned in deleting state.
+// Empty namespace means the default one.
+func (c *K8sClient) GetPSMDBCluster(ctx context.Context, namespace, name string) (*PSMDBClusterDetails, error) {
+	var cluster psmdb.PerconaServerMongoDB
+	err := c.kube.Get(ctx, namespace, string(perconaServerMongoDBKind), name, &cluster)
+	if err != nil && !errors.Is(err, kubectl.ErrNotFound) {
+		return nil, errors.Wrapf(err, "cannot get PSMDB cluster %s", name)
+	}
+	pods, podsErr := c.GetPods(ctx, namespace, "-l", "app.kubernetes.io/instance="+name)
+
+	if err != nil {
+		if podsErr != nil || len(pods.Items) == 0 {
+			return nil, errors.Wrapf(ErrNotFound, "PSMDB cluster %s", name)
+		}
+		return &PSMDBClusterDetails{
+			PSMDBCluster: PSMDBCluster{
+				Name:          name,
+				State:         ClusterStateDeleting,
+				Replicaset:    new(Replicaset),
+				DetailedState: []appStatus{},
+			},
+		}, nil
+	}
+	if len(cluster.Spec.Replsets) == 0 {
+		return nil, errors.Errorf("PSMDB cluster %s has no replica sets", name)
+	}
+	if podsErr != nil {
+		c.l.Warnf("Cannot get pods of PSMDB cluster %s: %s.", name, podsErr)
+		pods = new(common.PodList)
+	}
+
+	clusters := []PSMDBCluster{c.getPSMDBCluster(&cluster, pods.Items)}
+	restores, err := c.ListPSMDBClusterRestores(ctx, namespace, name)
+	if err != nil {
+		c.l.Warnf("Cannot get PSMDB cluster restores: %s.", err)
+	}
+	setPSMDBRestoreStates(clusters, restores)
+
+	res := &PSMDBClusterDetails{
+		PSMDBCluster:  clusters[0],
+		CRVersion:     cluster.Spec.CRVersion,
+		Image:         cluster.Spec.Image,
+		BackupEnabled: cluster.Spec.Backup.Enabled,
+		PMM: ClusterPMM{
+			Enabled:    cluster.Spec.PMM.Enabled,
+			ServerHost: cluster.Spec.PMM.ServerHost,
+			Image:      cluster.Spec.PMM.Image,
+		},
+		BackupTasks:    []PSMDBBackupTask{},
+		BackupStorages: []string{},
+		Host:           cluster.Status.Host,
+		Endpoints:      c.getClusterEndpoints(ctx, namespace, name),
+	}
+	for _, task := range cluster.Spec.Backup.Tasks {
+		if !task.Enabled {
+			continue
+		}
+		res.BackupTasks = append(res.BackupTasks, PSMDBBackupTask{
+			Name:            task.Name,
+			Schedule:        task.Schedule,
+			Keep:            task.Keep,
+			StorageName:     task.StorageName,
+			CompressionType: string(task.CompressionType),
+		})
+	}
+	for storage := range cluster.Spec.Backup.Storages {
+		res.BackupStorages = append(res.BackupStorages, storage)
+	}
+	sort.Strings(res.BackupStorages)
+	return res, nil
+}
+
+// getClusterEndpoints returns endpoints of Kubernetes services of a given cluster sorted by service name.
+// Endpoints are used for details only, so errors are logged and not returned.
+func (c *K8sClient) getClusterEndpoints(ctx context.Context, namespace, name string) []Endpoint {
+	var list common.ServiceList
+	args := withNamespace([]string{"get", "services"}, namespace)
+	args = append(args, "-l", "app.kubernetes.io/instance="+name, "-ojson")
+	out, err := c.kube.Run(ctx, args, nil)
+	if err == nil {
+		err = json.Unmarshal(out, &list)
+	}
+	if err != nil {
+		c.l.Warnf("Cannot get services of cluster %s: %s.", name, err)
+		return []Endpoint{}
+	}
+	return serviceEndpoints(list.Items)
+}
+
+// serviceEndpoints converts given services to endpoints sorted by service name.
+func serviceEndpoints(services []common.Service) []Endpoint {
+	res := []Endpoint{}
+	for _, service := range services {
+		hosts := []string{""}
+		if service.Spec.Type == common.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) != 0 {
+			hosts = hosts[:0]
+			for _, ingress := range service.Status.LoadBalancer.Ingress {
+				host := ingress.Hostname
+				if host == "" {
+					host = ingress.IP
+				}
+				hosts = append(hosts, host)
+			}
+		}
+		for _, host := range hosts {
+			for _, port := range service.Spec.Ports {
+				res = append(res, Endpoint{
+					Service:  service.Name,
+					Type:     string(service.Spec.Type),
+					Host:     host,
+					Port:     port.Port,
+					NodePort: port.NodePort,
+				})
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Service < res[j].Service })
+	return res
+}
@@ -0,0 +1,136 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakeDetailsClient returns given objects by kind and name, and given output of `get` commands by resource.
+type fakeDetailsClient struct {
+	kubeClient
+	objects map[string]string
+	outputs map[string]string
+}
+
+func (f *fakeDetailsClient) Get(ctx context.Context, namespace, kind, name string, res interface{}) error {
+	obj, ok := f.objects[kind+"/"+name]
+	if !ok {
+		if name != "" {
+			return kubectl.ErrNotFound
+		}
+		obj = `{"items": []}`
+	}
+	return json.Unmarshal([]byte(obj), res)
+}
+
+func (f *fakeDetailsClient) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
+	out, ok := f.outputs[args[1]]
+	if !ok {
+		out = `{"items": []}`
+	}
+	return []byte(out), nil
+}
+
+func TestClusterDetails(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("XtraDB", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeDetailsClient{
+			objects: map[string]string{
+				string(perconaXtraDBClusterKind) + "/test": `{
+					"metadata": {"name": "test"},
+					"spec": {
+						"crVersion": "1.8.0",
+						"pxc": {"size": 3, "image": "percona/percona-xtradb-cluster:8.0.22-13.1"},
+						"haproxy": {"enabled": true, "size": 3, "image": "percona/percona-xtradb-cluster-operator:1.8.0-haproxy", "serviceType": "LoadBalancer"},
+						"pmm": {"enabled": true, "serverHost": "pmm.example.com", "image": "percona/pmm-client:2"},
+						"backup": {
+							"schedule": [{"name": "daily", "schedule": "0 0 * * *", "keep": 5, "storageName": "s3"}],
+							"storages": {"s3": {"type": "s3"}, "fs": {"type": "filesystem"}}
+						}
+					},
+					"status": {"state": "ready", "host": "test-haproxy.default", "pxc": {"size": 3, "ready": 3}, "haproxy": {"size": 3, "ready": 3}}
+				}`,
+			},
+			outputs: map[string]string{
+				"services": `{"items": [
+					{
+						"metadata": {"name": "test-haproxy"},
+						"spec": {"type": "LoadBalancer", "ports": [{"name": "mysql", "port": 3306, "nodePort": 31000}]},
+						"status": {"loadBalancer": {"ingress": [{"hostname": "lb.example.com"}]}}
+					},
+					{
+						"metadata": {"name": "test-pxc"},
+						"spec": {"type": "ClusterIP", "ports": [{"name": "mysql", "port": 3306}]}
+					}
+				]}`,
+			},
+		}
+		c := &K8sClient{kube: kube, l: logger.Get(ctx)}
+
+		cluster, err := c.GetXtraDBCluster(ctx, "", "test")
+		require.NoError(t, err)
+		assert.Equal(t, "test", cluster.Name)
+		assert.Equal(t, ClusterStateReady, cluster.State)
+		assert.True(t, cluster.Exposed)
+		assert.Equal(t, "1.8.0", cluster.CRVersion)
+		assert.Equal(t, ProxyTypeHAProxy, cluster.ProxyType)
+		assert.Equal(t, "percona/percona-xtradb-cluster:8.0.22-13.1", cluster.PXC.Image)
+		assert.Equal(t, "percona/percona-xtradb-cluster-operator:1.8.0-haproxy", cluster.HAProxy.Image)
+		assert.Equal(t, ClusterPMM{Enabled: true, ServerHost: "pmm.example.com", Image: "percona/pmm-client:2"}, cluster.PMM)
+		assert.Equal(t, []BackupSchedule{{Name: "daily", Schedule: "0 0 * * *", Keep: 5, StorageName: "s3"}}, cluster.BackupSchedules)
+		assert.Equal(t, []string{"fs", "s3"}, cluster.BackupStorages)
+		assert.Equal(t, "test-haproxy.default", cluster.Host)
+		expected := []Endpoint{
+			{Service: "test-haproxy", Type: "LoadBalancer", Host: "lb.example.com", Port: 3306, NodePort: 31000},
+			{Service: "test-pxc", Type: "ClusterIP", Port: 3306},
+		}
+		assert.Equal(t, expected, cluster.Endpoints)
+
+		_, err = c.GetXtraDBCluster(ctx, "", "missing")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Deleting", func(t *testing.T) {
+		t.Parallel()
+
+		pods := common.PodList{Items: []common.Pod{{ObjectMeta: common.ObjectMeta{Name: "test-rs0-0"}}}}
+		b, err := json.Marshal(pods)
+		require.NoError(t, err)
+		kube := &fakeDetailsClient{outputs: map[string]string{"pods": string(b)}}
+		c := &K8sClient{kube: kube, l: logger.Get(ctx)}
+
+		cluster, err := c.GetPSMDBCluster(ctx, "", "test")
+		require.NoError(t, err)
+		assert.Equal(t, "test", cluster.Name)
+		assert.Equal(t, ClusterStateDeleting, cluster.State)
+	})
+}
@@ -0,0 +1,249 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+// normalWaitingReasons are container waiting reasons of a normally starting pod.
+var normalWaitingReasons = map[string]struct{}{ //nolint:gochecknoglobals
+	"ContainerCreating": {},
+	"PodInitializing":   {},
+}
+
+// ComponentStatus contains status of a cluster component reported by the operator.
+type ComponentStatus struct {
+	Name    string
+	State   string
+	Size    int32
+	Ready   int32
+	Message string
+	Image   string
+	Version string
+}
+
+// ClusterCondition is a cluster condition reported by the operator.
+type ClusterCondition struct {
+	Type               string
+	Status             string
+	Reason             string
+	Message            string
+	LastTransitionTime time.Time
+}
+
+// PodProblem explains why a pod of a cluster is not ready.
+type PodProblem struct {
+	Pod string
+	// Container is empty for problems of the whole pod like Unschedulable.
+	Container string
+	// Reason is a Kubernetes reason like CrashLoopBackOff, ImagePullBackOff or Unschedulable.
+	Reason   string
+	Message  string
+	Restarts int32
+}
+
+// ClusterHealth contains detailed health of a cluster.
+type ClusterHealth struct {
+	Components []ComponentStatus
+	// Conditions contains condition history, the latest condition is the last one.
+	Conditions []ClusterCondition
+	// PodProblems is empty if all pods of the cluster are running or starting normally.
+	PodProblems []PodProblem
+}
+
+// clusterPodsKey returns a key of cluster pods returned by getClusterPods.
+func clusterPodsKey(namespace, clusterName string) string {
+	return namespace + "/" + clusterName
+}
+
+// getClusterPods returns pods managed by a given operator keyed by cluster namespace and name, see clusterPodsKey.
+// They are listed once for cluster details and clusters which are not fully deleted yet.
+func (c *K8sClient) getClusterPods(ctx context.Context, namespace, managedBy string) (map[string][]common.Pod, error) {
+	pods, err := c.GetPods(ctx, namespace, "-l", "app.kubernetes.io/managed-by="+managedBy)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]common.Pod)
+	for _, pod := range pods.Items {
+		key := clusterPodsKey(pod.Namespace, pod.Labels["app.kubernetes.io/instance"])
+		res[key] = append(res[key], pod)
+	}
+	return res, nil
+}
+
+// getPXCHealth returns detailed health of a given XtraDB cluster.
+func getPXCHealth(cluster *pxc.PerconaXtraDBCluster, pods []common.Pod) *ClusterHealth {
+	res := &ClusterHealth{
+		PodProblems: getPodProblems(pods),
+	}
+
+	for _, component := range []struct {
+		name   string
+		status pxc.AppStatus
+	}{
+		{"pxc", cluster.Status.PXC},
+		{"proxysql", cluster.Status.ProxySQL},
+		{"haproxy", cluster.Status.HAProxy},
+		{"pmm", cluster.Status.PMM},
+		{"backup", cluster.Status.Backup},
+	} {
+		if component.status == (pxc.AppStatus{}) {
+			continue
+		}
+		res.Components = append(res.Components, ComponentStatus{
+			Name:    component.name,
+			State:   string(component.status.Status),
+			Size:    component.status.Size,
+			Ready:   component.status.Ready,
+			Message: component.status.Message,
+			Image:   component.status.Image,
+			Version: component.status.Version,
+		})
+	}
+
+	for _, condition := range cluster.Status.Conditions {
+		res.Conditions = append(res.Conditions, newClusterCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+	return res
+}
+
+// getPSMDBHealth returns detailed health of a given PSMDB cluster.
+func getPSMDBHealth(cluster *psmdb.PerconaServerMongoDB, pods []common.Pod) *ClusterHealth {
+	res := &ClusterHealth{
+		PodProblems: getPodProblems(pods),
+	}
+
+	names := make([]string, 0, len(cluster.Status.Replsets))
+	for name := range cluster.Status.Replsets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rs := cluster.Status.Replsets[name]
+		if rs == nil {
+			continue
+		}
+		component := ComponentStatus{
+			Name:    name,
+			State:   string(rs.Status),
+			Size:    rs.Size,
+			Ready:   rs.Ready,
+			Message: rs.Message,
+			Image:   cluster.Spec.Image,
+		}
+		for _, member := range rs.Members {
+			if member != nil && member.Version != "" {
+				component.Version = member.Version
+				break
+			}
+		}
+		res.Components = append(res.Components, component)
+	}
+	if cluster.Status.Mongos.Size > 0 {
+		res.Components = append(res.Components, ComponentStatus{
+			Name:  "mongos",
+			State: string(cluster.Status.Mongos.Status),
+			Size:  cluster.Status.Mongos.Size,
+			Ready: cluster.Status.Mongos.Ready,
+			Image: cluster.Spec.Image,
+		})
+	}
+
+	for _, condition := range cluster.Status.Conditions {
+		res.Conditions = append(res.Conditions, newClusterCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+	return res
+}
+
+// newClusterCondition returns a cluster condition from a condition reported by the operator.
+func newClusterCondition(conditionType, status, reason, message string, lastTransitionTime *time.Time) ClusterCondition {
+	res := ClusterCondition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	if lastTransitionTime != nil {
+		res.LastTransitionTime = *lastTransitionTime
+	}
+	return res
+}
+
+// getPodProblems returns problems of given pods sorted by pod name.
+func getPodProblems(pods []common.Pod) []PodProblem {
+	var res []PodProblem
+	for _, pod := range pods {
+		res = append(res, getPodProblem(pod)...)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Pod < res[j].Pod })
+	return res
+}
+
+// getPodProblem returns problems of a given pod: failed or unscheduled pod,
+// waiting containers with abnormal reasons and failed containers.
+func getPodProblem(pod common.Pod) []PodProblem {
+	if pod.Status.Phase == common.PodPhaseFailed {
+		return []PodProblem{{Pod: pod.Name, Reason: pod.Status.Reason, Message: pod.Status.Message}}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == "PodScheduled" && condition.Status == "False" {
+			return []PodProblem{{Pod: pod.Name, Reason: condition.Reason, Message: condition.Message}}
+		}
+	}
+
+	var res []PodProblem
+	statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) //nolint:gocritic
+	for _, status := range statuses {
+		problem := PodProblem{
+			Pod:       pod.Name,
+			Container: status.Name,
+			Restarts:  status.RestartCount,
+		}
+		waiting, isWaiting := status.State[string(common.ContainerStateWaiting)]
+		terminated, isTerminated := status.State[string(common.ContainerStateTerminated)]
+		switch {
+		case isWaiting:
+			if _, ok := normalWaitingReasons[waiting.Reason]; ok || waiting.Reason == "" {
+				continue
+			}
+			problem.Reason = waiting.Reason
+			problem.Message = waiting.Message
+			if last, ok := status.LastTerminationState[string(common.ContainerStateTerminated)]; ok && problem.Message == "" {
+				problem.Message = fmt.Sprintf("Last terminated with %s, exit code %d.", last.Reason, last.ExitCode)
+			}
+		case isTerminated && terminated.ExitCode != 0:
+			problem.Reason = terminated.Reason
+			problem.Message = terminated.Message
+		default:
+			continue
+		}
+		res = append(res, problem)
+	}
+	return res
+}
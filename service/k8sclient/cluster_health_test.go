@@ -0,0 +1,153 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+func TestClusterHealth(t *testing.T) {
+	t.Parallel()
+
+	t.Run("PodProblems", func(t *testing.T) {
+		t.Parallel()
+
+		// trimmed output of kubectl get pods -o json
+		data := []byte(`{"items": [
+			{
+				"metadata": {"name": "test-pxc-1"},
+				"status": {
+					"phase": "Running",
+					"initContainerStatuses": [
+						{"name": "pxc-init", "state": {"terminated": {"reason": "Completed"}}}
+					],
+					"containerStatuses": [
+						{"name": "pmm-client", "ready": true, "state": {"running": {}}},
+						{
+							"name": "pxc",
+							"restartCount": 5,
+							"state": {"waiting": {"reason": "CrashLoopBackOff"}},
+							"lastState": {"terminated": {"reason": "OOMKilled", "exitCode": 137}}
+						}
+					]
+				}
+			},
+			{
+				"metadata": {"name": "test-pxc-0"},
+				"status": {
+					"phase": "Pending",
+					"containerStatuses": [
+						{"name": "pxc", "state": {"waiting": {"reason": "ImagePullBackOff", "message": "Back-off pulling image"}}},
+						{"name": "pmm-client", "state": {"waiting": {"reason": "ContainerCreating"}}}
+					]
+				}
+			},
+			{
+				"metadata": {"name": "test-pxc-2"},
+				"status": {
+					"phase": "Pending",
+					"conditions": [
+						{"type": "PodScheduled", "status": "False", "reason": "Unschedulable", "message": "0/3 nodes are available"}
+					]
+				}
+			},
+			{
+				"metadata": {"name": "test-haproxy-0"},
+				"status": {"phase": "Failed", "reason": "Evicted", "message": "The node was low on resource: memory."}
+			}
+		]}`)
+		var pods common.PodList
+		require.NoError(t, json.Unmarshal(data, &pods))
+
+		expected := []PodProblem{
+			{Pod: "test-haproxy-0", Reason: "Evicted", Message: "The node was low on resource: memory."},
+			{Pod: "test-pxc-0", Container: "pxc", Reason: "ImagePullBackOff", Message: "Back-off pulling image"},
+			{Pod: "test-pxc-1", Container: "pxc", Reason: "CrashLoopBackOff", Message: "Last terminated with OOMKilled, exit code 137.", Restarts: 5},
+			{Pod: "test-pxc-2", Reason: "Unschedulable", Message: "0/3 nodes are available"},
+		}
+		assert.Equal(t, expected, getPodProblems(pods.Items))
+	})
+
+	t.Run("PXC", func(t *testing.T) {
+		t.Parallel()
+
+		transition := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+		cluster := &pxc.PerconaXtraDBCluster{
+			Status: pxc.PerconaXtraDBClusterStatus{
+				PXC: pxc.AppStatus{
+					Size: 3, Ready: 2, Status: pxc.AppStateInit,
+					Image: "percona/percona-xtradb-cluster:8.0.22-13.1", Version: "8.0.22-13.1",
+				},
+				HAProxy: pxc.AppStatus{Size: 3, Ready: 3, Status: pxc.AppStateReady},
+				Conditions: []pxc.ClusterCondition{
+					{Type: "initializing", Status: "True", LastTransitionTime: &transition},
+					{Type: "Error", Status: "True", Message: "pxc: back-off pulling image"},
+				},
+			},
+		}
+
+		health := getPXCHealth(cluster, nil)
+		expected := &ClusterHealth{
+			Components: []ComponentStatus{
+				{
+					Name: "pxc", State: "initializing", Size: 3, Ready: 2,
+					Image: "percona/percona-xtradb-cluster:8.0.22-13.1", Version: "8.0.22-13.1",
+				},
+				{Name: "haproxy", State: "ready", Size: 3, Ready: 3},
+			},
+			Conditions: []ClusterCondition{
+				{Type: "initializing", Status: "True", LastTransitionTime: transition},
+				{Type: "Error", Status: "True", Message: "pxc: back-off pulling image"},
+			},
+		}
+		assert.Equal(t, expected, health)
+	})
+
+	t.Run("ClusterPods", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		// clusters with the same name in different namespaces
+		kube := &fakeDetailsClient{outputs: map[string]string{"pods": `{"items": [
+			{"metadata": {"name": "test-pxc-0", "namespace": "ns1", "labels": {"app.kubernetes.io/instance": "test"}}},
+			{"metadata": {"name": "test-pxc-0", "namespace": "ns2", "labels": {"app.kubernetes.io/instance": "test"}}},
+			{"metadata": {"name": "test-pxc-1", "namespace": "ns2", "labels": {"app.kubernetes.io/instance": "test"}}}
+		]}`}}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		pods, err := client.getClusterPods(ctx, "", pxcOperatorDeploymentName)
+		require.NoError(t, err)
+		assert.Len(t, pods[clusterPodsKey("ns1", "test")], 1)
+		assert.Len(t, pods[clusterPodsKey("ns2", "test")], 2)
+
+		// pods of a deleted cluster are left
+		deleting := getDeletingXtraDBClusters([]XtraDBCluster{{Name: "other"}}, pods)
+		require.Len(t, deleting, 1)
+		assert.Equal(t, "test", deleting[0].Name)
+		assert.Equal(t, ClusterStateDeleting, deleting[0].State)
+		assert.Empty(t, getDeletingXtraDBClusters([]XtraDBCluster{{Name: "test"}}, pods))
+	})
+}
@@ -35,10 +35,27 @@ type HostPathVolumeSource struct {
 // https://pkg.go.dev/k8s.io/api/core/v1#EmptyDirVolumeSource
 type EmptyDirVolumeSource struct{}
 
+// ContainerStateDetails contains details of container's state.
+type ContainerStateDetails struct {
+	// Reason is a brief CamelCase reason like CrashLoopBackOff or OOMKilled.
+	Reason string `json:"reason,omitempty"`
+	// Message regarding the state.
+	Message string `json:"message,omitempty"`
+	// Exit status of the terminated container.
+	ExitCode int32 `json:"exitCode,omitempty"`
+}
+
 // ContainerStatus contains container's status.
 type ContainerStatus struct {
-	Name  string              `json:"name,omitempty"`
-	State map[string]struct{} `json:"state,omitempty"`
+	Name string `json:"name,omitempty"`
+	// State maps container state (waiting, running or terminated) to its details.
+	State map[string]ContainerStateDetails `json:"state,omitempty"`
+	// LastTerminationState contains details about the container's last termination.
+	LastTerminationState map[string]ContainerStateDetails `json:"lastState,omitempty"`
+	// Ready specifies whether the container has passed its readiness probe.
+	Ready bool `json:"ready,omitempty"`
+	// RestartCount is the number of times the container has been restarted.
+	RestartCount int32 `json:"restartCount,omitempty"`
 }
 
 // ContainerSpec represents a container definition.
@@ -135,6 +152,40 @@ type PodStatus struct {
 
 	// Phase holds pod's phase.
 	Phase PodPhase `json:"phase,omitempty"`
+
+	// Current service state of pod.
+	Conditions []PodCondition `json:"conditions,omitempty"`
+
+	// A brief CamelCase message indicating details about why the pod is in this state.
+	Reason string `json:"reason,omitempty"`
+
+	// A human readable message indicating details about why the pod is in this condition.
+	Message string `json:"message,omitempty"`
+}
+
+// PodCondition contains details for the current condition of this pod.
+type PodCondition struct {
+	// Type is the type of the condition like PodScheduled or Ready.
+	Type string `json:"type"`
+	// Status is the status of the condition: True, False or Unknown.
+	Status string `json:"status"`
+	// Unique, one-word, CamelCase reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+	// Human-readable message indicating details about last transition.
+	Message string `json:"message,omitempty"`
+}
+
+// IsPodReady returns true if pod is running and all its containers are ready.
+func IsPodReady(pod Pod) bool {
+	if pod.Status.Phase != PodPhaseRunning || len(pod.Status.ContainerStatuses) == 0 {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if !status.Ready {
+			return false
+		}
+	}
+	return true
 }
 
 // Pod is a collection of containers that can run on a host. This resource is created
@@ -156,6 +207,72 @@ type Pod struct {
 	Status PodStatus `json:"status,omitempty"`
 }
 
+// Service is a named abstraction of software service consisting of local port
+// that the proxy listens on, and the selector that determines which pods will answer requests.
+//
+// https://pkg.go.dev/k8s.io/api/core/v1#Service
+type Service struct {
+	TypeMeta // anonymous for embedding
+
+	// Standard object's metadata.
+	ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the behavior of a service.
+	Spec ServiceSpec `json:"spec,omitempty"`
+
+	// Status represents the current status of a service.
+	Status ServiceStatus `json:"status,omitempty"`
+}
+
+// ServiceSpec describes the attributes that a user creates on a service.
+type ServiceSpec struct {
+	// Type determines how the Service is exposed.
+	Type ServiceType `json:"type,omitempty"`
+
+	// The list of ports that are exposed by this service.
+	Ports []ServicePort `json:"ports,omitempty"`
+}
+
+// ServicePort contains information on service's port.
+type ServicePort struct {
+	// The name of this port within the service.
+	Name string `json:"name,omitempty"`
+
+	// The port that will be exposed by this service.
+	Port int32 `json:"port"`
+
+	// The port on each node on which this service is exposed when type is NodePort or LoadBalancer.
+	NodePort int32 `json:"nodePort,omitempty"`
+}
+
+// ServiceStatus represents the current status of a service.
+type ServiceStatus struct {
+	// LoadBalancer contains the current status of the load-balancer, if one is present.
+	LoadBalancer LoadBalancerStatus `json:"loadBalancer,omitempty"`
+}
+
+// LoadBalancerStatus represents the status of a load-balancer.
+type LoadBalancerStatus struct {
+	// Ingress is a list containing ingress points for the load-balancer.
+	Ingress []LoadBalancerIngress `json:"ingress,omitempty"`
+}
+
+// LoadBalancerIngress represents the status of a load-balancer ingress point.
+type LoadBalancerIngress struct {
+	// IP is set for load-balancer ingress points that are IP based.
+	IP string `json:"ip,omitempty"`
+
+	// Hostname is set for load-balancer ingress points that are DNS based.
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// ServiceList holds a list of services.
+type ServiceList struct {
+	TypeMeta // anonymous for embedding
+
+	Items []Service `json:"items"`
+}
+
 // Secret holds secret data of a certain type. The total bytes of the values in
 // the Data field must be less than 1024 * 1024 bytes.
 type Secret struct {
@@ -245,7 +362,9 @@ type Image struct {
 
 // NodeSpec holds Kubernetes node specification.
 type NodeSpec struct {
-	Taints []Taint `json:"taints,omitempty"`
+	// ID of the node assigned by the cloud provider in the format: <ProviderName>://<ProviderSpecificNodeID>.
+	ProviderID string  `json:"providerID,omitempty"`
+	Taints     []Taint `json:"taints,omitempty"`
 }
 
 // Node holds information about Kubernetes node.
@@ -276,3 +395,84 @@ type PersistentVolume struct {
 	// Specification of the volume.
 	Spec PersistentVolumeSpec `json:"spec,omitempty"`
 }
+
+// PersistentVolumeClaimConditionType is a valid value of PersistentVolumeClaimCondition.Type.
+type PersistentVolumeClaimConditionType string
+
+const (
+	// PersistentVolumeClaimResizing means a user trigger resize of pvc has been started.
+	PersistentVolumeClaimResizing PersistentVolumeClaimConditionType = "Resizing"
+	// PersistentVolumeClaimFileSystemResizePending means controller resize is finished
+	// and a file system resize is pending on node.
+	PersistentVolumeClaimFileSystemResizePending PersistentVolumeClaimConditionType = "FileSystemResizePending"
+)
+
+// PersistentVolumeClaimCondition contains details about state of pvc.
+type PersistentVolumeClaimCondition struct {
+	Type   PersistentVolumeClaimConditionType `json:"type"`
+	Status string                             `json:"status"`
+	// Unique, this should be a short, machine understandable string that gives the reason
+	// for condition's last transition.
+	Reason string `json:"reason,omitempty"`
+	// Human-readable message indicating details about last transition.
+	Message string `json:"message,omitempty"`
+}
+
+// PersistentVolumeClaimStatus holds the current status of PVC.
+type PersistentVolumeClaimStatus struct {
+	// Capacity represents the actual resources of the underlying volume.
+	Capacity PersistentVolumeCapacity `json:"capacity,omitempty"`
+	// Current Condition of persistent volume claim.
+	Conditions []PersistentVolumeClaimCondition `json:"conditions,omitempty"`
+}
+
+// PersistentVolumeClaim holds information about PVC.
+type PersistentVolumeClaim struct {
+	TypeMeta
+	ObjectMeta `json:"metadata,omitempty"`
+	// Specification of the claim.
+	Spec PersistentVolumeClaimSpec `json:"spec,omitempty"`
+	// Status of the claim.
+	Status PersistentVolumeClaimStatus `json:"status,omitempty"`
+}
+
+// PersistentVolumeClaimList holds a list of PVC objects.
+type PersistentVolumeClaimList struct {
+	TypeMeta // anonymous for embedding
+
+	Items []PersistentVolumeClaim `json:"items"`
+}
+
+// Extracted from https://pkg.go.dev/k8s.io/api/storage/v1#StorageClass
+
+// StorageClass describes the parameters for a class of storage for
+// which PersistentVolumes can be dynamically provisioned.
+type StorageClass struct {
+	TypeMeta // anonymous for embedding
+
+	// Standard object's metadata.
+	ObjectMeta `json:"metadata,omitempty"`
+
+	// Provisioner indicates the type of the provisioner.
+	Provisioner string `json:"provisioner"`
+
+	// Parameters holds the parameters for the provisioner that should create volumes of this storage class.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// ReclaimPolicy of dynamically provisioned PersistentVolumes: Delete or Retain.
+	// Defaults to Delete.
+	ReclaimPolicy string `json:"reclaimPolicy,omitempty"`
+
+	// AllowVolumeExpansion shows whether the storage class allow volume expand.
+	AllowVolumeExpansion *bool `json:"allowVolumeExpansion,omitempty"`
+
+	// VolumeBindingMode indicates how PersistentVolumeClaims should be provisioned and bound.
+	VolumeBindingMode string `json:"volumeBindingMode,omitempty"`
+}
+
+// StorageClassList is a collection of storage classes.
+type StorageClassList struct {
+	TypeMeta // anonymous for embedding
+
+	Items []StorageClass `json:"items"`
+}
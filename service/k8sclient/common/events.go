@@ -0,0 +1,88 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import "time"
+
+// Extracted from https://pkg.go.dev/k8s.io/api/core/v1#Event
+
+// EventTypeWarning is a type of events about possible problems.
+const EventTypeWarning = "Warning"
+
+// Event is a report of an event somewhere in the cluster.
+type Event struct {
+	TypeMeta // anonymous for embedding
+
+	// Standard object's metadata.
+	ObjectMeta `json:"metadata,omitempty"`
+
+	// The object that this event is about.
+	InvolvedObject ObjectReference `json:"involvedObject"`
+
+	// This should be a short, machine understandable string that gives the reason
+	// for the transition into the object's current status.
+	Reason string `json:"reason,omitempty"`
+
+	// A human-readable description of the status of this operation.
+	Message string `json:"message,omitempty"`
+
+	// The component reporting this event.
+	Source EventSource `json:"source,omitempty"`
+
+	// The time at which the event was first recorded.
+	FirstTimestamp *time.Time `json:"firstTimestamp,omitempty"`
+
+	// The time at which the most recent occurrence of this event was recorded.
+	LastTimestamp *time.Time `json:"lastTimestamp,omitempty"`
+
+	// The number of times this event has occurred.
+	Count int32 `json:"count,omitempty"`
+
+	// Type of this event (Normal, Warning), new types could be added in the future.
+	Type string `json:"type,omitempty"`
+
+	// Time when this Event was first observed, it is set by new event clients instead of timestamps.
+	EventTime *time.Time `json:"eventTime,omitempty"`
+}
+
+// ObjectReference contains enough information to let you inspect or modify the referred object.
+type ObjectReference struct {
+	// Kind of the referent.
+	Kind string `json:"kind,omitempty"`
+
+	// Namespace of the referent.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the referent.
+	Name string `json:"name,omitempty"`
+}
+
+// EventSource contains information for an event.
+type EventSource struct {
+	// Component from which the event is generated.
+	Component string `json:"component,omitempty"`
+
+	// Node name on which the event is generated.
+	Host string `json:"host,omitempty"`
+}
+
+// EventList is a list of events.
+type EventList struct {
+	TypeMeta // anonymous for embedding
+
+	Items []Event `json:"items"`
+}
@@ -40,6 +40,8 @@ type PersistentVolumeClaimSpec struct {
 	// Resources represents the minimum resources the volume should have.
 	// More info: https://kubernetes.io/docs/concepts/storage/persistent-volumes#resources
 	Resources ResourceRequirements `json:"resources,omitempty"`
+	// Name of the StorageClass required by the claim, nil means the default one.
+	StorageClassName *string `json:"storageClassName,omitempty"`
 }
 
 // ResourceRequirements describes the compute resource requirements.
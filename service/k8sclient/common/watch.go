@@ -0,0 +1,77 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Extracted from https://pkg.go.dev/k8s.io/apimachinery/pkg/apis/meta/v1#WatchEvent
+
+// WatchEventType defines the type of watch event.
+type WatchEventType string
+
+const (
+	// WatchEventAdded is sent for a new object and for all existing objects when the watch starts.
+	WatchEventAdded WatchEventType = "ADDED"
+	// WatchEventModified is sent when an object is changed.
+	WatchEventModified WatchEventType = "MODIFIED"
+	// WatchEventDeleted is sent when an object is deleted, it contains the last state of the object.
+	WatchEventDeleted WatchEventType = "DELETED"
+	// WatchEventBookmark contains only resource version of the watched objects.
+	WatchEventBookmark WatchEventType = "BOOKMARK"
+	// WatchEventError contains Status object, the watch is closed after it.
+	WatchEventError WatchEventType = "ERROR"
+)
+
+// WatchEvent represents a single event of a watched resource.
+type WatchEvent struct {
+	Type WatchEventType `json:"type"`
+
+	// Object is the changed object for ADDED, MODIFIED and DELETED events,
+	// Status object for ERROR events.
+	Object json.RawMessage `json:"object"`
+}
+
+// DecodeWatchEvents decodes stream of JSON watch events and sends them to a given channel
+// until the stream is closed or ctx is canceled.
+func DecodeWatchEvents(ctx context.Context, r io.Reader, events chan<- WatchEvent) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var event WatchEvent
+		err := decoder.Decode(&event)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.Wrap(err, "cannot decode watch event")
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+)
+
+// followLogsRetryInterval is a time between attempts to follow logs of a restarted or not started container.
+const followLogsRetryInterval = 2 * time.Second
+
+// FollowLogsParams contains parameters of container logs streaming.
+type FollowLogsParams struct {
+	// Namespace of the cluster, empty namespace means the default one.
+	Namespace   string
+	ClusterName string
+	// Selector is an additional label selector of cluster pods, for example app.kubernetes.io/component=pxc.
+	Selector string
+	// Containers to stream logs of, empty means all regular containers of selected pods.
+	// Init containers are streamed only if requested by name.
+	Containers []string
+	// SinceTime streams logs newer than a given time, zero means all logs or TailLines last lines.
+	SinceTime time.Time
+	// TailLines is a number of last lines to start with if SinceTime is not set, 0 means all lines.
+	TailLines int64
+	// Previous returns logs of the previous terminated container instances, for example after a crash.
+	// Logs are not followed in that case.
+	Previous bool
+}
+
+// LogLine is a single line of container logs.
+type LogLine struct {
+	Pod       string
+	Container string
+	// Time is a timestamp added to the line by Kubernetes.
+	Time time.Time
+	Line string
+}
+
+// logTarget is a container to stream logs of.
+type logTarget struct {
+	pod       string
+	container string
+}
+
+// FollowLogs streams logs of containers of a given cluster to `lines`. Containers restarted during
+// streaming are followed again, pods created after the start are not followed.
+// Terminated containers are followed until their pods are deleted, as cluster pods always restart them.
+// It blocks until all followed pods are deleted, or ctx is canceled.
+// With Previous set it returns once previous logs of all containers are sent.
+func (c *K8sClient) FollowLogs(ctx context.Context, params *FollowLogsParams, lines chan<- LogLine) error {
+	selector := "app.kubernetes.io/instance=" + params.ClusterName
+	if params.Selector != "" {
+		selector += "," + params.Selector
+	}
+	pods, err := c.GetPods(ctx, params.Namespace, "-l", selector)
+	if err != nil {
+		return err
+	}
+	targets := logTargets(pods.Items, params.Containers)
+	if len(targets) == 0 {
+		return errors.Errorf("no containers of cluster %s match given filters", params.ClusterName)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(targets))
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target logTarget) {
+			defer wg.Done()
+			errs <- c.followContainerLogs(ctx, params, target, lines)
+		}(target)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logTargets returns containers of given pods with given names, all regular containers if names are empty.
+func logTargets(pods []common.Pod, containers []string) []logTarget {
+	var res []logTarget
+	for _, pod := range pods {
+		if len(containers) == 0 {
+			for _, container := range pod.Spec.Containers {
+				res = append(res, logTarget{pod: pod.Name, container: container.Name})
+			}
+			continue
+		}
+
+		all := append(pod.Spec.InitContainers, pod.Spec.Containers...) //nolint:gocritic
+		for _, container := range all {
+			if contains(containers, container.Name) {
+				res = append(res, logTarget{pod: pod.Name, container: container.Name})
+			}
+		}
+	}
+	return res
+}
+
+// followContainerLogs streams logs of a given container, following it again after restarts
+// until the pod is deleted or ctx is canceled.
+func (c *K8sClient) followContainerLogs(ctx context.Context, params *FollowLogsParams, target logTarget, lines chan<- LogLine) error {
+	w := &logLineWriter{
+		ctx:       ctx,
+		pod:       target.pod,
+		container: target.container,
+		lines:     lines,
+	}
+	for {
+		args := withNamespace([]string{"logs", target.pod, target.container, "--timestamps"}, params.Namespace)
+		since := params.SinceTime
+		if !w.last.IsZero() {
+			since = w.last
+		}
+		switch {
+		case params.Previous:
+			args = append(args, "--previous")
+		case !since.IsZero():
+			args = append(args, "--follow", "--since-time="+since.Format(time.RFC3339Nano))
+		case params.TailLines > 0:
+			args = append(args, "--follow", "--tail="+strconv.FormatInt(params.TailLines, 10))
+		default:
+			args = append(args, "--follow")
+		}
+
+		w.skipUntil = w.last
+		err := c.kube.Stream(ctx, args, w)
+		if flushErr := w.flush(); err == nil {
+			err = flushErr
+		}
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case params.Previous:
+			return errors.Wrapf(err, "cannot get previous logs of %s/%s", target.pod, target.container)
+		case errors.Is(err, kubectl.ErrNotFound):
+			c.l.Debugf("Pod %s is deleted, logs of %s are not followed.", target.pod, target.container)
+			return nil
+		case err != nil:
+			c.l.Debugf("Cannot follow logs of %s/%s: %s.", target.pod, target.container, err)
+		}
+
+		select {
+		case <-time.After(followLogsRetryInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// logLineWriter splits container logs with timestamps to lines and sends them to a channel.
+type logLineWriter struct {
+	ctx       context.Context
+	pod       string
+	container string
+	lines     chan<- LogLine
+	buf       []byte
+	// last is the timestamp of the last sent line.
+	last time.Time
+	// skipUntil skips lines already sent before logs were followed again.
+	skipUntil time.Time
+}
+
+// Write implements io.Writer.
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(w.buf[:i])
+		w.buf = w.buf[i+1:]
+		if err := w.send(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// flush sends the last incomplete line.
+func (w *logLineWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := string(w.buf)
+	w.buf = nil
+	return w.send(line)
+}
+
+// send parses a timestamp of a given line and sends the line.
+func (w *logLineWriter) send(line string) error {
+	res := LogLine{
+		Pod:       w.pod,
+		Container: w.container,
+		Line:      line,
+	}
+	if i := strings.IndexByte(line, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, line[:i]); err == nil {
+			if !t.After(w.skipUntil) {
+				return nil
+			}
+			w.skipUntil = time.Time{}
+			res.Time = t
+			res.Line = line[i+1:]
+			w.last = t
+		}
+	}
+
+	select {
+	case w.lines <- res:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
@@ -0,0 +1,127 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakeLogsClient returns given pods and writes given outputs for subsequent logs streams.
+type fakeLogsClient struct {
+	kubeClient
+	pods    string
+	m       sync.Mutex
+	streams [][]string
+	outputs []string
+}
+
+func (f *fakeLogsClient) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
+	return []byte(f.pods), nil
+}
+
+func (f *fakeLogsClient) Stream(ctx context.Context, args []string, w io.Writer) error {
+	f.m.Lock()
+	i := len(f.streams)
+	f.streams = append(f.streams, args)
+	f.m.Unlock()
+
+	if i >= len(f.outputs) {
+		return kubectl.ErrNotFound
+	}
+	_, err := io.WriteString(w, f.outputs[i])
+	return err
+}
+
+func TestFollowLogs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Targets", func(t *testing.T) {
+		t.Parallel()
+
+		pods := []common.Pod{{
+			ObjectMeta: common.ObjectMeta{Name: "test-pxc-0"},
+			Spec: common.PodSpec{
+				InitContainers: []common.ContainerSpec{{Name: "pxc-init"}},
+				Containers:     []common.ContainerSpec{{Name: "pxc"}, {Name: "pmm-client"}},
+			},
+		}}
+		assert.Equal(t, []logTarget{{"test-pxc-0", "pxc"}, {"test-pxc-0", "pmm-client"}}, logTargets(pods, nil))
+		assert.Equal(t, []logTarget{{"test-pxc-0", "pxc-init"}, {"test-pxc-0", "pxc"}}, logTargets(pods, []string{"pxc", "pxc-init"}))
+		assert.Empty(t, logTargets(pods, []string{"haproxy"}))
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeLogsClient{
+			pods: `{"items": [{"metadata": {"name": "test-pxc-0"}, "spec": {"containers": [{"name": "pxc"}]}}]}`,
+			outputs: []string{
+				"2021-06-01T10:00:00.000000001Z starting\n2021-06-01T10:00:01.000000001Z crashed",
+				"2021-06-01T10:00:01.000000001Z crashed\n2021-06-01T10:00:05.000000001Z joined the cluster\n",
+			},
+		}
+		ctx := context.Background()
+		c := &K8sClient{kube: kube, l: logger.Get(ctx)}
+
+		lines := make(chan LogLine, 10)
+		err := c.FollowLogs(ctx, &FollowLogsParams{ClusterName: "test", TailLines: 100}, lines)
+		require.NoError(t, err)
+		close(lines)
+
+		var actual []string
+		for line := range lines {
+			assert.Equal(t, "test-pxc-0", line.Pod)
+			assert.Equal(t, "pxc", line.Container)
+			actual = append(actual, line.Time.Format(time.RFC3339)+" "+line.Line)
+		}
+		expected := []string{
+			"2021-06-01T10:00:00Z starting",
+			"2021-06-01T10:00:01Z crashed",
+			"2021-06-01T10:00:05Z joined the cluster",
+		}
+		assert.Equal(t, expected, actual)
+
+		require.Len(t, kube.streams, 3)
+		assert.Equal(t, "logs test-pxc-0 pxc --timestamps --follow --tail=100", strings.Join(kube.streams[0], " "))
+		assert.Equal(t, "logs test-pxc-0 pxc --timestamps --follow --since-time=2021-06-01T10:00:01.000000001Z", strings.Join(kube.streams[1], " "))
+	})
+
+	t.Run("Previous", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeLogsClient{pods: `{"items": [{"metadata": {"name": "test-pxc-0"}, "spec": {"containers": [{"name": "pxc"}]}}]}`}
+		ctx := context.Background()
+		c := &K8sClient{kube: kube, l: logger.Get(ctx)}
+
+		err := c.FollowLogs(ctx, &FollowLogsParams{ClusterName: "test", Previous: true}, make(chan LogLine))
+		assert.True(t, errors.Is(err, kubectl.ErrNotFound))
+		assert.Equal(t, "logs test-pxc-0 pxc --timestamps --previous", strings.Join(kube.streams[0], " "))
+	})
+}
@@ -0,0 +1,199 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/AlekSi/pointer"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var (
+	// mycnfSectionRe matches my.cnf section header like [mysqld].
+	mycnfSectionRe = regexp.MustCompile(`^\[[A-Za-z0-9_.-]+\]$`) //nolint:gochecknoglobals
+	// mycnfOptionRe matches my.cnf option like max_connections=100 or skip-name-resolve.
+	mycnfOptionRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*(\s*=.*)?$`) //nolint:gochecknoglobals
+)
+
+// haproxySections are keywords starting sections of haproxy.cfg.
+var haproxySections = map[string]struct{}{ //nolint:gochecknoglobals
+	"global":      {},
+	"defaults":    {},
+	"frontend":    {},
+	"backend":     {},
+	"listen":      {},
+	"resolvers":   {},
+	"userlist":    {},
+	"peers":       {},
+	"mailers":     {},
+	"program":     {},
+	"http-errors": {},
+	"ring":        {},
+	"cache":       {},
+}
+
+// validatePXCConfiguration checks my.cnf fragment for syntax errors.
+func validatePXCConfiguration(configuration string) error {
+	section := false
+	for i, line := range strings.Split(configuration, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "!include"):
+			continue
+		case strings.HasPrefix(line, "["):
+			if !mycnfSectionRe.MatchString(line) {
+				return errors.Errorf("line %d: invalid section header %q", i+1, line)
+			}
+			section = true
+		case !section:
+			return errors.Errorf("line %d: option %q is outside of any section", i+1, line)
+		case !mycnfOptionRe.MatchString(line):
+			return errors.Errorf("line %d: invalid option %q", i+1, line)
+		}
+	}
+	if !section {
+		return errors.New("configuration has no sections")
+	}
+	return nil
+}
+
+// validateProxySQLConfiguration checks proxysql.cnf fragment for unbalanced quotes and brackets.
+func validateProxySQLConfiguration(configuration string) error {
+	var stack []rune
+	closing := map[rune]rune{'}': '{', ')': '(', ']': '['}
+	line := 1
+	var quote rune
+	comment := false
+	for _, r := range configuration {
+		switch {
+		case r == '\n':
+			if quote != 0 {
+				return errors.Errorf("line %d: unterminated string", line)
+			}
+			comment = false
+			line++
+		case comment:
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '#':
+			comment = true
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '{' || r == '(' || r == '[':
+			stack = append(stack, r)
+		case closing[r] != 0:
+			if len(stack) == 0 || stack[len(stack)-1] != closing[r] {
+				return errors.Errorf("line %d: unexpected %q", line, r)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if quote != 0 {
+		return errors.Errorf("line %d: unterminated string", line)
+	}
+	if len(stack) != 0 {
+		return errors.Errorf("unclosed %q", stack[len(stack)-1])
+	}
+	return nil
+}
+
+// validateHAProxyConfiguration checks that haproxy.cfg fragment consists of sections with indented directives.
+func validateHAProxyConfiguration(configuration string) error {
+	section := false
+	for i, line := range strings.Split(configuration, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if !section {
+				return errors.Errorf("line %d: directive %q is outside of any section", i+1, trimmed)
+			}
+			continue
+		}
+		keyword := strings.Fields(trimmed)[0]
+		if _, ok := haproxySections[keyword]; !ok {
+			return errors.Errorf("line %d: unknown section %q", i+1, keyword)
+		}
+		section = true
+	}
+	if !section {
+		return errors.New("configuration has no sections")
+	}
+	return nil
+}
+
+// validateMongodConfiguration checks that mongod configuration is a YAML mapping.
+func validateMongodConfiguration(configuration string) error {
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(configuration), 4096)
+	var cfg map[string]interface{}
+	if err := decoder.Decode(&cfg); err != nil {
+		if err == io.EOF {
+			return errors.New("configuration is empty")
+		}
+		return errors.Wrap(err, "invalid YAML")
+	}
+	if len(cfg) == 0 {
+		return errors.New("configuration is empty")
+	}
+	return nil
+}
+
+// validateConfiguration checks custom configurations of XtraDB cluster components.
+func (p *XtraDBParams) validateConfiguration() error {
+	if p.PXC != nil && pointer.GetString(p.PXC.Configuration) != "" {
+		if err := validatePXCConfiguration(*p.PXC.Configuration); err != nil {
+			return errors.Wrap(err, "invalid PXC configuration")
+		}
+	}
+	if p.ProxySQL != nil && pointer.GetString(p.ProxySQL.Configuration) != "" {
+		if err := validateProxySQLConfiguration(*p.ProxySQL.Configuration); err != nil {
+			return errors.Wrap(err, "invalid ProxySQL configuration")
+		}
+	}
+	if p.HAProxy != nil && pointer.GetString(p.HAProxy.Configuration) != "" {
+		if err := validateHAProxyConfiguration(*p.HAProxy.Configuration); err != nil {
+			return errors.Wrap(err, "invalid HAProxy configuration")
+		}
+	}
+	return nil
+}
+
+// validateConfiguration checks custom mongod configurations of PSMDB cluster replica sets.
+func (p *PSMDBParams) validateConfiguration() error {
+	replsets := p.Shards
+	if p.Replicaset != nil {
+		replsets = append([]*Replicaset{p.Replicaset}, replsets...)
+	}
+	for _, rs := range replsets {
+		if rs == nil || pointer.GetString(rs.Configuration) == "" {
+			continue
+		}
+		if err := validateMongodConfiguration(*rs.Configuration); err != nil {
+			return errors.Wrap(err, "invalid mongod configuration")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakeUpdateClient returns given objects and records the last applied PXC cluster.
+type fakeUpdateClient struct {
+	fakeDetailsClient
+	cluster *pxc.PerconaXtraDBCluster
+}
+
+func (f *fakeUpdateClient) Apply(ctx context.Context, namespace string, res interface{}) error {
+	if cluster, ok := res.(*pxc.PerconaXtraDBCluster); ok {
+		f.cluster = cluster
+	}
+	return nil
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		validate func(string) error
+		cfg      string
+		err      string
+	}{
+		{
+			name:     "PXC",
+			validate: validatePXCConfiguration,
+			cfg:      "# tuning\n[mysqld]\ninnodb_buffer_pool_size = 2G\nmax_connections=500\nskip-name-resolve\n",
+		},
+		{
+			name:     "PXCNoSection",
+			validate: validatePXCConfiguration,
+			cfg:      "max_connections=500\n",
+			err:      `line 1: option "max_connections=500" is outside of any section`,
+		},
+		{
+			name:     "PXCInvalidSection",
+			validate: validatePXCConfiguration,
+			cfg:      "[mysqld\nmax_connections=500\n",
+			err:      `line 1: invalid section header "[mysqld"`,
+		},
+		{
+			name:     "PXCInvalidOption",
+			validate: validatePXCConfiguration,
+			cfg:      "[mysqld]\n= 500\n",
+			err:      `line 2: invalid option "= 500"`,
+		},
+		{
+			name:     "ProxySQL",
+			validate: validateProxySQLConfiguration,
+			cfg:      "mysql_variables=\n{\n\tmax_connections=2048 # per node\n\tinterfaces=\"0.0.0.0:3306;/tmp/proxysql.sock\"\n}\n",
+		},
+		{
+			name:     "ProxySQLUnclosed",
+			validate: validateProxySQLConfiguration,
+			cfg:      "mysql_variables=\n{\n\tmax_connections=2048\n",
+			err:      `unclosed '{'`,
+		},
+		{
+			name:     "ProxySQLUnterminatedString",
+			validate: validateProxySQLConfiguration,
+			cfg:      "admin_variables=\n{\n\tadmin_credentials=\"admin:admin\n}\n",
+			err:      "line 3: unterminated string",
+		},
+		{
+			name:     "HAProxy",
+			validate: validateHAProxyConfiguration,
+			cfg:      "global\n  maxconn 4096\n\n# timeouts\ndefaults\n  timeout client 28800s\n",
+		},
+		{
+			name:     "HAProxyNoSection",
+			validate: validateHAProxyConfiguration,
+			cfg:      "  maxconn 4096\n",
+			err:      `line 1: directive "maxconn 4096" is outside of any section`,
+		},
+		{
+			name:     "HAProxyUnknownSection",
+			validate: validateHAProxyConfiguration,
+			cfg:      "global\n  maxconn 4096\nmaxconn 2048\n",
+			err:      `line 3: unknown section "maxconn"`,
+		},
+		{
+			name:     "Mongod",
+			validate: validateMongodConfiguration,
+			cfg:      "operationProfiling:\n  mode: slowOp\nstorage:\n  wiredTiger:\n    engineConfig:\n      cacheSizeRatio: 0.8\n",
+		},
+		{
+			name:     "MongodInvalid",
+			validate: validateMongodConfiguration,
+			cfg:      "storage:\n  wiredTiger: [\n",
+			err:      "invalid YAML",
+		},
+		{
+			name:     "MongodEmpty",
+			validate: validateMongodConfiguration,
+			cfg:      "# nothing\n",
+			err:      "configuration is empty",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.validate(tt.cfg)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestUpdateConfiguration(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("XtraDB", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeUpdateClient{fakeDetailsClient: fakeDetailsClient{
+			objects: map[string]string{
+				string(perconaXtraDBClusterKind) + "/test": `{
+					"metadata": {"name": "test"},
+					"spec": {
+						"pxc": {"size": 3, "configuration": "[mysqld]\nmax_connections=500\n"},
+						"haproxy": {"enabled": true, "size": 3, "configuration": "global\n  maxconn 4096\n"}
+					},
+					"status": {"pxc": {"status": "ready"}}
+				}`,
+			},
+		}}
+		c := &K8sClient{kube: kube, l: logger.Get(ctx)}
+
+		// configuration is unchanged if it is not set
+		err := c.UpdateXtraDBCluster(ctx, &XtraDBParams{Name: "test", Size: 5, PXC: &PXC{}, HAProxy: &HAProxy{}})
+		require.NoError(t, err)
+		require.NotNil(t, kube.cluster)
+		assert.Equal(t, int32(5), kube.cluster.Spec.PXC.Size)
+		assert.Equal(t, "[mysqld]\nmax_connections=500\n", kube.cluster.Spec.PXC.Configuration)
+		assert.Equal(t, "global\n  maxconn 4096\n", kube.cluster.Spec.HAProxy.Configuration)
+
+		// empty configuration removes custom one
+		err = c.UpdateXtraDBCluster(ctx, &XtraDBParams{
+			Name:    "test",
+			PXC:     &PXC{Configuration: pointer.ToString("")},
+			HAProxy: &HAProxy{Configuration: pointer.ToString("")},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, kube.cluster.Spec.PXC.Configuration)
+		assert.Empty(t, kube.cluster.Spec.HAProxy.Configuration)
+	})
+
+	t.Run("PSMDB", func(t *testing.T) {
+		t.Parallel()
+
+		rs := &psmdb.ReplsetSpec{Name: "rs0", Size: 3, Configuration: "operationProfiling:\n  mode: slowOp\n"}
+		cluster := &psmdb.PerconaServerMongoDB{
+			Spec: psmdb.PerconaServerMongoDBSpec{
+				CRVersion: "1.9.0",
+				Replsets:  []*psmdb.ReplsetSpec{rs},
+			},
+		}
+		c := &K8sClient{l: logger.Get(ctx)}
+
+		err := c.updatePSMDBReplsets(cluster, &PSMDBParams{Replicaset: &Replicaset{}})
+		require.NoError(t, err)
+		assert.Equal(t, "operationProfiling:\n  mode: slowOp\n", rs.Configuration)
+
+		err = c.updatePSMDBReplsets(cluster, &PSMDBParams{Replicaset: &Replicaset{Configuration: pointer.ToString("")}})
+		require.NoError(t, err)
+		assert.Empty(t, rs.Configuration)
+	})
+}
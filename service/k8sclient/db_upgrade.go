@@ -0,0 +1,237 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+// UpdateStrategy defines how the operator replaces pods of a cluster.
+type UpdateStrategy string
+
+const (
+	// UpdateStrategySmartUpdate replaces pods one by one, the primary or writer pod is replaced last.
+	// It is used if update strategy is not set.
+	UpdateStrategySmartUpdate UpdateStrategy = "SmartUpdate"
+	// UpdateStrategyRollingUpdate replaces pods one by one in the statefulset order.
+	UpdateStrategyRollingUpdate UpdateStrategy = "RollingUpdate"
+	// UpdateStrategyOnDelete replaces pods only after they are deleted manually.
+	UpdateStrategyOnDelete UpdateStrategy = "OnDelete"
+)
+
+// upgradeApplyDisabled disables automatic upgrades by the operator, so it does not override the chosen image.
+const upgradeApplyDisabled = "Disabled"
+
+// ErrDowngradeNotAllowed is returned when target database version is lower than the running one.
+var ErrDowngradeNotAllowed = errors.New("database downgrade is not allowed")
+
+// UpgradeParams contains parameters of a database version upgrade.
+type UpgradeParams struct {
+	// Namespace of the cluster, empty namespace means the default one.
+	Namespace string
+	Name      string
+	// Image is a database image with the target version in its tag.
+	Image string
+	// UpdateStrategy of the cluster, empty means SmartUpdate.
+	UpdateStrategy UpdateStrategy
+	// Force allows downgrades.
+	Force bool
+}
+
+// PodUpgradeStatus contains upgrade progress of a single pod.
+type PodUpgradeStatus struct {
+	Name     string
+	Image    string
+	Upgraded bool
+	Ready    bool
+}
+
+// UpgradeProgress contains upgrade progress of a cluster.
+type UpgradeProgress struct {
+	Image string
+	Pods  []PodUpgradeStatus
+	// Done is true when all database pods run the target image and are ready.
+	Done bool
+}
+
+// imageVersion returns version from a given image tag.
+func imageVersion(image string) (*version.Version, error) {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return nil, errors.Errorf("image %s has no version tag", image)
+	}
+	// Versions like 8.0.20-11.1 have build number after dash that is not a semver pre-release.
+	v, err := version.NewVersion(strings.ReplaceAll(image[i+1:], "-", "."))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse version of image %s", image)
+	}
+	return v, nil
+}
+
+// checkUpgrade checks that database can be upgraded from current image to a target one.
+func checkUpgrade(currentImage string, params *UpgradeParams) error {
+	switch params.UpdateStrategy {
+	case "", UpdateStrategySmartUpdate, UpdateStrategyRollingUpdate, UpdateStrategyOnDelete:
+	default:
+		return errors.Errorf("unsupported update strategy %q", params.UpdateStrategy)
+	}
+
+	target, err := imageVersion(params.Image)
+	if err != nil {
+		return err
+	}
+	current, err := imageVersion(currentImage)
+	if err != nil {
+		return err
+	}
+	if target.LessThan(current) && !params.Force {
+		return errors.Wrapf(ErrDowngradeNotAllowed, "%s is older than %s", target, current)
+	}
+	return nil
+}
+
+// updateStrategy returns update strategy with default applied.
+func (p *UpgradeParams) updateStrategy() string {
+	if p.UpdateStrategy == "" {
+		return string(UpdateStrategySmartUpdate)
+	}
+	return string(p.UpdateStrategy)
+}
+
+// UpgradeXtraDBCluster changes PXC image of a given cluster.
+func (c *K8sClient) UpgradeXtraDBCluster(ctx context.Context, params *UpgradeParams) error {
+	var cluster pxc.PerconaXtraDBCluster
+	err := c.kube.Get(ctx, params.Namespace, string(perconaXtraDBClusterKind), params.Name, &cluster)
+	if err != nil {
+		return err
+	}
+
+	// This is to prevent concurrent updates
+	if cluster.Status.PXC.Status != pxc.AppStateReady {
+		return errors.Wrapf(ErrXtraDBClusterNotReady, "state is %v", cluster.Status.Status) //nolint:wrapcheck
+	}
+
+	if err = checkUpgrade(cluster.Spec.PXC.Image, params); err != nil {
+		return err
+	}
+
+	cluster.Spec.PXC.Image = params.Image
+	cluster.Spec.UpdateStrategy = params.updateStrategy()
+	cluster.Spec.UpgradeOptions.Apply = upgradeApplyDisabled
+	return c.kube.Apply(ctx, params.Namespace, &cluster)
+}
+
+// UpgradePSMDBCluster changes MongoDB image of a given cluster.
+func (c *K8sClient) UpgradePSMDBCluster(ctx context.Context, params *UpgradeParams) error {
+	var cluster psmdb.PerconaServerMongoDB
+	err := c.kube.Get(ctx, params.Namespace, string(perconaServerMongoDBKind), params.Name, &cluster)
+	if err != nil {
+		return err
+	}
+
+	// This is to prevent concurrent updates
+	if cluster.Status.Status != psmdb.AppStateReady {
+		return errors.Wrapf(ErrPSMDBClusterNotReady, "state is %v", cluster.Status.Status) //nolint:wrapcheck
+	}
+
+	if err = checkUpgrade(cluster.Spec.Image, params); err != nil {
+		return err
+	}
+
+	cluster.Spec.Image = params.Image
+	cluster.Spec.UpdateStrategy = params.updateStrategy()
+	if cluster.Spec.UpgradeOptions == nil {
+		cluster.Spec.UpgradeOptions = new(psmdb.UpgradeOptions)
+	}
+	cluster.Spec.UpgradeOptions.Apply = upgradeApplyDisabled
+	return c.kube.Apply(ctx, params.Namespace, &cluster)
+}
+
+// GetXtraDBClusterUpgradeProgress returns upgrade progress of PXC pods of a given cluster.
+func (c *K8sClient) GetXtraDBClusterUpgradeProgress(ctx context.Context, namespace, name string) (*UpgradeProgress, error) {
+	var cluster pxc.PerconaXtraDBCluster
+	err := c.kube.Get(ctx, namespace, string(perconaXtraDBClusterKind), name, &cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := fmt.Sprintf("app.kubernetes.io/instance=%s,app.kubernetes.io/component=pxc", name)
+	pods, err := c.GetPods(ctx, namespace, "-l", selector)
+	if err != nil {
+		return nil, err
+	}
+	return getUpgradeProgress(cluster.Spec.PXC.Image, pods, "pxc"), nil
+}
+
+// GetPSMDBClusterUpgradeProgress returns upgrade progress of mongod and mongos pods of a given cluster.
+func (c *K8sClient) GetPSMDBClusterUpgradeProgress(ctx context.Context, namespace, name string) (*UpgradeProgress, error) {
+	var cluster psmdb.PerconaServerMongoDB
+	err := c.kube.Get(ctx, namespace, string(perconaServerMongoDBKind), name, &cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := c.GetPods(ctx, namespace, "-l", "app.kubernetes.io/instance="+name)
+	if err != nil {
+		return nil, err
+	}
+	return getUpgradeProgress(cluster.Spec.Image, pods, "mongod", "mongos"), nil
+}
+
+// getUpgradeProgress returns progress of pods with given database containers towards a given image.
+func getUpgradeProgress(image string, pods *common.PodList, containers ...string) *UpgradeProgress {
+	res := &UpgradeProgress{
+		Image: image,
+		Done:  true,
+	}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			if !contains(containers, container.Name) {
+				continue
+			}
+			status := PodUpgradeStatus{
+				Name:     pod.Name,
+				Image:    container.Image,
+				Upgraded: container.Image == image,
+				Ready:    common.IsPodReady(pod),
+			}
+			res.Done = res.Done && status.Upgraded && status.Ready
+			res.Pods = append(res.Pods, status)
+			break
+		}
+	}
+	return res
+}
+
+// contains returns true if a given list contains a given string.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
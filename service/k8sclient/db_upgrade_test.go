@@ -0,0 +1,89 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+func TestDBUpgrade(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CheckUpgrade", func(t *testing.T) {
+		t.Parallel()
+
+		current := "percona/percona-xtradb-cluster:8.0.20-11.1"
+		err := checkUpgrade(current, &UpgradeParams{Image: "percona/percona-xtradb-cluster:8.0.22-13.1"})
+		require.NoError(t, err)
+
+		err = checkUpgrade(current, &UpgradeParams{Image: "percona/percona-xtradb-cluster:8.0.20-11.2"})
+		require.NoError(t, err)
+
+		err = checkUpgrade(current, &UpgradeParams{Image: "percona/percona-xtradb-cluster:8.0.19-10.1"})
+		assert.True(t, errors.Is(err, ErrDowngradeNotAllowed))
+
+		err = checkUpgrade(current, &UpgradeParams{Image: "percona/percona-xtradb-cluster:8.0.19-10.1", Force: true})
+		require.NoError(t, err)
+
+		err = checkUpgrade(current, &UpgradeParams{Image: "localhost:5000/percona-xtradb-cluster"})
+		assert.EqualError(t, err, "image localhost:5000/percona-xtradb-cluster has no version tag")
+
+		err = checkUpgrade(current, &UpgradeParams{Image: "percona/percona-xtradb-cluster:8.0.22-13.1", UpdateStrategy: "Recreate"})
+		assert.EqualError(t, err, `unsupported update strategy "Recreate"`)
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		t.Parallel()
+
+		image := "percona/percona-server-mongodb:4.4.5-7"
+		pod := func(name, image string, ready bool) common.Pod {
+			return common.Pod{
+				ObjectMeta: common.ObjectMeta{Name: name},
+				Spec: common.PodSpec{Containers: []common.ContainerSpec{
+					{Name: "backup-agent", Image: "percona/percona-server-mongodb-operator:1.8.0-backup"},
+					{Name: "mongod", Image: image},
+				}},
+				Status: common.PodStatus{
+					Phase:             common.PodPhaseRunning,
+					ContainerStatuses: []common.ContainerStatus{{Name: "mongod", Ready: ready}},
+				},
+			}
+		}
+
+		progress := getUpgradeProgress(image, &common.PodList{Items: []common.Pod{
+			pod("rs0-0", image, true),
+			pod("rs0-1", "percona/percona-server-mongodb:4.2.8-8", true),
+		}}, "mongod")
+		assert.Equal(t, &UpgradeProgress{
+			Image: image,
+			Pods: []PodUpgradeStatus{
+				{Name: "rs0-0", Image: image, Upgraded: true, Ready: true},
+				{Name: "rs0-1", Image: "percona/percona-server-mongodb:4.2.8-8", Upgraded: false, Ready: true},
+			},
+			Done: false,
+		}, progress)
+
+		progress = getUpgradeProgress(image, &common.PodList{Items: []common.Pod{pod("rs0-0", image, true)}}, "mongod")
+		assert.True(t, progress.Done)
+	})
+}
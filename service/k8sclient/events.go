@@ -0,0 +1,200 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+const (
+	podKind                   = "Pod"
+	persistentVolumeClaimKind = "PersistentVolumeClaim"
+)
+
+// Event is a Kubernetes event about an object.
+type Event struct {
+	// Type is Normal or Warning.
+	Type    string
+	Reason  string
+	Message string
+	// Count is a number of times this event has occurred.
+	Count          int32
+	FirstTimestamp time.Time
+	LastTimestamp  time.Time
+	InvolvedObject KubernetesObject
+	// Source is a component reporting the event like kubelet or default-scheduler.
+	Source string
+}
+
+// EventsParams contains parameters for listing events.
+type EventsParams struct {
+	Namespace string
+	// Kind and Name of the involved object, empty values match all objects.
+	Kind string
+	Name string
+	// WarningsOnly skips Normal events.
+	WarningsOnly bool
+	// Since skips events last seen before that time, zero value means no limit.
+	Since time.Time
+}
+
+// ListEvents returns events matching given parameters sorted by the last timestamp.
+func (c *K8sClient) ListEvents(ctx context.Context, params *EventsParams) ([]Event, error) {
+	var selectors []string
+	if params.Kind != "" {
+		selectors = append(selectors, "involvedObject.kind="+params.Kind)
+	}
+	if params.Name != "" {
+		selectors = append(selectors, "involvedObject.name="+params.Name)
+	}
+	if params.WarningsOnly {
+		selectors = append(selectors, "type="+common.EventTypeWarning)
+	}
+	args := []string{"get", "events", "-ojson"}
+	if len(selectors) != 0 {
+		args = append(args, "--field-selector="+strings.Join(selectors, ","))
+	}
+
+	stdout, err := c.kube.Run(ctx, withNamespace(args, params.Namespace), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get events")
+	}
+	var list common.EventList
+	if err = json.Unmarshal(stdout, &list); err != nil {
+		return nil, errors.Wrap(err, "couldn't parse events")
+	}
+
+	res := make([]Event, 0, len(list.Items))
+	for _, item := range list.Items {
+		event := convertEvent(item)
+		if !params.Since.IsZero() && event.LastTimestamp.Before(params.Since) {
+			continue
+		}
+		res = append(res, event)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].LastTimestamp.Before(res[j].LastTimestamp) })
+	return res, nil
+}
+
+// ListPodEvents returns events of a given pod.
+func (c *K8sClient) ListPodEvents(ctx context.Context, namespace, pod string, warningsOnly bool, since time.Time) ([]Event, error) {
+	return c.ListEvents(ctx, &EventsParams{
+		Namespace:    namespace,
+		Kind:         podKind,
+		Name:         pod,
+		WarningsOnly: warningsOnly,
+		Since:        since,
+	})
+}
+
+// ListXtraDBClusterEvents returns events of a given XtraDB cluster custom resource.
+func (c *K8sClient) ListXtraDBClusterEvents(ctx context.Context, namespace, name string, warningsOnly bool, since time.Time) ([]Event, error) {
+	return c.ListEvents(ctx, &EventsParams{
+		Namespace:    namespace,
+		Kind:         string(perconaXtraDBClusterKind),
+		Name:         name,
+		WarningsOnly: warningsOnly,
+		Since:        since,
+	})
+}
+
+// ListPSMDBClusterEvents returns events of a given PSMDB cluster custom resource.
+func (c *K8sClient) ListPSMDBClusterEvents(ctx context.Context, namespace, name string, warningsOnly bool, since time.Time) ([]Event, error) {
+	return c.ListEvents(ctx, &EventsParams{
+		Namespace:    namespace,
+		Kind:         string(perconaServerMongoDBKind),
+		Name:         name,
+		WarningsOnly: warningsOnly,
+		Since:        since,
+	})
+}
+
+// ListPVCEvents returns events of a given persistent volume claim.
+func (c *K8sClient) ListPVCEvents(ctx context.Context, namespace, pvc string, warningsOnly bool, since time.Time) ([]Event, error) {
+	return c.ListEvents(ctx, &EventsParams{
+		Namespace:    namespace,
+		Kind:         persistentVolumeClaimKind,
+		Name:         pvc,
+		WarningsOnly: warningsOnly,
+		Since:        since,
+	})
+}
+
+// GetEvents returns pod's events as a slice of strings.
+func (c *K8sClient) GetEvents(ctx context.Context, namespace, pod string) ([]string, error) {
+	events, err := c.ListPodEvents(ctx, namespace, pod, false, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	// Add name of the pod to the Events line so it's clear what pod events we got.
+	res := []string{pod + " Events:"}
+	if len(events) == 0 {
+		return append(res, "  <none>"), nil
+	}
+	for _, event := range events {
+		res = append(res, formatEvent(event))
+	}
+	return res, nil
+}
+
+// convertEvent converts Kubernetes event to Event.
+// Events reported by new clients have only event time, it is used for both timestamps then.
+func convertEvent(event common.Event) Event {
+	res := Event{
+		Type:    event.Type,
+		Reason:  event.Reason,
+		Message: event.Message,
+		Count:   event.Count,
+		InvolvedObject: KubernetesObject{
+			Kind: event.InvolvedObject.Kind,
+			Name: event.InvolvedObject.Name,
+		},
+		Source: event.Source.Component,
+	}
+	if event.EventTime != nil {
+		res.FirstTimestamp = *event.EventTime
+		res.LastTimestamp = *event.EventTime
+	}
+	if event.FirstTimestamp != nil {
+		res.FirstTimestamp = *event.FirstTimestamp
+	}
+	if event.LastTimestamp != nil {
+		res.LastTimestamp = *event.LastTimestamp
+	}
+	if res.Count == 0 {
+		res.Count = 1
+	}
+	return res
+}
+
+// formatEvent formats a given event as a log line.
+func formatEvent(event Event) string {
+	line := fmt.Sprintf("  %s  %s  %s  %s", event.LastTimestamp.UTC().Format(time.RFC3339), event.Type, event.Reason, event.Source)
+	if event.Count > 1 {
+		line += fmt.Sprintf(" (x%d since %s)", event.Count, event.FirstTimestamp.UTC().Format(time.RFC3339))
+	}
+	return line + "  " + event.Message
+}
@@ -0,0 +1,124 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakeEventsClient returns given events output and records arguments of the last command.
+type fakeEventsClient struct {
+	kubeClient
+	out  string
+	args []string
+}
+
+func (f *fakeEventsClient) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
+	f.args = args
+	return []byte(f.out), nil
+}
+
+const testEvents = `{"items": [
+	{
+		"involvedObject": {"kind": "Pod", "name": "test-pxc-0"},
+		"reason": "BackOff",
+		"message": "Back-off restarting failed container",
+		"source": {"component": "kubelet"},
+		"firstTimestamp": "2021-03-01T10:00:00Z",
+		"lastTimestamp": "2021-03-01T10:05:00Z",
+		"count": 4,
+		"type": "Warning"
+	},
+	{
+		"involvedObject": {"kind": "Pod", "name": "test-pxc-0"},
+		"reason": "Scheduled",
+		"message": "Successfully assigned default/test-pxc-0 to node",
+		"source": {"component": "default-scheduler"},
+		"eventTime": "2021-03-01T09:59:00Z",
+		"type": "Normal"
+	}
+]}`
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("ListEvents", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeEventsClient{out: testEvents}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		events, err := client.ListPodEvents(ctx, "default", "test-pxc-0", false, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"get", "events", "-ojson", "--field-selector=involvedObject.kind=Pod,involvedObject.name=test-pxc-0", "--namespace=default",
+		}, kube.args)
+		require.Len(t, events, 2)
+		assert.Equal(t, Event{
+			Type:           "Normal",
+			Reason:         "Scheduled",
+			Message:        "Successfully assigned default/test-pxc-0 to node",
+			Count:          1,
+			FirstTimestamp: time.Date(2021, 3, 1, 9, 59, 0, 0, time.UTC),
+			LastTimestamp:  time.Date(2021, 3, 1, 9, 59, 0, 0, time.UTC),
+			InvolvedObject: KubernetesObject{Kind: "Pod", Name: "test-pxc-0"},
+			Source:         "default-scheduler",
+		}, events[0])
+		assert.Equal(t, "BackOff", events[1].Reason)
+		assert.Equal(t, int32(4), events[1].Count)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeEventsClient{out: testEvents}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		since := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+		events, err := client.ListXtraDBClusterEvents(ctx, "", "test", true, since)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"get", "events", "-ojson", "--field-selector=involvedObject.kind=PerconaXtraDBCluster,involvedObject.name=test,type=Warning",
+		}, kube.args)
+		require.Len(t, events, 1)
+		assert.Equal(t, "BackOff", events[0].Reason)
+	})
+
+	t.Run("GetEvents", func(t *testing.T) {
+		t.Parallel()
+
+		client := &K8sClient{kube: &fakeEventsClient{out: testEvents}, l: logger.Get(ctx)}
+		lines, err := client.GetEvents(ctx, "", "test-pxc-0")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"test-pxc-0 Events:",
+			"  2021-03-01T09:59:00Z  Normal  Scheduled  default-scheduler  Successfully assigned default/test-pxc-0 to node",
+			"  2021-03-01T10:05:00Z  Warning  BackOff  kubelet (x4 since 2021-03-01T10:00:00Z)  Back-off restarting failed container",
+		}, lines)
+
+		client = &K8sClient{kube: &fakeEventsClient{out: `{"items": []}`}, l: logger.Get(ctx)}
+		lines, err = client.GetEvents(ctx, "", "test-pxc-0")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test-pxc-0 Events:", "  <none>"}, lines)
+	})
+}
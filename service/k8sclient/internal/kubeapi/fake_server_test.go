@@ -0,0 +1,306 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package kubeapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+const (
+	discoveryAPI = `{"kind":"APIVersions","versions":["v1"]}`
+
+	discoveryAPIs = `{"kind":"APIGroupList","apiVersion":"v1","groups":[
+  {"name":"apps","versions":[{"groupVersion":"apps/v1","version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}}
+]}`
+
+	// discoveryAPIsWithCRD is returned after CRD is installed.
+	discoveryAPIsWithCRD = `{"kind":"APIGroupList","apiVersion":"v1","groups":[
+  {"name":"apps","versions":[{"groupVersion":"apps/v1","version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}},
+  {"name":"pxc.percona.com","versions":[{"groupVersion":"pxc.percona.com/v1","version":"v1"}],"preferredVersion":{"groupVersion":"pxc.percona.com/v1","version":"v1"}}
+]}`
+
+	discoveryPXCV1 = `{"kind":"APIResourceList","groupVersion":"pxc.percona.com/v1","resources":[
+  {"name":"perconaxtradbclusters","singularName":"perconaxtradbcluster","namespaced":true,"kind":"PerconaXtraDBCluster","verbs":["get","list"],"shortNames":["pxc"]}
+]}`
+
+	discoveryCoreV1 = `{"kind":"APIResourceList","groupVersion":"v1","resources":[
+  {"name":"pods","singularName":"","namespaced":true,"kind":"Pod","verbs":["get","list"],"shortNames":["po"]},
+  {"name":"events","singularName":"","namespaced":true,"kind":"Event","verbs":["get","list"],"shortNames":["ev"]},
+  {"name":"persistentvolumes","singularName":"","namespaced":false,"kind":"PersistentVolume","verbs":["get","list"],"shortNames":["pv"]},
+  {"name":"persistentvolumeclaims","singularName":"","namespaced":true,"kind":"PersistentVolumeClaim","verbs":["get","list","patch"],"shortNames":["pvc"]},
+  {"name":"secrets","singularName":"","namespaced":true,"kind":"Secret","verbs":["get","list","create","patch","delete"]}
+]}`
+
+	discoveryAppsV1 = `{"kind":"APIResourceList","groupVersion":"apps/v1","resources":[
+  {"name":"deployments","singularName":"","namespaced":true,"kind":"Deployment","verbs":["get","list","patch"],"shortNames":["deploy"]},
+  {"name":"statefulsets","singularName":"","namespaced":true,"kind":"StatefulSet","verbs":["get","list","patch","delete"],"shortNames":["sts"]}
+]}`
+)
+
+// fakeServer is a minimal Kubernetes API server that records requests.
+type fakeServer struct {
+	m        sync.Mutex
+	requests []string
+	bodies   map[string]string
+	// crdInstalled adds PXC API group to discovery.
+	crdInstalled bool
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	req := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		req += "?" + r.URL.RawQuery
+	}
+
+	f.m.Lock()
+	f.requests = append(f.requests, req)
+	if len(body) > 0 {
+		f.bodies[r.Method+" "+r.URL.Path] = r.Header.Get("Content-Type") + " " + string(body)
+	}
+	crdInstalled := f.crdInstalled
+	f.m.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/version":
+		fmt.Fprint(w, `{"major":"1","minor":"20","gitVersion":"v1.20.0"}`)
+	case "/api":
+		fmt.Fprint(w, discoveryAPI)
+	case "/apis":
+		if crdInstalled {
+			fmt.Fprint(w, discoveryAPIsWithCRD)
+			return
+		}
+		fmt.Fprint(w, discoveryAPIs)
+	case "/apis/pxc.percona.com/v1":
+		fmt.Fprint(w, discoveryPXCV1)
+	case "/api/v1":
+		fmt.Fprint(w, discoveryCoreV1)
+	case "/apis/apps/v1":
+		fmt.Fprint(w, discoveryAppsV1)
+	case "/api/v1/namespaces/myns/pods/pod-0":
+		fmt.Fprint(w, `{"kind":"Pod","apiVersion":"v1","metadata":{"name":"pod-0","namespace":"myns"}}`)
+	case "/api/v1/namespaces/myns/pods/pod-0/log":
+		fmt.Fprint(w, "line 1\nline 2\n")
+	case "/api/v1/namespaces/myns/events":
+		fmt.Fprint(w, `{"kind":"EventList","items":[
+  {"type":"Warning","reason":"BackOff","message":"Back-off restarting failed container","source":{"component":"kubelet"}}
+]}`)
+	case "/api/v1/namespaces/myns/pods":
+		if r.URL.Query().Get("watch") == "true" {
+			fmt.Fprint(w, `{"type":"ADDED","object":{"kind":"Pod","metadata":{"name":"pod-0"}}}
+{"type":"DELETED","object":{"kind":"Pod","metadata":{"name":"pod-0"}}}
+`)
+			return
+		}
+		fmt.Fprint(w, `{"kind":"List","items":[]}`)
+	case "/api/v1/pods", "/api/v1/persistentvolumes":
+		fmt.Fprint(w, `{"kind":"List","items":[]}`)
+	default:
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","status":"Failure","reason":"NotFound","code":404}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+// last returns the last recorded request and resets the log.
+func (f *fakeServer) last() string {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if len(f.requests) == 0 {
+		return ""
+	}
+	res := f.requests[len(f.requests)-1]
+	f.requests = nil
+	return res
+}
+
+func TestKubeAPIFakeServer(t *testing.T) {
+	fake := &fakeServer{bodies: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: fake
+  cluster:
+    server: %s
+users:
+- name: fake
+  user:
+    token: secret-token
+contexts:
+- name: fake
+  context:
+    cluster: fake
+    user: fake
+    namespace: myns
+current-context: fake
+`, server.URL)
+
+	ctx := context.Background()
+	client, err := NewKubeAPI(ctx, kubeconfig)
+	require.NoError(t, err)
+	defer client.Cleanup() //nolint:errcheck
+
+	t.Run("Get", func(t *testing.T) {
+		var pod struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+		require.NoError(t, client.Get(ctx, "", "pod", "pod-0", &pod))
+		assert.Equal(t, "pod-0", pod.Metadata.Name)
+		assert.Equal(t, "GET /api/v1/namespaces/myns/pods/pod-0", fake.last())
+
+		err = client.Get(ctx, "other", "deployment", "missing", &pod)
+		assert.Equal(t, ErrNotFound, err)
+		assert.Equal(t, "GET /apis/apps/v1/namespaces/other/deployments/missing", fake.last())
+	})
+
+	t.Run("RunGet", func(t *testing.T) {
+		_, err = client.Run(ctx, []string{"get", "pv", "-ojson"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "GET /api/v1/persistentvolumes", fake.last())
+
+		_, err = client.Run(ctx, []string{"get", "pods", "--all-namespaces", "-lapp=test"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "GET /api/v1/pods?labelSelector=app%3Dtest", fake.last())
+
+		_, err = client.Run(ctx, []string{"get", "events", "--field-selector=type=Warning", "-ojson"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "GET /api/v1/namespaces/myns/events?fieldSelector=type%3DWarning", fake.last())
+	})
+
+	t.Run("RunLogs", func(t *testing.T) {
+		out, err := client.Run(ctx, []string{"logs", "pod-0", "app", "--tail=10"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "line 1\nline 2\n", string(out))
+		assert.Equal(t, "GET /api/v1/namespaces/myns/pods/pod-0/log?container=app&tailLines=10", fake.last())
+	})
+
+	t.Run("StreamLogs", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := client.Stream(ctx, []string{"logs", "pod-0", "app", "-f", "--timestamps"}, &buf)
+		require.NoError(t, err)
+		assert.Equal(t, "line 1\nline 2\n", buf.String())
+		assert.Equal(t, "GET /api/v1/namespaces/myns/pods/pod-0/log?container=app&follow=true&timestamps=true", fake.last())
+
+		err = client.Stream(ctx, []string{"logs", "missing", "app"}, &buf)
+		assert.Equal(t, ErrNotFound, err)
+
+		err = client.Stream(ctx, []string{"get", "pods"}, &buf)
+		assert.EqualError(t, err, `kubectl command "get pods" is not supported for streaming by Kubernetes API client`)
+	})
+
+	t.Run("RunDescribe", func(t *testing.T) {
+		out, err := client.Run(ctx, []string{"describe", "pod", "pod-0"}, nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "Back-off restarting failed container")
+		assert.Contains(t, string(out), "BackOff")
+	})
+
+	t.Run("RunRolloutRestart", func(t *testing.T) {
+		out, err := client.Run(ctx, []string{"rollout", "restart", "deployment/operator"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "deployments/operator restarted\n", string(out))
+		assert.Equal(t, "PATCH /apis/apps/v1/namespaces/myns/deployments/operator", fake.last())
+	})
+
+	t.Run("RunPatchDelete", func(t *testing.T) {
+		patch := `{"spec":{"resources":{"requests":{"storage":"20Gi"}}}}`
+		out, err := client.Run(ctx, []string{"patch", "pvc", "datadir-0", "--type=merge", "--patch=" + patch}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "persistentvolumeclaims/datadir-0 patched\n", string(out))
+		assert.Equal(t, "PATCH /api/v1/namespaces/myns/persistentvolumeclaims/datadir-0", fake.last())
+		assert.Equal(t, contentTypeMergePatch+" "+patch, fake.bodies["PATCH /api/v1/namespaces/myns/persistentvolumeclaims/datadir-0"])
+
+		out, err = client.Run(ctx, []string{"delete", "sts", "test-pxc", "--cascade=orphan", "--namespace=tenant"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "statefulsets \"test-pxc\" deleted\n", string(out))
+		assert.Equal(t, "DELETE /apis/apps/v1/namespaces/tenant/statefulsets/test-pxc?propagationPolicy=Orphan", fake.last())
+	})
+
+	t.Run("RunAPIVersions", func(t *testing.T) {
+		out, err := client.Run(ctx, []string{"api-versions"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "apps/v1\nv1\n", string(out))
+
+		// CRD installed after the client is created is visible without a new client
+		fake.m.Lock()
+		fake.crdInstalled = true
+		fake.m.Unlock()
+		out, err = client.Run(ctx, []string{"api-versions"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "apps/v1\npxc.percona.com/v1\nv1\n", string(out))
+	})
+
+	t.Run("ApplyDelete", func(t *testing.T) {
+		secret := []byte(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: test-secret
+`)
+		require.NoError(t, client.Apply(ctx, "", secret))
+		assert.Equal(t, "PATCH /api/v1/namespaces/myns/secrets/test-secret?fieldManager=dbaas-controller&force=true", fake.last())
+		assert.Contains(t, fake.bodies["PATCH /api/v1/namespaces/myns/secrets/test-secret"], contentTypeApplyPatch)
+
+		require.NoError(t, client.Apply(ctx, "tenant", secret))
+		assert.Equal(t, "PATCH /api/v1/namespaces/tenant/secrets/test-secret?fieldManager=dbaas-controller&force=true", fake.last())
+
+		require.NoError(t, client.Delete(ctx, "", secret))
+		assert.Equal(t, "DELETE /api/v1/namespaces/myns/secrets/test-secret?propagationPolicy=Background", fake.last())
+
+		require.NoError(t, client.Delete(ctx, "tenant", secret))
+		assert.Equal(t, "DELETE /api/v1/namespaces/tenant/secrets/test-secret?propagationPolicy=Background", fake.last())
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		events := make(chan common.WatchEvent, 2)
+		require.NoError(t, client.Watch(ctx, "", "pods", "app=test", events))
+		assert.Equal(t, "GET /api/v1/namespaces/myns/pods?labelSelector=app%3Dtest&watch=true", fake.last())
+		require.Len(t, events, 2)
+		assert.Equal(t, common.WatchEventAdded, (<-events).Type)
+		assert.Equal(t, common.WatchEventDeleted, (<-events).Type)
+
+		err = client.Watch(ctx, "other", "pods", "", events)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err = client.Run(ctx, []string{"exec", "pod-0"}, nil)
+		assert.EqualError(t, err, `kubectl command "exec pod-0" is not supported by Kubernetes API client`)
+	})
+}
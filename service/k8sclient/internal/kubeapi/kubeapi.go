@@ -0,0 +1,437 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package kubeapi provides in-process Kubernetes API client with the same
+// interface as kubectl CLI wrapper.
+package kubeapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+const (
+	fieldManager = "dbaas-controller"
+
+	contentTypeJSON           = "application/json"
+	contentTypeMergePatch     = "application/merge-patch+json"
+	contentTypeStrategicPatch = "application/strategic-merge-patch+json"
+	contentTypeApplyPatch     = "application/apply-patch+yaml"
+)
+
+//nolint:gochecknoglobals
+var (
+	podsResource   = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	eventsResource = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+)
+
+// ErrNotFound should be returned when referenced resource does not exist
+// inside Kubernetes cluster. It is the same error kubectl CLI wrapper returns
+// so callers could handle both clients in the same way.
+var ErrNotFound = kubectl.ErrNotFound
+
+// KubeAPI talks to Kubernetes API server directly without spawning kubectl processes.
+type KubeAPI struct {
+	l         logger.Logger
+	client    rest.Interface
+	discovery discovery.CachedDiscoveryInterface
+	mapper    *restmapper.DeferredDiscoveryRESTMapper
+	expander  meta.RESTMapper
+	namespace string
+}
+
+// object holds the part of Kubernetes object required to build its API path.
+type object struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	raw []byte
+}
+
+// NewKubeAPI creates a new KubeAPI object with a given kubeconfig.
+// Empty kubeconfig means default loading rules: in-cluster config or ~/.kube/config.
+func NewKubeAPI(ctx context.Context, kubeconfig string) (*KubeAPI, error) {
+	l := logger.Get(ctx)
+	l = l.WithField("component", "kubeapi")
+
+	var clientConfig clientcmd.ClientConfig
+	if kubeconfig == "" {
+		clientConfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			clientcmd.NewDefaultClientConfigLoadingRules(),
+			new(clientcmd.ConfigOverrides),
+		)
+	} else {
+		var err error
+		clientConfig, err = clientcmd.NewClientConfigFromBytes([]byte(kubeconfig))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse kubeconfig")
+		}
+	}
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot build Kubernetes client config")
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get default namespace")
+	}
+	config.UserAgent = fieldManager
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create Kubernetes discovery client")
+	}
+
+	// The same check as `kubectl version` does in kubectl wrapper.
+	serverVersion, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get Kubernetes server version")
+	}
+	l.Infof("Using Kubernetes API %s, namespace %q", serverVersion.GitVersion, namespace)
+
+	// Discovery client has its own request timeout, use a separate client
+	// so long requests like following logs are limited only by context.
+	restConfig := rest.CopyConfig(config)
+	restConfig.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	client, err := rest.UnversionedRESTClientFor(restConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create Kubernetes REST client")
+	}
+
+	cached := memory.NewMemCacheClient(discoveryClient)
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(cached)
+	return &KubeAPI{
+		l:         l,
+		client:    client,
+		discovery: cached,
+		mapper:    mapper,
+		expander:  restmapper.NewShortcutExpander(mapper, cached),
+		namespace: namespace,
+	}, nil
+}
+
+// Cleanup exists to match kubectl CLI wrapper, KubeAPI does not create temporary files.
+func (k *KubeAPI) Cleanup() error {
+	return nil
+}
+
+// Get gets object of a given kind and optional name from the given namespace,
+// and decodes resource into `res`. Empty name means a list of all objects of that kind,
+// empty namespace means the default one.
+func (k *KubeAPI) Get(ctx context.Context, namespace, kind, name string, res interface{}) error {
+	mapping, err := k.mappingForResource(kind)
+	if err != nil {
+		return err
+	}
+
+	body, err := k.do(ctx, http.MethodGet, k.path(mapping, k.namespaceOrDefault(namespace), name), nil, "", nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, res)
+}
+
+// Apply creates or updates given resource using server-side apply. Resource
+// could be either a structure or a YAML/JSON bytes with one or more documents.
+// Namespace is used for namespaced objects without metadata.namespace,
+// empty namespace means the default one.
+func (k *KubeAPI) Apply(ctx context.Context, namespace string, res interface{}) error {
+	objects, err := decodeObjects(res)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		if err = k.apply(ctx, k.namespaceOrDefault(namespace), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KubeAPI) apply(ctx context.Context, namespace string, obj *object) error {
+	mapping, err := k.mappingForObject(obj)
+	if err != nil {
+		return err
+	}
+	if obj.Metadata.Namespace != "" {
+		namespace = obj.Metadata.Namespace
+	}
+
+	objPath := k.path(mapping, namespace, obj.Metadata.Name)
+	params := url.Values{"fieldManager": {fieldManager}, "force": {"true"}}
+	_, err = k.do(ctx, http.MethodPatch, objPath, params, contentTypeApplyPatch, obj.raw)
+
+	// Server-side apply is not available before Kubernetes 1.16, fall back to create or merge.
+	var apiErr *kubeAPIError
+	if !errors.As(err, &apiErr) || apiErr.code != http.StatusUnsupportedMediaType {
+		return err
+	}
+	_, err = k.do(ctx, http.MethodPost, k.path(mapping, namespace, ""), nil, contentTypeJSON, obj.raw)
+	if !errors.As(err, &apiErr) || apiErr.code != http.StatusConflict {
+		return err
+	}
+	_, err = k.do(ctx, http.MethodPatch, objPath, nil, contentTypeMergePatch, obj.raw)
+	return err
+}
+
+// Delete deletes given resource. Resource could be either a structure or
+// a YAML/JSON bytes with one or more documents. Namespace is used the same way as in Apply.
+func (k *KubeAPI) Delete(ctx context.Context, namespace string, res interface{}) error {
+	objects, err := decodeObjects(res)
+	if err != nil {
+		return err
+	}
+
+	namespace = k.namespaceOrDefault(namespace)
+	for _, obj := range objects {
+		mapping, err := k.mappingForObject(obj)
+		if err != nil {
+			return err
+		}
+		objNamespace := namespace
+		if obj.Metadata.Namespace != "" {
+			objNamespace = obj.Metadata.Namespace
+		}
+
+		params := url.Values{"propagationPolicy": {"Background"}}
+		if _, err = k.do(ctx, http.MethodDelete, k.path(mapping, objNamespace, obj.Metadata.Name), params, "", nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Watch watches objects of a given kind matching optional label selector in the given namespace,
+// and sends events to `events` until the watch is closed by API server or ctx is canceled.
+// Empty namespace means the default one.
+func (k *KubeAPI) Watch(ctx context.Context, namespace, kind, selector string, events chan<- common.WatchEvent) error {
+	mapping, err := k.mappingForResource(kind)
+	if err != nil {
+		return err
+	}
+
+	absPath := k.path(mapping, k.namespaceOrDefault(namespace), "")
+	req := k.client.Get().AbsPath(absPath).Param("watch", "true")
+	if selector != "" {
+		req = req.Param("labelSelector", selector)
+	}
+	k.l.Debugf("GET %s?watch=true", absPath)
+
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return streamError(err, "WATCH "+absPath)
+	}
+	defer stream.Close() //nolint:errcheck
+
+	return common.DecodeWatchEvents(ctx, stream, events)
+}
+
+// namespaceOrDefault returns given namespace or the default one if it is empty.
+func (k *KubeAPI) namespaceOrDefault(namespace string) string {
+	if namespace == "" {
+		return k.namespace
+	}
+	return namespace
+}
+
+// mappingForResource returns REST mapping for kubectl-like resource name:
+// kind, plural or singular resource name or its short name.
+func (k *KubeAPI) mappingForResource(resource string) (*meta.RESTMapping, error) {
+	gvr := schema.GroupVersionResource{Resource: strings.ToLower(resource)}
+	if i := strings.Index(gvr.Resource, "."); i > 0 {
+		// Fully qualified name like "statefulsets.apps".
+		gvr.Group = gvr.Resource[i+1:]
+		gvr.Resource = gvr.Resource[:i]
+	}
+
+	fullGVR, err := k.expander.ResourceFor(gvr)
+	if meta.IsNoMatchError(err) {
+		// CRDs could be installed after discovery information was cached.
+		k.mapper.Reset()
+		fullGVR, err = k.expander.ResourceFor(gvr)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot find resource %q", resource)
+	}
+
+	gvk, err := k.expander.KindFor(fullGVR)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot find kind of resource %q", resource)
+	}
+	return k.expander.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// mappingForObject returns REST mapping for given object's apiVersion and kind.
+func (k *KubeAPI) mappingForObject(obj *object) (*meta.RESTMapping, error) {
+	gv, err := schema.ParseGroupVersion(obj.APIVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse apiVersion of %s %q", obj.Kind, obj.Metadata.Name)
+	}
+
+	gk := schema.GroupKind{Group: gv.Group, Kind: obj.Kind}
+	mapping, err := k.mapper.RESTMapping(gk, gv.Version)
+	if meta.IsNoMatchError(err) {
+		k.mapper.Reset()
+		mapping, err = k.mapper.RESTMapping(gk, gv.Version)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot find resource for %s %q", obj.Kind, obj.Metadata.Name)
+	}
+	return mapping, nil
+}
+
+func (k *KubeAPI) path(mapping *meta.RESTMapping, namespace, name string) string {
+	return resourcePath(mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, namespace, name)
+}
+
+// resourcePath returns API path of resource. Empty namespace for namespaced
+// resource means all namespaces, empty name means a list of objects.
+func resourcePath(gvr schema.GroupVersionResource, namespaced bool, namespace, name string) string {
+	parts := []string{"/apis", gvr.Group, gvr.Version}
+	if gvr.Group == "" {
+		parts = []string{"/api", gvr.Version}
+	}
+	if namespaced && namespace != "" {
+		parts = append(parts, "namespaces", namespace)
+	}
+	parts = append(parts, gvr.Resource)
+	if name != "" {
+		parts = append(parts, name)
+	}
+	return path.Join(parts...)
+}
+
+// do executes request to Kubernetes API server and returns response body.
+func (k *KubeAPI) do(ctx context.Context, method, absPath string, params url.Values, contentType string, body []byte) ([]byte, error) {
+	req := k.client.Verb(method).AbsPath(absPath)
+	for name, values := range params {
+		for _, value := range values {
+			req = req.Param(name, value)
+		}
+	}
+	if body != nil {
+		req = req.SetHeader("Content-Type", contentType).Body(body)
+		k.l.Debugf("%s %s with input:\n%s", method, absPath, body)
+	} else {
+		k.l.Debugf("%s %s", method, absPath)
+	}
+
+	var code int
+	out, err := req.Do(ctx).StatusCode(&code).Raw()
+	if code == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, &kubeAPIError{
+			err:  errors.WithStack(err),
+			code: code,
+			req:  method + " " + absPath,
+		}
+	}
+	return out, nil
+}
+
+// decodeObjects converts structure or YAML/JSON bytes with one or more documents into objects.
+func decodeObjects(res interface{}) ([]*object, error) {
+	b, ok := res.([]byte)
+	if !ok {
+		var err error
+		if b, err = json.Marshal(res); err != nil {
+			return nil, err
+		}
+	}
+
+	var objects []*object
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(b), 4096)
+	for {
+		var doc json.RawMessage
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode Kubernetes object")
+		}
+		if len(doc) == 0 || string(doc) == "null" {
+			continue
+		}
+
+		obj := &object{raw: doc}
+		if err = json.Unmarshal(doc, obj); err != nil {
+			return nil, errors.Wrap(err, "cannot decode Kubernetes object")
+		}
+		if obj.Kind == "" || obj.APIVersion == "" {
+			return nil, errors.Errorf("apiVersion and kind should be set for object %q", obj.Metadata.Name)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// streamError converts error of streaming request to ErrNotFound or kubeAPIError.
+func streamError(err error, req string) error {
+	if apierrors.IsNotFound(err) {
+		return ErrNotFound
+	}
+	var code int
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		code = int(status.Status().Code)
+	}
+	return &kubeAPIError{
+		err:  errors.WithStack(err),
+		code: code,
+		req:  req,
+	}
+}
+
+type kubeAPIError struct {
+	err  error
+	code int
+	req  string
+}
+
+func (e *kubeAPIError) Error() string {
+	return fmt.Sprintf("%s\nrequest: %s\nstatus code: %d", e.err, e.req, e.code)
+}
@@ -0,0 +1,138 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package kubeapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseArgs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get pods", func(t *testing.T) {
+		t.Parallel()
+		cmd := parseArgs([]string{"pods", "-lapp.kubernetes.io/instance=test", "-ntest-ns", "hello1", "-ojson"})
+		assert.Equal(t, []string{"pods", "hello1"}, cmd.positional)
+		assert.Equal(t, map[string]string{
+			"selector":  "app.kubernetes.io/instance=test",
+			"namespace": "test-ns",
+			"output":    "json",
+		}, cmd.flags)
+	})
+
+	t.Run("separate values and bool flags", func(t *testing.T) {
+		t.Parallel()
+		cmd := parseArgs([]string{"--all-namespaces", "pods", "-n", "ns", "--output=json", "-p", "--since-time", "2021-01-01T00:00:00Z"})
+		assert.Equal(t, []string{"pods"}, cmd.positional)
+		assert.Equal(t, map[string]string{
+			"all-namespaces": "true",
+			"namespace":      "ns",
+			"output":         "json",
+			"previous":       "true",
+			"since-time":     "2021-01-01T00:00:00Z",
+		}, cmd.flags)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		t.Parallel()
+		cmd := parseArgs([]string{"--raw", "/api/v1/nodes/node1/proxy/stats/summary"})
+		assert.Empty(t, cmd.positional)
+		assert.Equal(t, "/api/v1/nodes/node1/proxy/stats/summary", cmd.flags["raw"])
+	})
+}
+
+func TestResourcePath(t *testing.T) {
+	t.Parallel()
+
+	pxc := schema.GroupVersionResource{Group: "pxc.percona.com", Version: "v1-8-0", Resource: "perconaxtradbclusters"}
+	pv := schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}
+	for _, tc := range []struct {
+		gvr        schema.GroupVersionResource
+		namespaced bool
+		namespace  string
+		name       string
+		expected   string
+	}{
+		{podsResource, true, "default", "", "/api/v1/namespaces/default/pods"},
+		{podsResource, true, "", "", "/api/v1/pods"},
+		{podsResource, true, "ns", "pod-0", "/api/v1/namespaces/ns/pods/pod-0"},
+		{pv, false, "default", "pv-1", "/api/v1/persistentvolumes/pv-1"},
+		{pxc, true, "default", "cluster", "/apis/pxc.percona.com/v1-8-0/namespaces/default/perconaxtradbclusters/cluster"},
+	} {
+		assert.Equal(t, tc.expected, resourcePath(tc.gvr, tc.namespaced, tc.namespace, tc.name))
+	}
+}
+
+func TestDecodeObjects(t *testing.T) {
+	t.Parallel()
+
+	t.Run("YAML documents", func(t *testing.T) {
+		t.Parallel()
+		objects, err := decodeObjects([]byte(`---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: percona-xtradb-cluster-operator
+---
+# empty document
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: percona-xtradb-cluster-operator
+  namespace: pxc
+`))
+		require.NoError(t, err)
+		require.Len(t, objects, 2)
+		assert.Equal(t, "ServiceAccount", objects[0].Kind)
+		assert.Equal(t, "apps/v1", objects[1].APIVersion)
+		assert.Equal(t, "pxc", objects[1].Metadata.Namespace)
+		assert.JSONEq(t, `{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"name":"percona-xtradb-cluster-operator"}}`, string(objects[0].raw))
+	})
+
+	t.Run("structure", func(t *testing.T) {
+		t.Parallel()
+		secret := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]string{"name": "test"},
+		}
+		objects, err := decodeObjects(secret)
+		require.NoError(t, err)
+		require.Len(t, objects, 1)
+		assert.Equal(t, "test", objects[0].Metadata.Name)
+	})
+
+	t.Run("no kind", func(t *testing.T) {
+		t.Parallel()
+		_, err := decodeObjects([]byte(`{"metadata": {"name": "test"}}`))
+		assert.EqualError(t, err, `apiVersion and kind should be set for object "test"`)
+	})
+}
+
+func TestShortDuration(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "59s", shortDuration(59*time.Second))
+	assert.Equal(t, "5m", shortDuration(5*time.Minute+10*time.Second))
+	assert.Equal(t, "3h", shortDuration(3*time.Hour))
+	assert.Equal(t, "4d", shortDuration(100*time.Hour))
+}
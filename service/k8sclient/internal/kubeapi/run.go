@@ -0,0 +1,449 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package kubeapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// shortFlags maps kubectl short flags to their long names.
+var shortFlags = map[string]string{ //nolint:gochecknoglobals
+	"A": "all-namespaces",
+	"c": "container",
+	"f": "follow",
+	"l": "selector",
+	"n": "namespace",
+	"o": "output",
+	"p": "previous",
+}
+
+// boolFlags contains kubectl flags without value.
+var boolFlags = map[string]struct{}{ //nolint:gochecknoglobals
+	"all-namespaces": {},
+	"follow":         {},
+	"previous":       {},
+	"timestamps":     {},
+}
+
+// cmdArgs holds parsed kubectl arguments.
+type cmdArgs struct {
+	positional []string
+	flags      map[string]string
+}
+
+// parseArgs parses kubectl arguments like "-lapp=x", "-n", "ns" or "--output=json".
+func parseArgs(args []string) cmdArgs {
+	res := cmdArgs{flags: make(map[string]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			res.positional = append(res.positional, arg)
+			continue
+		}
+
+		var name, value string
+		var hasValue bool
+		if strings.HasPrefix(arg, "--") {
+			name = strings.TrimPrefix(arg, "--")
+			if j := strings.Index(name, "="); j >= 0 {
+				name, value, hasValue = name[:j], name[j+1:], true
+			}
+		} else {
+			name = arg[1:2]
+			if len(arg) > 2 {
+				value, hasValue = strings.TrimPrefix(arg[2:], "="), true
+			}
+		}
+		if long, ok := shortFlags[name]; ok {
+			name = long
+		}
+
+		if !hasValue {
+			if _, ok := boolFlags[name]; ok {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+		}
+		res.flags[name] = value
+	}
+	return res
+}
+
+// Run emulates kubectl commands used by K8sClient: get, logs, describe pod,
+// rollout restart, patch, delete and api-versions. Stdin is not used by any of them.
+func (k *KubeAPI) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no kubectl command given")
+	}
+
+	cmd := parseArgs(args[1:])
+	switch args[0] {
+	case "get":
+		return k.runGet(ctx, cmd)
+	case "logs":
+		return k.runLogs(ctx, cmd)
+	case "describe":
+		return k.runDescribe(ctx, cmd)
+	case "rollout":
+		return k.runRollout(ctx, cmd)
+	case "patch":
+		return k.runPatch(ctx, cmd)
+	case "delete":
+		return k.runDelete(ctx, cmd)
+	case "api-versions":
+		return k.runAPIVersions()
+	}
+	return nil, errors.Errorf("kubectl command %q is not supported by Kubernetes API client", strings.Join(args, " "))
+}
+
+// namespaceFor returns namespace given by flags or the default one.
+func (k *KubeAPI) namespaceFor(cmd cmdArgs) string {
+	if cmd.flags["all-namespaces"] == "true" {
+		return ""
+	}
+	return k.namespaceOrDefault(cmd.flags["namespace"])
+}
+
+func (k *KubeAPI) runGet(ctx context.Context, cmd cmdArgs) ([]byte, error) {
+	if raw, ok := cmd.flags["raw"]; ok {
+		return k.do(ctx, http.MethodGet, raw, nil, "", nil)
+	}
+	if len(cmd.positional) == 0 {
+		return nil, errors.New("resource type is required")
+	}
+
+	resource, names := cmd.positional[0], cmd.positional[1:]
+	mapping, err := k.mappingForResource(resource)
+	if err != nil {
+		return nil, err
+	}
+	namespace := k.namespaceFor(cmd)
+
+	if len(names) == 0 {
+		params := make(url.Values)
+		if selector := cmd.flags["selector"]; selector != "" {
+			params.Set("labelSelector", selector)
+		}
+		if selector := cmd.flags["field-selector"]; selector != "" {
+			params.Set("fieldSelector", selector)
+		}
+		return k.do(ctx, http.MethodGet, k.path(mapping, namespace, ""), params, "", nil)
+	}
+
+	// kubectl returns a single object for one name and a list for more names.
+	items := make([]json.RawMessage, 0, len(names))
+	for _, name := range names {
+		item, err := k.do(ctx, http.MethodGet, k.path(mapping, namespace, name), nil, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	})
+}
+
+func (k *KubeAPI) runLogs(ctx context.Context, cmd cmdArgs) ([]byte, error) {
+	logsPath, params, err := k.logsRequest(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return k.do(ctx, http.MethodGet, logsPath, params, "", nil)
+}
+
+// logsRequest returns API path and parameters of `kubectl logs` command.
+func (k *KubeAPI) logsRequest(cmd cmdArgs) (string, url.Values, error) {
+	if len(cmd.positional) == 0 {
+		return "", nil, errors.New("pod name is required")
+	}
+
+	params := make(url.Values)
+	container := cmd.flags["container"]
+	if len(cmd.positional) > 1 {
+		container = cmd.positional[1]
+	}
+	if container != "" {
+		params.Set("container", container)
+	}
+	if cmd.flags["follow"] == "true" {
+		params.Set("follow", "true")
+	}
+	if cmd.flags["previous"] == "true" {
+		params.Set("previous", "true")
+	}
+	if cmd.flags["timestamps"] == "true" {
+		params.Set("timestamps", "true")
+	}
+	if sinceTime := cmd.flags["since-time"]; sinceTime != "" {
+		params.Set("sinceTime", sinceTime)
+	}
+	if tail := cmd.flags["tail"]; tail != "" && tail != "-1" {
+		params.Set("tailLines", tail)
+	}
+
+	podPath := resourcePath(podsResource, true, k.namespaceFor(cmd), cmd.positional[0])
+	return podPath + "/log", params, nil
+}
+
+// Stream emulates kubectl commands with long running output and writes the output to w
+// until it ends or ctx is canceled. Only logs command is supported.
+func (k *KubeAPI) Stream(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] != "logs" {
+		return errors.Errorf("kubectl command %q is not supported for streaming by Kubernetes API client", strings.Join(args, " "))
+	}
+
+	logsPath, params, err := k.logsRequest(parseArgs(args[1:]))
+	if err != nil {
+		return err
+	}
+	req := k.client.Get().AbsPath(logsPath)
+	for name, values := range params {
+		for _, value := range values {
+			req = req.Param(name, value)
+		}
+	}
+	k.l.Debugf("GET %s?%s", logsPath, params.Encode())
+
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return streamError(err, "GET "+logsPath)
+	}
+	defer stream.Close() //nolint:errcheck
+
+	_, err = io.Copy(w, stream)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.WithStack(err)
+}
+
+// event holds Kubernetes event fields shown by `kubectl describe`.
+type event struct {
+	Type          string    `json:"type"`
+	Reason        string    `json:"reason"`
+	Message       string    `json:"message"`
+	Count         int32     `json:"count"`
+	LastTimestamp time.Time `json:"lastTimestamp"`
+	Source        struct {
+		Component string `json:"component"`
+	} `json:"source"`
+}
+
+// runDescribe supports only pods and prints their events the same way kubectl does.
+func (k *KubeAPI) runDescribe(ctx context.Context, cmd cmdArgs) ([]byte, error) {
+	if len(cmd.positional) != 2 || !isPodResource(cmd.positional[0]) {
+		return nil, errors.Errorf("only `describe pod <name>` is supported, got %v", cmd.positional)
+	}
+
+	name := cmd.positional[1]
+	namespace := k.namespaceFor(cmd)
+	if _, err := k.do(ctx, http.MethodGet, resourcePath(podsResource, true, namespace, name), nil, "", nil); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{"fieldSelector": {"involvedObject.kind=Pod,involvedObject.name=" + name}}
+	out, err := k.do(ctx, http.MethodGet, resourcePath(eventsResource, true, namespace, ""), params, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []event `json:"items"`
+	}
+	if err = json.Unmarshal(out, &list); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Name:         %s\nNamespace:    %s\n", name, namespace)
+	if len(list.Items) == 0 {
+		buf.WriteString("Events:       <none>\n")
+		return buf.Bytes(), nil
+	}
+
+	sort.SliceStable(list.Items, func(i, j int) bool {
+		return list.Items[i].LastTimestamp.Before(list.Items[j].LastTimestamp)
+	})
+	buf.WriteString("Events:\n")
+	w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  Type\tReason\tAge\tFrom\tMessage")
+	fmt.Fprintln(w, "  ----\t------\t----\t----\t-------")
+	for _, e := range list.Items {
+		age := "<unknown>"
+		if !e.LastTimestamp.IsZero() {
+			age = shortDuration(time.Since(e.LastTimestamp))
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", e.Type, e.Reason, age, e.Source.Component, strings.TrimSpace(e.Message))
+	}
+	if err = w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// runRollout supports only `rollout restart <kind> <name>`, it works the same
+// way as kubectl by changing pod template annotation.
+func (k *KubeAPI) runRollout(ctx context.Context, cmd cmdArgs) ([]byte, error) {
+	args := cmd.positional
+	if len(args) == 2 && strings.Contains(args[1], "/") {
+		args = append([]string{args[0]}, strings.SplitN(args[1], "/", 2)...)
+	}
+	if len(args) != 3 || args[0] != "restart" {
+		return nil, errors.Errorf("only `rollout restart <kind> <name>` is supported, got %v", cmd.positional)
+	}
+
+	mapping, err := k.mappingForResource(args[1])
+	if err != nil {
+		return nil, err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						"kubectl.kubernetes.io/restartedAt": time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = k.do(ctx, http.MethodPatch, k.path(mapping, k.namespaceFor(cmd), args[2]), nil, contentTypeStrategicPatch, patch)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%s/%s restarted\n", mapping.Resource.Resource, args[2])), nil
+}
+
+// runPatch supports only `patch <kind> <name> --type=<type> --patch=<patch>`.
+func (k *KubeAPI) runPatch(ctx context.Context, cmd cmdArgs) ([]byte, error) {
+	if len(cmd.positional) != 2 || cmd.flags["patch"] == "" {
+		return nil, errors.Errorf("only `patch <kind> <name> --patch=<patch>` is supported, got %v", cmd.positional)
+	}
+
+	var contentType string
+	switch cmd.flags["type"] {
+	case "", "strategic":
+		contentType = contentTypeStrategicPatch
+	case "merge":
+		contentType = contentTypeMergePatch
+	default:
+		return nil, errors.Errorf("patch type %q is not supported", cmd.flags["type"])
+	}
+
+	mapping, err := k.mappingForResource(cmd.positional[0])
+	if err != nil {
+		return nil, err
+	}
+	path := k.path(mapping, k.namespaceFor(cmd), cmd.positional[1])
+	if _, err = k.do(ctx, http.MethodPatch, path, nil, contentType, []byte(cmd.flags["patch"])); err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%s/%s patched\n", mapping.Resource.Resource, cmd.positional[1])), nil
+}
+
+// runDelete supports only `delete <kind> <name> [--cascade=<policy>]`.
+func (k *KubeAPI) runDelete(ctx context.Context, cmd cmdArgs) ([]byte, error) {
+	if len(cmd.positional) != 2 {
+		return nil, errors.Errorf("only `delete <kind> <name>` is supported, got %v", cmd.positional)
+	}
+
+	var policy string
+	switch cmd.flags["cascade"] {
+	case "", "true", "background":
+		policy = "Background"
+	case "false", "orphan":
+		policy = "Orphan"
+	case "foreground":
+		policy = "Foreground"
+	default:
+		return nil, errors.Errorf("cascade %q is not supported", cmd.flags["cascade"])
+	}
+
+	mapping, err := k.mappingForResource(cmd.positional[0])
+	if err != nil {
+		return nil, err
+	}
+	path := k.path(mapping, k.namespaceFor(cmd), cmd.positional[1])
+	if _, err = k.do(ctx, http.MethodDelete, path, url.Values{"propagationPolicy": {policy}}, "", nil); err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%s \"%s\" deleted\n", mapping.Resource.Resource, cmd.positional[1])), nil
+}
+
+// runAPIVersions returns supported API versions in "group/version" form, one per line.
+// Cached discovery information is dropped first, so CRDs installed after the client is created are returned.
+func (k *KubeAPI) runAPIVersions() ([]byte, error) {
+	k.discovery.Invalidate()
+	groups, err := k.discovery.ServerGroups()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get server API groups")
+	}
+
+	var versions []string
+	for _, group := range groups.Groups {
+		for _, v := range group.Versions {
+			versions = append(versions, v.GroupVersion)
+		}
+	}
+	sort.Strings(versions)
+	return []byte(strings.Join(versions, "\n") + "\n"), nil
+}
+
+func isPodResource(resource string) bool {
+	switch strings.ToLower(resource) {
+	case "po", "pod", "pods":
+		return true
+	}
+	return false
+}
+
+// shortDuration formats duration the same way kubectl shows age of objects.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < 2*time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < 2*time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
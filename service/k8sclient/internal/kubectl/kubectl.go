@@ -22,6 +22,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -32,6 +33,7 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/sys/unix"
 
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
 	"github.com/percona-platform/dbaas-controller/utils/logger"
 )
 
@@ -203,9 +205,10 @@ func (k *KubeCtl) Cleanup() error {
 }
 
 // Get executes `kubectl get` with given object kind and optional name,
-// and decodes resource into `res`.
-func (k *KubeCtl) Get(ctx context.Context, kind string, name string, res interface{}) error {
-	args := []string{"get", "-o=json", kind}
+// and decodes resource into `res`. Empty namespace means the default one.
+func (k *KubeCtl) Get(ctx context.Context, namespace, kind, name string, res interface{}) error {
+	args := append([]string{"get", "-o=json"}, namespaceArgs(namespace)...)
+	args = append(args, kind)
 	if name != "" {
 		args = append(args, name)
 	}
@@ -218,18 +221,30 @@ func (k *KubeCtl) Get(ctx context.Context, kind string, name string, res interfa
 	return json.Unmarshal(stdout, res)
 }
 
-// Apply executes `kubectl apply` with given resource.
-func (k *KubeCtl) Apply(ctx context.Context, res interface{}) error {
-	_, err := run(ctx, k.cmd, []string{"apply", "-f", "-"}, res)
+// Apply executes `kubectl apply` with given resource. Namespace is used for
+// namespaced objects without metadata.namespace, empty namespace means the default one.
+func (k *KubeCtl) Apply(ctx context.Context, namespace string, res interface{}) error {
+	args := append([]string{"apply"}, namespaceArgs(namespace)...)
+	_, err := run(ctx, k.cmd, append(args, "-f", "-"), res)
 	return err
 }
 
-// Delete executes `kubectl delete` with given resource.
-func (k *KubeCtl) Delete(ctx context.Context, res interface{}) error {
-	_, err := run(ctx, k.cmd, []string{"delete", "-f", "-"}, res)
+// Delete executes `kubectl delete` with given resource. Namespace is used for
+// namespaced objects without metadata.namespace, empty namespace means the default one.
+func (k *KubeCtl) Delete(ctx context.Context, namespace string, res interface{}) error {
+	args := append([]string{"delete"}, namespaceArgs(namespace)...)
+	_, err := run(ctx, k.cmd, append(args, "-f", "-"), res)
 	return err
 }
 
+// namespaceArgs returns kubectl arguments for a given namespace.
+func namespaceArgs(namespace string) []string {
+	if namespace == "" {
+		return nil
+	}
+	return []string{"--namespace=" + namespace}
+}
+
 // Run wraps func run.
 func (k *KubeCtl) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
 	out, err := run(ctx, k.cmd, args, stdin)
@@ -239,6 +254,87 @@ func (k *KubeCtl) Run(ctx context.Context, args []string, stdin interface{}) ([]
 	return out, nil
 }
 
+// Watch executes `kubectl get --watch` with given object kind and optional label selector,
+// and sends events to `events` until the watch is closed or ctx is canceled.
+// Empty namespace means the default one.
+func (k *KubeCtl) Watch(ctx context.Context, namespace, kind, selector string, events chan<- common.WatchEvent) error {
+	args := append([]string{"get", "--watch", "--output-watch-events", "-o=json"}, namespaceArgs(namespace)...)
+	if selector != "" {
+		args = append(args, "--selector="+selector)
+	}
+	args = append(k.cmd, append(args, kind)...)
+	argsString := strings.Join(args, " ")
+	k.l.Debugf("Running %s", argsString)
+
+	var errBuf bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+	pdeathsig.Set(cmd, unix.SIGKILL)
+	cmd.Stderr = &errBuf
+	cmd.Env = cmdEnv()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	decodeErr := common.DecodeWatchEvents(ctx, stdout, events)
+	if decodeErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	if err = streamError(ctx, cmd.Wait(), argsString, errBuf.String()); err != nil {
+		return err
+	}
+	return decodeErr
+}
+
+// Stream executes kubectl with given arguments and writes its output to w
+// until kubectl exits or ctx is canceled.
+func (k *KubeCtl) Stream(ctx context.Context, args []string, w io.Writer) error {
+	args = append(k.cmd, args...)
+	argsString := strings.Join(args, " ")
+	k.l.Debugf("Running %s", argsString)
+
+	var errBuf bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+	pdeathsig.Set(cmd, unix.SIGKILL)
+	cmd.Stdout = w
+	cmd.Stderr = &errBuf
+	cmd.Env = cmdEnv()
+	return streamError(ctx, cmd.Run(), argsString, errBuf.String())
+}
+
+// streamError converts error of kubectl with long running output: canceled context is not kubectl failure.
+func streamError(ctx context.Context, err error, cmd, stderr string) error {
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case err == nil:
+		return nil
+	case strings.Contains(stderr, "NotFound"):
+		return ErrNotFound
+	}
+	return &kubeCtlError{
+		err:    errors.WithStack(err),
+		cmd:    cmd,
+		stderr: stderr,
+	}
+}
+
+// cmdEnv returns environment for kubectl with dbaas tools in PATH.
+func cmdEnv() []string {
+	envs := os.Environ()
+	res := make([]string, 0, len(envs))
+	for _, env := range envs {
+		if strings.HasPrefix(env, "PATH=") {
+			env = fmt.Sprintf("PATH=%s:%s", dbaasToolPath, os.Getenv("PATH"))
+		}
+		res = append(res, env)
+	}
+	return res
+}
+
 // run executes kubectl with given kubectl binary/command, arguments and stdin data (encoded as JSON),
 // and returns stdout, stderr and execution error.
 func run(ctx context.Context, kubectlCmd []string, args []string, stdin interface{}) ([]byte, error) {
@@ -270,13 +366,7 @@ func run(ctx context.Context, kubectlCmd []string, args []string, stdin interfac
 	cmd.Stdin = &inBuf
 	cmd.Stdout = &outBuf
 	cmd.Stderr = &errBuf
-	envs := os.Environ()
-	for _, env := range envs {
-		if strings.HasPrefix(env, "PATH=") {
-			env = fmt.Sprintf("PATH=%s:%s", dbaasToolPath, os.Getenv("PATH"))
-		}
-		cmd.Env = append(cmd.Env, env)
-	}
+	cmd.Env = cmdEnv()
 	err := cmd.Run()
 	if err != nil {
 		if strings.Contains(errBuf.String(), "NotFound") {
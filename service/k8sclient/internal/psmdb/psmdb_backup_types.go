@@ -0,0 +1,78 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package psmdb
+
+import (
+	"time"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+// PerconaServerMongoDBBackupList holds exported fields representing Percona Server for MongoDB backup list.
+type PerconaServerMongoDBBackupList struct {
+	common.TypeMeta // anonymous for embedding
+
+	Items []PerconaServerMongoDBBackup `json:"items"`
+}
+
+// PerconaServerMongoDBBackup represents a Percona Server for MongoDB backup.
+type PerconaServerMongoDBBackup struct {
+	common.TypeMeta   // anonymous for embedding
+	common.ObjectMeta `json:"metadata"`
+	Spec              PerconaServerMongoDBBackupSpec   `json:"spec"`
+	Status            PerconaServerMongoDBBackupStatus `json:"status,omitempty"`
+}
+
+// PerconaServerMongoDBBackupSpec defines the desired state of PerconaServerMongoDBBackup.
+type PerconaServerMongoDBBackupSpec struct {
+	PSMDBCluster string `json:"psmdbCluster,omitempty"`
+	StorageName  string `json:"storageName,omitempty"`
+}
+
+// PerconaServerMongoDBBackupStatus defines the observed state of PerconaServerMongoDBBackup.
+type PerconaServerMongoDBBackupStatus struct {
+	State          BackupState          `json:"state,omitempty"`
+	StartAt        *time.Time           `json:"start,omitempty"`
+	CompletedAt    *time.Time           `json:"completed,omitempty"`
+	LastTransition *time.Time           `json:"lastTransition,omitempty"`
+	Destination    string               `json:"destination,omitempty"`
+	StorageName    string               `json:"storageName,omitempty"`
+	S3             *BackupStorageS3Spec `json:"s3,omitempty"`
+	ReplsetNames   []string             `json:"replsetNames,omitempty"`
+	PBMName        string               `json:"pbmName,omitempty"`
+	Error          string               `json:"error,omitempty"`
+}
+
+// BackupState PSMDB backup state string.
+type BackupState string
+
+const (
+	// BackupStateNew backup is just created.
+	BackupStateNew BackupState = ""
+	// BackupStateWaiting backup waits for the cluster.
+	BackupStateWaiting BackupState = "waiting"
+	// BackupStateRequested backup is requested from backup agent.
+	BackupStateRequested BackupState = "requested"
+	// BackupStateRejected backup is rejected.
+	BackupStateRejected BackupState = "rejected"
+	// BackupStateRunning backup is running.
+	BackupStateRunning BackupState = "running"
+	// BackupStateError backup failed.
+	BackupStateError BackupState = "error"
+	// BackupStateReady backup is done.
+	BackupStateReady BackupState = "ready"
+)
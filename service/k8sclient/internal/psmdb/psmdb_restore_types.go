@@ -0,0 +1,75 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package psmdb
+
+import (
+	"time"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+// PerconaServerMongoDBRestoreSpec defines the desired state of PerconaServerMongoDBRestore.
+type PerconaServerMongoDBRestoreSpec struct {
+	ClusterName  string                            `json:"clusterName,omitempty"`
+	BackupName   string                            `json:"backupName,omitempty"`
+	BackupSource *PerconaServerMongoDBBackupStatus `json:"backupSource,omitempty"`
+}
+
+// PerconaServerMongoDBRestoreStatus defines the observed state of PerconaServerMongoDBRestore.
+type PerconaServerMongoDBRestoreStatus struct {
+	State          RestoreState `json:"state,omitempty"`
+	PBMName        string       `json:"pbmName,omitempty"`
+	Error          string       `json:"error,omitempty"`
+	CompletedAt    *time.Time   `json:"completed,omitempty"`
+	LastTransition *time.Time   `json:"lastTransition,omitempty"`
+}
+
+// PerconaServerMongoDBRestore is the Schema for the perconaservermongodbrestores API.
+type PerconaServerMongoDBRestore struct {
+	common.TypeMeta   // anonymous for embedding
+	common.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PerconaServerMongoDBRestoreSpec   `json:"spec,omitempty"`
+	Status PerconaServerMongoDBRestoreStatus `json:"status,omitempty"`
+}
+
+// PerconaServerMongoDBRestoreList contains a list of PerconaServerMongoDBRestore.
+type PerconaServerMongoDBRestoreList struct {
+	common.TypeMeta // anonymous for embedding
+
+	Items []PerconaServerMongoDBRestore `json:"items"`
+}
+
+// RestoreState PSMDB restore state string.
+type RestoreState string
+
+const (
+	// RestoreStateNew restore is just created.
+	RestoreStateNew RestoreState = ""
+	// RestoreStateWaiting restore waits for the cluster.
+	RestoreStateWaiting RestoreState = "waiting"
+	// RestoreStateRequested restore is requested from backup agent.
+	RestoreStateRequested RestoreState = "requested"
+	// RestoreStateRejected restore is rejected.
+	RestoreStateRejected RestoreState = "rejected"
+	// RestoreStateRunning restore is running.
+	RestoreStateRunning RestoreState = "running"
+	// RestoreStateError restore failed.
+	RestoreStateError RestoreState = "error"
+	// RestoreStateReady restore is done.
+	RestoreStateReady RestoreState = "ready"
+)
@@ -18,6 +18,8 @@
 package psmdb
 
 import (
+	"time"
+
 	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
 )
 
@@ -64,20 +66,30 @@ type ShardingSpec struct {
 
 // PerconaServerMongoDBSpec defines the desired state of PerconaServerMongoDB.
 type PerconaServerMongoDBSpec struct {
-	CRVersion               string         `json:"crVersion,omitempty"`
-	Pause                   bool           `json:"pause,omitempty"`
-	UnsafeConf              bool           `json:"allowUnsafeConfigurations"`
-	RunUID                  int64          `json:"runUid,omitempty"`
-	Platform                *platform      `json:"platform,omitempty"`
-	Image                   string         `json:"image,omitempty"`
-	Mongod                  *MongodSpec    `json:"mongod,omitempty"`
-	Replsets                []*ReplsetSpec `json:"replsets,omitempty"`
-	Secrets                 *SecretsSpec   `json:"secrets,omitempty"`
-	Backup                  BackupSpec     `json:"backup,omitempty"`
-	PMM                     PmmSpec        `json:"pmm,omitempty"`
-	SchedulerName           string         `json:"schedulerName,omitempty"`
-	ClusterServiceDNSSuffix string         `json:"clusterServiceDNSSuffix,omitempty"`
-	Sharding                *ShardingSpec  `json:"sharding,omitempty"`
+	CRVersion               string          `json:"crVersion,omitempty"`
+	Pause                   bool            `json:"pause,omitempty"`
+	UnsafeConf              bool            `json:"allowUnsafeConfigurations"`
+	RunUID                  int64           `json:"runUid,omitempty"`
+	Platform                *platform       `json:"platform,omitempty"`
+	Image                   string          `json:"image,omitempty"`
+	Mongod                  *MongodSpec     `json:"mongod,omitempty"`
+	Replsets                []*ReplsetSpec  `json:"replsets,omitempty"`
+	Secrets                 *SecretsSpec    `json:"secrets,omitempty"`
+	Backup                  BackupSpec      `json:"backup,omitempty"`
+	PMM                     PmmSpec         `json:"pmm,omitempty"`
+	SchedulerName           string          `json:"schedulerName,omitempty"`
+	ClusterServiceDNSSuffix string          `json:"clusterServiceDNSSuffix,omitempty"`
+	Sharding                *ShardingSpec   `json:"sharding,omitempty"`
+	UpdateStrategy          string          `json:"updateStrategy,omitempty"`
+	UpgradeOptions          *UpgradeOptions `json:"upgradeOptions,omitempty"`
+}
+
+// UpgradeOptions holds configuration options to handle automatic upgrades.
+type UpgradeOptions struct {
+	VersionServiceEndpoint string `json:"versionServiceEndpoint,omitempty"`
+	Apply                  string `json:"apply,omitempty"`
+	Schedule               string `json:"schedule,omitempty"`
+	SetFCV                 bool   `json:"setFCV,omitempty"`
 }
 
 type replsetMemberStatus struct {
@@ -148,10 +160,11 @@ const (
 )
 
 type clusterCondition struct {
-	Status  conditionStatus      `json:"status"`
-	Type    clusterConditionType `json:"type"`
-	Reason  string               `json:"reason,omitempty"`
-	Message string               `json:"message,omitempty"`
+	Status             conditionStatus      `json:"status"`
+	Type               clusterConditionType `json:"type"`
+	Reason             string               `json:"reason,omitempty"`
+	Message            string               `json:"message,omitempty"`
+	LastTransitionTime *time.Time           `json:"lastTransitionTime,omitempty"`
 }
 
 // PmmSpec defines pmm specification.
@@ -187,12 +200,14 @@ type ReplsetSpec struct {
 	Expose              Expose                          `json:"expose,omitempty"`
 	Size                int32                           `json:"size"`
 	Arbiter             Arbiter                         `json:"arbiter,omitempty"`
+	NonVoting           *NonVoting                      `json:"nonvoting,omitempty"`
 	Resources           *common.PodResources            `json:"resources,omitempty"`
 	Name                string                          `json:"name,omitempty"`
 	ClusterRole         clusterRole                     `json:"clusterRole,omitempty"`
 	VolumeSpec          *common.VolumeSpec              `json:"volumeSpec,omitempty"`
 	LivenessProbe       *livenessProbeExtended          `json:"livenessProbe,omitempty"`
 	PodDisruptionBudget *common.PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+	Configuration       string                          `json:"configuration,omitempty"`
 	MultiAZ
 }
 
@@ -367,26 +382,36 @@ type MongodSpecOperationProfiling struct {
 	RateLimit         int                    `json:"rateLimit,omitempty"`
 }
 
-type compressionType string
+// CompressionType is a backup compression algorithm.
+type CompressionType string
 
 const (
-	compressionTypeNone   compressionType = "none"
-	compressionTypeGZIP   compressionType = "gzip"
-	compressionTypePGZIP  compressionType = "pgzip"
-	compressionTypeSNAPPY compressionType = "snappy"
-	compressionTypeLZ4    compressionType = "lz4"
-	compressionTypeS2     compressionType = "s2"
+	// CompressionTypeNone disables compression.
+	CompressionTypeNone CompressionType = "none"
+	// CompressionTypeGZIP uses gzip.
+	CompressionTypeGZIP CompressionType = "gzip"
+	// CompressionTypePGZIP uses parallel gzip.
+	CompressionTypePGZIP CompressionType = "pgzip"
+	// CompressionTypeSNAPPY uses snappy.
+	CompressionTypeSNAPPY CompressionType = "snappy"
+	// CompressionTypeLZ4 uses lz4.
+	CompressionTypeLZ4 CompressionType = "lz4"
+	// CompressionTypeS2 uses s2.
+	CompressionTypeS2 CompressionType = "s2"
 )
 
-type backupTaskSpec struct {
+// BackupTaskSpec defines scheduled backup task.
+type BackupTaskSpec struct {
 	Name            string          `json:"name"`
 	Enabled         bool            `json:"enabled"`
+	Keep            int             `json:"keep,omitempty"`
 	Schedule        string          `json:"schedule,omitempty"`
 	StorageName     string          `json:"storageName,omitempty"`
-	CompressionType compressionType `json:"compressionType,omitempty"`
+	CompressionType CompressionType `json:"compressionType,omitempty"`
 }
 
-type backupStorageS3Spec struct {
+// BackupStorageS3Spec holds the S3 configuration.
+type BackupStorageS3Spec struct {
 	Bucket            string `json:"bucket"`
 	Prefix            string `json:"prefix,omitempty"`
 	Region            string `json:"region,omitempty"`
@@ -394,24 +419,28 @@ type backupStorageS3Spec struct {
 	CredentialsSecret string `json:"credentialsSecret"`
 }
 
-type backupStorageType string
+// BackupStorageType backup storage type.
+type BackupStorageType string
 
 const (
-	backupStorageFilesystem backupStorageType = "filesystem"
-	backupStorageS3         backupStorageType = "s3"
+	// BackupStorageFilesystem use local filesystem for storage.
+	BackupStorageFilesystem BackupStorageType = "filesystem"
+	// BackupStorageS3 use S3 for storage.
+	BackupStorageS3 BackupStorageType = "s3"
 )
 
-type backupStorageSpec struct {
-	Type backupStorageType   `json:"type"`
-	S3   backupStorageS3Spec `json:"s3,omitempty"`
+// BackupStorageSpec holds backup's storage specs.
+type BackupStorageSpec struct {
+	Type BackupStorageType   `json:"type"`
+	S3   BackupStorageS3Spec `json:"s3,omitempty"`
 }
 
 // BackupSpec defines back up specification.
 type BackupSpec struct {
 	Enabled            bool                         `json:"enabled"`
-	Storages           map[string]backupStorageSpec `json:"storages,omitempty"`
+	Storages           map[string]BackupStorageSpec `json:"storages,omitempty"`
 	Image              string                       `json:"image,omitempty"`
-	Tasks              []backupTaskSpec             `json:"tasks,omitempty"`
+	Tasks              []BackupTaskSpec             `json:"tasks,omitempty"`
 	ServiceAccountName string                       `json:"serviceAccountName,omitempty"`
 	Resources          *common.PodResources         `json:"resources,omitempty"`
 }
@@ -422,3 +451,13 @@ type Arbiter struct {
 	Size    int32 `json:"size"`
 	MultiAZ
 }
+
+// NonVoting defines non-voting replica set members.
+type NonVoting struct {
+	Enabled             bool                            `json:"enabled"`
+	Size                int32                           `json:"size"`
+	Resources           *common.PodResources            `json:"resources,omitempty"`
+	VolumeSpec          *common.VolumeSpec              `json:"volumeSpec,omitempty"`
+	PodDisruptionBudget *common.PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+	MultiAZ
+}
@@ -17,6 +17,8 @@
 package pxc
 
 import (
+	"time"
+
 	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
 )
 
@@ -45,11 +47,27 @@ type PXCBackupSpec struct {
 
 // PXCBackupStatus PXC backup status.
 type PXCBackupStatus struct {
-	State       PXCBackupState       `json:"state,omitempty"`
-	Destination string               `json:"destination,omitempty"`
-	StorageName string               `json:"storageName,omitempty"`
-	S3          *BackupStorageS3Spec `json:"s3,omitempty"`
+	State         PXCBackupState       `json:"state,omitempty"`
+	CompletedAt   *time.Time           `json:"completed,omitempty"`
+	LastScheduled *time.Time           `json:"lastscheduled,omitempty"`
+	Destination   string               `json:"destination,omitempty"`
+	StorageName   string               `json:"storageName,omitempty"`
+	S3            *BackupStorageS3Spec `json:"s3,omitempty"`
+	StorageType   BackupStorageType    `json:"storage_type,omitempty"`
 }
 
 // PXCBackupState PXC backup state string.
 type PXCBackupState string
+
+const (
+	// BackupStateNew backup is just created.
+	BackupStateNew PXCBackupState = ""
+	// BackupStateStarting backup job is being started.
+	BackupStateStarting PXCBackupState = "Starting"
+	// BackupStateRunning backup is running.
+	BackupStateRunning PXCBackupState = "Running"
+	// BackupStateFailed backup failed.
+	BackupStateFailed PXCBackupState = "Failed"
+	// BackupStateSucceeded backup is done.
+	BackupStateSucceeded PXCBackupState = "Succeeded"
+)
@@ -17,6 +17,8 @@
 package pxc
 
 import (
+	"time"
+
 	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
 )
 
@@ -25,12 +27,22 @@ type PerconaXtraDBClusterRestoreSpec struct {
 	PXCCluster   string           `json:"pxcCluster"`
 	BackupName   string           `json:"backupName"`
 	BackupSource *PXCBackupStatus `json:"backupSource"`
+	PITR         *PITR            `json:"pitr,omitempty"`
+}
+
+// PITR defines point-in-time recovery applied after the backup is restored.
+type PITR struct {
+	BackupSource *PXCBackupStatus `json:"backupSource,omitempty"`
+	Type         string           `json:"type"`
+	Date         string           `json:"date,omitempty"`
+	GTID         string           `json:"gtid,omitempty"`
 }
 
 // PerconaXtraDBClusterRestoreStatus defines the observed state of PerconaXtraDBClusterRestore.
 type PerconaXtraDBClusterRestoreStatus struct {
-	State    BcpRestoreStates `json:"state,omitempty"`
-	Comments string           `json:"comments,omitempty"`
+	State     BcpRestoreStates `json:"state,omitempty"`
+	Comments  string           `json:"comments,omitempty"`
+	Completed *time.Time       `json:"completed,omitempty"`
 }
 
 // PerconaXtraDBClusterRestore is the Schema for the perconaxtradbclusterrestores API.
@@ -51,3 +63,22 @@ type PerconaXtraDBClusterRestoreList struct {
 
 // BcpRestoreStates backup restore states.
 type BcpRestoreStates string
+
+const (
+	// RestoreNew restore is just created.
+	RestoreNew BcpRestoreStates = ""
+	// RestoreStarting restore is being started.
+	RestoreStarting BcpRestoreStates = "Starting"
+	// RestoreStopCluster cluster is being stopped before restore.
+	RestoreStopCluster BcpRestoreStates = "Stopping Cluster"
+	// RestoreRestore data is being restored.
+	RestoreRestore BcpRestoreStates = "Restoring"
+	// RestoreStartCluster cluster is being started after restore.
+	RestoreStartCluster BcpRestoreStates = "Starting Cluster"
+	// RestorePITR point-in-time recovery is running.
+	RestorePITR BcpRestoreStates = "Point-in-time recovering"
+	// RestoreFailed restore failed.
+	RestoreFailed BcpRestoreStates = "Failed"
+	// RestoreSucceeded restore is done.
+	RestoreSucceeded BcpRestoreStates = "Succeeded"
+)
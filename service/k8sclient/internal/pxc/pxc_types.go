@@ -18,6 +18,8 @@
 package pxc
 
 import (
+	"time"
+
 	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
 )
 
@@ -107,10 +109,11 @@ type ClusterConditionType string
 
 // ClusterCondition holds exported fields with the cluster condition.
 type ClusterCondition struct {
-	Status  ConditionStatus      `json:"status,omitempty"`
-	Type    ClusterConditionType `json:"type,omitempty"`
-	Reason  string               `json:"reason,omitempty"`
-	Message string               `json:"message,omitempty"`
+	Status             ConditionStatus      `json:"status,omitempty"`
+	Type               ClusterConditionType `json:"type,omitempty"`
+	Reason             string               `json:"reason,omitempty"`
+	Message            string               `json:"message,omitempty"`
+	LastTransitionTime *time.Time           `json:"lastTransitionTime,omitempty"`
 }
 
 // AppStatus holds exported fields representing the application status information.
@@ -21,17 +21,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"reflect"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/AlekSi/pointer"
 	"github.com/avast/retry-go"
 	"github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 
-	dbaascontroller "github.com/percona-platform/dbaas-controller"
 	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
 	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
 	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
@@ -82,11 +80,12 @@ const (
 	pxcSecretNameTmpl       = "dbaas-%s-pxc-secrets"
 	pxcInternalSecretTmpl   = "internal-%s"
 
-	psmdbCRVersion      = "1.8.0"
-	psmdbBackupImage    = "percona/percona-server-mongodb-operator:1.8.0-backup"
-	psmdbDefaultImage   = "percona/percona-server-mongodb:4.2.8-8"
-	psmdbAPIVersion     = "psmdb.percona.com/v1-8-0"
-	psmdbSecretNameTmpl = "dbaas-%s-psmdb-secrets"
+	psmdbCRVersion         = "1.8.0"
+	psmdbBackupImage       = "percona/percona-server-mongodb-operator:1.8.0-backup"
+	psmdbBackupStorageName = "psmdb-backup-storage-%s"
+	psmdbDefaultImage      = "percona/percona-server-mongodb:4.2.8-8"
+	psmdbAPIVersion        = "psmdb.percona.com/v1-8-0"
+	psmdbSecretNameTmpl    = "dbaas-%s-psmdb-secrets"
 
 	// Max size of volume for AWS Elastic Block Storage service is 16TiB.
 	maxVolumeSizeEBS uint64 = 16 * 1024 * 1024 * 1024 * 1024
@@ -101,6 +100,18 @@ const (
 	AmazonEKSClusterType
 	// MinikubeClusterType represents minikube Kubernetes cluster.
 	MinikubeClusterType
+	// GoogleGKEClusterType represents GKE cluster type.
+	GoogleGKEClusterType
+	// AzureAKSClusterType represents AKS cluster type.
+	AzureAKSClusterType
+	// OpenShiftClusterType represents OpenShift cluster on any infrastructure.
+	OpenShiftClusterType
+	// K3sClusterType represents k3s Kubernetes cluster.
+	K3sClusterType
+	// KindClusterType represents kind Kubernetes cluster.
+	KindClusterType
+	// GenericClusterType represents a cluster of other platforms like on-premises ones.
+	GenericClusterType
 )
 
 // ContainerState describes container's state - waiting, running, terminated.
@@ -124,6 +135,14 @@ const (
 	OperatorStatusNotInstalled OperatorStatus = 3
 )
 
+// DefaultNamespace means the namespace of kubeconfig context, or "default" if it is not set there.
+const DefaultNamespace = ""
+
+const (
+	pxcOperatorDeploymentName   = "percona-xtradb-cluster-operator"
+	psmdbOperatorDeploymentName = "percona-server-mongodb-operator"
+)
+
 const (
 	clusterWithSameNameExistsErrTemplate = "Cluster '%s' already exists"
 	canNotGetCredentialsErrTemplate      = "cannot get %s cluster credentials"
@@ -133,6 +152,8 @@ const (
 type Operator struct {
 	Status  OperatorStatus
 	Version string
+	// EmbeddedVersion is the version of embedded operator bundle matching the installed operator, empty if there is none.
+	EmbeddedVersion string
 }
 
 // Operators contains statuses of operators.
@@ -152,6 +173,11 @@ type PXC struct {
 	Image            string
 	ComputeResources *ComputeResources
 	DiskSize         string
+	// StorageClass of volumes, empty value means the default storage class. It is used on creation only.
+	StorageClass string
+	// Configuration is a custom my.cnf fragment. Nil means none on create and no changes on update,
+	// empty value removes custom configuration on update.
+	Configuration *string
 }
 
 // ProxySQL contains information related to ProxySQL containers in Percona XtraDB cluster.
@@ -159,18 +185,40 @@ type ProxySQL struct {
 	Image            string
 	ComputeResources *ComputeResources
 	DiskSize         string
+	// StorageClass of volumes, empty value means the default storage class. It is used on creation only.
+	StorageClass string
+	// Configuration is a custom proxysql.cnf fragment. Nil means none on create and no changes on update,
+	// empty value removes custom configuration on update.
+	Configuration *string
 }
 
 // HAProxy contains information related to HAProxy containers in Percona XtraDB cluster.
 type HAProxy struct {
 	Image            string
 	ComputeResources *ComputeResources
+	// Configuration is a custom haproxy.cfg fragment. Nil means none on create and no changes on update,
+	// empty value removes custom configuration on update.
+	Configuration *string
 }
 
 // Replicaset contains information related to Replicaset containers in PSMDB cluster.
 type Replicaset struct {
+	// Name of the replica set, it is used for shards only.
+	Name string
+	// Size of the replica set, it is used for shards only.
+	Size             int32
 	ComputeResources *ComputeResources
 	DiskSize         string
+	// StorageClass of volumes, empty value means the default storage class. It is used on creation only.
+	StorageClass string
+	// Arbiters is a number of arbiters, 0 or 1. Nil means no arbiters on create and no changes on update.
+	Arbiters *int32
+	// NonVotingMembers is a number of non-voting members. Nil means none on create and no changes on update.
+	// Non-voting members require crVersion 1.9.0 or newer.
+	NonVotingMembers *int32
+	// Configuration is a custom mongod configuration in YAML. Nil means none on create and no changes on update,
+	// empty value removes custom configuration on update.
+	Configuration *string
 }
 
 // PMM contains information related to PMM.
@@ -185,15 +233,34 @@ type PMM struct {
 
 // XtraDBParams contains all parameters required to create or update Percona XtraDB cluster.
 type XtraDBParams struct {
-	Name     string
-	Size     int32
-	Suspend  bool
-	Resume   bool
-	PXC      *PXC
-	ProxySQL *ProxySQL
-	PMM      *PMM
-	HAProxy  *HAProxy
-	Expose   bool
+	Name string
+	// Namespace of the cluster, empty namespace means the default one.
+	Namespace string
+	Size      int32
+	Suspend   bool
+	Resume    bool
+	PXC       *PXC
+	ProxySQL  *ProxySQL
+	PMM       *PMM
+	HAProxy   *HAProxy
+	Expose    bool
+	// BackupSchedules of the cluster, nil means the default schedule, empty slice means no scheduled backups.
+	BackupSchedules []BackupSchedule
+	// BackupStorage is used instead of filesystem backup storage if set.
+	BackupStorage *BackupStorageS3
+	// TLS configuration of the cluster, nil means certificates generated by the operator.
+	// It is used on creation only.
+	TLS *TLSParams
+	// BackupImage is an image of backup jobs, empty value means the default one.
+	BackupImage string
+}
+
+// backupImage returns backup image of the cluster.
+func (p *XtraDBParams) backupImage() string {
+	if p.BackupImage != "" {
+		return p.BackupImage
+	}
+	return pxcBackupImage
 }
 
 // Cluster contains common information related to cluster.
@@ -203,7 +270,9 @@ type Cluster struct {
 
 // PSMDBParams contains all parameters required to create or update percona server for mongodb cluster.
 type PSMDBParams struct {
-	Name       string
+	Name string
+	// Namespace of the cluster, empty namespace means the default one.
+	Namespace  string
 	Image      string
 	Size       int32
 	Suspend    bool
@@ -211,6 +280,28 @@ type PSMDBParams struct {
 	Replicaset *Replicaset
 	PMM        *PMM
 	Expose     bool
+	// BackupStorage is used for backups if set, there is no backup storage otherwise.
+	BackupStorage *BackupStorageS3
+	// BackupTasks of the cluster, nil means no changes on update, empty slice means no scheduled backups.
+	BackupTasks []PSMDBBackupTask
+	// Topology of the cluster, empty means sharded one. It cannot be changed on update.
+	Topology PSMDBTopology
+	// Shards are replica sets of the cluster. Size and Replicaset are used for a single replica set if empty.
+	// On update, shards are matched by name and shards with new names are added.
+	Shards []*Replicaset
+	// TLS configuration of the cluster, nil means certificates generated by the operator.
+	// It is used on creation only, cert-manager issuer is not supported by PSMDB operator.
+	TLS *TLSParams
+	// BackupImage is an image of backup agents, empty value means the default one. It is used on creation only.
+	BackupImage string
+}
+
+// backupImage returns backup image of the cluster.
+func (p *PSMDBParams) backupImage() string {
+	if p.BackupImage != "" {
+		return p.BackupImage
+	}
+	return psmdbBackupImage
 }
 
 type appStatus struct {
@@ -233,6 +324,8 @@ type XtraDBCluster struct {
 	Pause         bool
 	DetailedState DetailedState
 	Exposed       bool
+	// Health contains operator reported components status, conditions and pod problems.
+	Health *ClusterHealth
 }
 
 // PSMDBCluster contains information related to psmdb cluster.
@@ -245,6 +338,11 @@ type PSMDBCluster struct {
 	Replicaset    *Replicaset
 	DetailedState DetailedState
 	Exposed       bool
+	Topology      PSMDBTopology
+	// Shards contains all replica sets of the cluster, Size and Replicaset describe the first one.
+	Shards []*Replicaset
+	// Health contains operator reported components status, conditions and pod problems.
+	Health *ClusterHealth
 }
 
 // PSMDBCredentials represents PSMDB connection credentials.
@@ -254,6 +352,8 @@ type PSMDBCredentials struct {
 	Host       string
 	Port       int32
 	Replicaset string
+	// CABundle is a PEM encoded CA certificate of the cluster, it is empty if TLS secret doesn't exist yet.
+	CABundle string
 }
 
 // XtraDBCredentials represents XtraDB connection credentials.
@@ -262,41 +362,8 @@ type XtraDBCredentials struct {
 	Password string
 	Host     string
 	Port     int32
-}
-
-// StorageClass represents a cluster storage class information.
-// We use the Items.Provisioner to detect if we are running against minikube or AWS.
-// Returned value examples:
-// - AWS EKS: kubernetes.io/aws-ebs
-// - Minukube: k8s.io/minikube-hostpath.
-type StorageClass struct {
-	APIVersion string `json:"apiVersion"`
-	Items      []struct {
-		APIVersion string `json:"apiVersion"`
-		Kind       string `json:"kind"`
-		Metadata   struct {
-			Annotations struct {
-				KubectlKubernetesIoLastAppliedConfiguration string `json:"kubectl.kubernetes.io/last-applied-configuration"`
-				StorageclassKubernetesIoIsDefaultClass      string `json:"storageclass.kubernetes.io/is-default-class"`
-			} `json:"annotations"`
-			CreationTimestamp time.Time `json:"creationTimestamp"`
-			Labels            struct {
-				AddonmanagerKubernetesIoMode string `json:"addonmanager.kubernetes.io/mode"`
-			} `json:"labels"`
-			Name            string `json:"name"`
-			ResourceVersion string `json:"resourceVersion"`
-			SelfLink        string `json:"selfLink"`
-			UID             string `json:"uid"`
-		} `json:"metadata"`
-		Provisioner       string `json:"provisioner"`
-		ReclaimPolicy     string `json:"reclaimPolicy"`
-		VolumeBindingMode string `json:"volumeBindingMode"`
-	} `json:"items"`
-	Kind     string `json:"kind"`
-	Metadata struct {
-		ResourceVersion string `json:"resourceVersion"`
-		SelfLink        string `json:"selfLink"`
-	} `json:"metadata"`
+	// CABundle is a PEM encoded CA certificate of the cluster, it is empty if TLS secret doesn't exist yet.
+	CABundle string
 }
 
 // pxcStatesMap matches pxc app states to cluster states.
@@ -328,8 +395,11 @@ var (
 
 // K8sClient is a client for Kubernetes.
 type K8sClient struct {
-	kubeCtl *kubectl.KubeCtl
-	l       logger.Logger
+	kube kubeClient
+	l    logger.Logger
+
+	// release returns pooled Kubernetes client back to the pool, nil for not pooled clients.
+	release func() error
 }
 
 // CountReadyPods returns number of pods that are ready and belong to the
@@ -349,44 +419,62 @@ func (d DetailedState) CountAllPods() (count int32) {
 	return
 }
 
-// New returns new K8Client object.
+// New returns K8Client object that uses DefaultBackend. Kubernetes clients
+// are cached by DefaultPool, so Cleanup should be called as soon as the object is not needed.
 func New(ctx context.Context, kubeconfig string) (*K8sClient, error) {
+	return DefaultPool.Get(ctx, kubeconfig, DefaultBackend)
+}
+
+// newWithBackend returns new not pooled K8Client object that uses given backend.
+func newWithBackend(ctx context.Context, kubeconfig string, backend Backend) (*K8sClient, error) {
 	l := logger.Get(ctx)
 	l = l.WithField("component", "K8sClient")
 
-	kubeCtl, err := kubectl.NewKubeCtl(ctx, kubeconfig)
+	kube, err := newKubeClient(ctx, kubeconfig, backend)
 	if err != nil {
 		return nil, err
 	}
 	return &K8sClient{
-		kubeCtl: kubeCtl,
-		l:       l,
+		kube: kube,
+		l:    l,
 	}, nil
 }
 
-// Cleanup removes temporary files created by that object.
+// Cleanup returns Kubernetes client to the pool or removes temporary files
+// created by that object if it is not pooled.
 func (c *K8sClient) Cleanup() error {
-	return c.kubeCtl.Cleanup()
+	if c.release != nil {
+		return c.release()
+	}
+	return c.kube.Cleanup()
 }
 
-// ListXtraDBClusters returns list of Percona XtraDB clusters and their statuses.
-func (c *K8sClient) ListXtraDBClusters(ctx context.Context) ([]XtraDBCluster, error) {
-	perconaXtraDBClusters, err := c.getPerconaXtraDBClusters(ctx)
+// ListXtraDBClusters returns list of Percona XtraDB clusters and their statuses in a given namespace.
+// Empty namespace means the default one.
+func (c *K8sClient) ListXtraDBClusters(ctx context.Context, namespace string) ([]XtraDBCluster, error) {
+	pods, err := c.getClusterPods(ctx, namespace, pxcOperatorDeploymentName)
 	if err != nil {
 		return nil, err
 	}
-
-	deletingClusters, err := c.getDeletingXtraDBClusters(ctx, perconaXtraDBClusters)
+	perconaXtraDBClusters, err := c.getPerconaXtraDBClusters(ctx, namespace, pods)
 	if err != nil {
 		return nil, err
 	}
+
+	restores, err := c.ListXtraDBClusterRestores(ctx, namespace, "")
+	if err != nil {
+		c.l.Warnf("Cannot get XtraDB cluster restores: %s.", err)
+	}
+	setXtraDBRestoreStates(perconaXtraDBClusters, restores)
+
+	deletingClusters := getDeletingXtraDBClusters(perconaXtraDBClusters, pods)
 	res := append(perconaXtraDBClusters, deletingClusters...)
 
 	return res, nil
 }
 
-// CreateSecret creates secret resource to use as credential source for clusters.
-func (c *K8sClient) CreateSecret(ctx context.Context, secretName string, data map[string][]byte) error {
+// CreateSecret creates secret resource in a given namespace to use as credential source for clusters.
+func (c *K8sClient) CreateSecret(ctx context.Context, namespace, secretName string, data map[string][]byte) error {
 	secret := common.Secret{
 		TypeMeta: common.TypeMeta{
 			APIVersion: k8sAPIVersion,
@@ -398,7 +486,7 @@ func (c *K8sClient) CreateSecret(ctx context.Context, secretName string, data ma
 		Type: common.SecretTypeOpaque,
 		Data: data,
 	}
-	return c.kubeCtl.Apply(ctx, secret)
+	return c.kube.Apply(ctx, namespace, secret)
 }
 
 // CreateXtraDBCluster creates Percona XtraDB cluster with provided parameters.
@@ -407,12 +495,34 @@ func (c *K8sClient) CreateXtraDBCluster(ctx context.Context, params *XtraDBParam
 		return errors.New("xtradb cluster must have one and only one proxy type defined")
 	}
 
+	if params.BackupStorage != nil {
+		if err := params.BackupStorage.validate(); err != nil {
+			return err
+		}
+	}
+	if params.TLS != nil {
+		if err := params.TLS.validate(); err != nil {
+			return err
+		}
+	}
+	if err := params.validateConfiguration(); err != nil {
+		return err
+	}
+
 	var cluster pxc.PerconaXtraDBCluster
-	err := c.kubeCtl.Get(ctx, string(perconaXtraDBClusterKind), params.Name, &cluster)
+	err := c.kube.Get(ctx, params.Namespace, string(perconaXtraDBClusterKind), params.Name, &cluster)
 	if err == nil {
 		return fmt.Errorf(clusterWithSameNameExistsErrTemplate, params.Name)
 	}
 
+	storageClasses := []string{params.PXC.StorageClass}
+	if params.ProxySQL != nil {
+		storageClasses = append(storageClasses, params.ProxySQL.StorageClass)
+	}
+	if err = c.checkStorageClasses(ctx, storageClasses...); err != nil {
+		return err
+	}
+
 	secretName := fmt.Sprintf(pxcSecretNameTmpl, params.Name)
 	secrets, err := generateXtraDBPasswords()
 	if err != nil {
@@ -435,16 +545,19 @@ func (c *K8sClient) CreateXtraDBCluster(ctx context.Context, params *XtraDBParam
 			Finalizers: []string{"delete-proxysql-pvc", "delete-pxc-pvc"},
 		},
 		Spec: pxc.PerconaXtraDBClusterSpec{
-			CRVersion:         pxcCRVersion,
-			AllowUnsafeConfig: true,
-			SecretsName:       secretName,
+			CRVersion:             pxcCRVersion,
+			AllowUnsafeConfig:     true,
+			SecretsName:           secretName,
+			SSLSecretName:         fmt.Sprintf(sslSecretNameTmpl, params.Name),
+			SSLInternalSecretName: fmt.Sprintf(sslInternalSecretNameTmpl, params.Name),
 
 			PXC: &pxc.PodSpec{
 				Size:            params.Size,
 				Resources:       c.setComputeResources(params.PXC.ComputeResources),
 				Image:           pxcImage,
 				ImagePullPolicy: pullPolicy,
-				VolumeSpec:      c.volumeSpec(params.PXC.DiskSize),
+				VolumeSpec:      c.volumeSpec(params.PXC.DiskSize, params.PXC.StorageClass),
+				Configuration:   pointer.GetString(params.PXC.Configuration),
 				Affinity: &pxc.PodAffinity{
 					TopologyKey: pointer.ToString(pxc.AffinityTopologyKeyOff),
 				},
@@ -458,17 +571,12 @@ func (c *K8sClient) CreateXtraDBCluster(ctx context.Context, params *XtraDBParam
 			},
 
 			Backup: &pxc.PXCScheduledBackup{
-				Image: pxcBackupImage,
-				Schedule: []pxc.PXCScheduledBackupSchedule{{
-					Name:        "test",
-					Schedule:    "*/30 * * * *",
-					Keep:        3,
-					StorageName: storageName,
-				}},
+				Image:    params.backupImage(),
+				Schedule: pxcBackupSchedules(params.BackupSchedules, storageName),
 				Storages: map[string]*pxc.BackupStorageSpec{
 					storageName: {
 						Type:   pxc.BackupStorageFilesystem,
-						Volume: c.volumeSpec(params.PXC.DiskSize),
+						Volume: c.volumeSpec(params.PXC.DiskSize, params.PXC.StorageClass),
 					},
 				},
 				ServiceAccountName: "percona-xtradb-cluster-operator",
@@ -501,7 +609,8 @@ func (c *K8sClient) CreateXtraDBCluster(ctx context.Context, params *XtraDBParam
 			podSpec.Image = params.ProxySQL.Image
 		}
 		podSpec.Resources = c.setComputeResources(params.ProxySQL.ComputeResources)
-		podSpec.VolumeSpec = c.volumeSpec(params.ProxySQL.DiskSize)
+		podSpec.VolumeSpec = c.volumeSpec(params.ProxySQL.DiskSize, params.ProxySQL.StorageClass)
+		podSpec.Configuration = pointer.GetString(params.ProxySQL.Configuration)
 	} else {
 		res.Spec.HAProxy = new(pxc.PodSpec)
 		podSpec = res.Spec.HAProxy
@@ -510,6 +619,7 @@ func (c *K8sClient) CreateXtraDBCluster(ctx context.Context, params *XtraDBParam
 			podSpec.Image = params.HAProxy.Image
 		}
 		podSpec.Resources = c.setComputeResources(params.HAProxy.ComputeResources)
+		podSpec.Configuration = pointer.GetString(params.HAProxy.Configuration)
 	}
 
 	// This enables ingress for the cluster and exposes the cluster to the world.
@@ -526,22 +636,45 @@ func (c *K8sClient) CreateXtraDBCluster(ctx context.Context, params *XtraDBParam
 		TopologyKey: pointer.ToString(pxc.AffinityTopologyKeyOff),
 	}
 
-	err = c.CreateSecret(ctx, secretName, secrets)
+	if params.BackupStorage != nil {
+		s3SecretName := fmt.Sprintf(pxcBackupS3SecretNameTmpl, params.Name)
+		err = c.createBackupS3Secret(ctx, params.Namespace, s3SecretName, params.BackupStorage)
+		if err != nil {
+			return err
+		}
+		res.Spec.Backup.Storages[storageName] = params.BackupStorage.pxcSpec(s3SecretName)
+	}
+
+	if params.TLS != nil {
+		res.Spec.TLS = params.TLS.pxcSpec()
+		if params.TLS.userSupplied() {
+			if err = c.createTLSSecrets(ctx, params.Namespace, params.Name, params.TLS); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = c.CreateSecret(ctx, params.Namespace, secretName, secrets)
 	if err != nil {
 		return errors.Wrap(err, "cannot create secret for PXC")
 	}
 
-	return c.kubeCtl.Apply(ctx, res)
+	return c.kube.Apply(ctx, params.Namespace, res)
 }
 
 // UpdateXtraDBCluster changes size of provided Percona XtraDB cluster.
+// The operator restarts pods one by one when configuration of a component is changed.
+// PXC and ProxySQL volumes are expanded if disk size is increased, decreasing is not allowed.
 func (c *K8sClient) UpdateXtraDBCluster(ctx context.Context, params *XtraDBParams) error {
 	if (params.ProxySQL != nil) && (params.HAProxy != nil) {
 		return errors.New("can't update both proxies, only one should be in use")
 	}
+	if err := params.validateConfiguration(); err != nil {
+		return err
+	}
 
 	var cluster pxc.PerconaXtraDBCluster
-	err := c.kubeCtl.Get(ctx, string(perconaXtraDBClusterKind), params.Name, &cluster)
+	err := c.kube.Get(ctx, params.Namespace, string(perconaXtraDBClusterKind), params.Name, &cluster)
 	if err != nil {
 		return err
 	}
@@ -558,6 +691,11 @@ func (c *K8sClient) UpdateXtraDBCluster(ctx context.Context, params *XtraDBParam
 		cluster.Spec.Pause = true
 	}
 
+	resizes, err := c.prepareXtraDBVolumesResize(ctx, &cluster, params)
+	if err != nil {
+		return err
+	}
+
 	if params.Size > 0 {
 		cluster.Spec.PXC.Size = params.Size
 		if cluster.Spec.ProxySQL != nil {
@@ -569,21 +707,54 @@ func (c *K8sClient) UpdateXtraDBCluster(ctx context.Context, params *XtraDBParam
 
 	if params.PXC != nil {
 		cluster.Spec.PXC.Resources = c.updateComputeResources(params.PXC.ComputeResources, cluster.Spec.PXC.Resources)
+		if params.PXC.Configuration != nil {
+			cluster.Spec.PXC.Configuration = *params.PXC.Configuration
+		}
 	}
 
 	if params.ProxySQL != nil {
 		cluster.Spec.ProxySQL.Resources = c.updateComputeResources(params.ProxySQL.ComputeResources, cluster.Spec.ProxySQL.Resources)
+		if params.ProxySQL.Configuration != nil {
+			cluster.Spec.ProxySQL.Configuration = *params.ProxySQL.Configuration
+		}
 	}
 
 	if params.HAProxy != nil {
 		cluster.Spec.HAProxy.Resources = c.updateComputeResources(params.HAProxy.ComputeResources, cluster.Spec.HAProxy.Resources)
+		if params.HAProxy.Configuration != nil {
+			cluster.Spec.HAProxy.Configuration = *params.HAProxy.Configuration
+		}
 	}
 
-	return c.kubeCtl.Apply(ctx, &cluster)
+	if params.BackupStorage != nil {
+		if err = params.BackupStorage.validate(); err != nil {
+			return err
+		}
+		s3SecretName := fmt.Sprintf(pxcBackupS3SecretNameTmpl, params.Name)
+		err = c.createBackupS3Secret(ctx, params.Namespace, s3SecretName, params.BackupStorage)
+		if err != nil {
+			return err
+		}
+		if cluster.Spec.Backup == nil {
+			cluster.Spec.Backup = &pxc.PXCScheduledBackup{
+				Image:              params.backupImage(),
+				ServiceAccountName: "percona-xtradb-cluster-operator",
+			}
+		}
+		if cluster.Spec.Backup.Storages == nil {
+			cluster.Spec.Backup.Storages = make(map[string]*pxc.BackupStorageSpec)
+		}
+		cluster.Spec.Backup.Storages[fmt.Sprintf(pxcBackupStorageName, params.Name)] = params.BackupStorage.pxcSpec(s3SecretName)
+	}
+
+	if err = c.kube.Apply(ctx, params.Namespace, &cluster); err != nil {
+		return err
+	}
+	return c.resizeVolumes(ctx, params.Namespace, resizes)
 }
 
-// DeleteXtraDBCluster deletes Percona XtraDB cluster with provided name.
-func (c *K8sClient) DeleteXtraDBCluster(ctx context.Context, name string) error {
+// DeleteXtraDBCluster deletes Percona XtraDB cluster with provided name from a given namespace.
+func (c *K8sClient) DeleteXtraDBCluster(ctx context.Context, namespace, name string) error {
 	res := &pxc.PerconaXtraDBCluster{
 		TypeMeta: common.TypeMeta{
 			APIVersion: pxcAPIVersion,
@@ -593,25 +764,37 @@ func (c *K8sClient) DeleteXtraDBCluster(ctx context.Context, name string) error
 			Name: name,
 		},
 	}
-	err := c.kubeCtl.Delete(ctx, res)
+	err := c.kube.Delete(ctx, namespace, res)
 	if err != nil {
 		return errors.Wrap(err, "cannot delete PXC")
 	}
 
-	err = c.deleteSecret(ctx, fmt.Sprintf(pxcSecretNameTmpl, name))
+	err = c.deleteSecret(ctx, namespace, fmt.Sprintf(pxcSecretNameTmpl, name))
 	if err != nil {
 		c.l.Errorf("cannot delete secret for %s: %v", name, err)
 	}
 
-	err = c.deleteSecret(ctx, fmt.Sprintf(pxcInternalSecretTmpl, name))
+	err = c.deleteSecret(ctx, namespace, fmt.Sprintf(pxcInternalSecretTmpl, name))
 	if err != nil {
 		c.l.Errorf("cannot delete internal secret for %s: %v", name, err)
 	}
 
+	err = c.deleteBackupS3Secret(ctx, namespace, fmt.Sprintf(pxcBackupS3SecretNameTmpl, name))
+	if err != nil {
+		c.l.Errorf("cannot delete S3 backup storage secret for %s: %v", name, err)
+	}
+
+	for _, secretTmpl := range []string{sslSecretNameTmpl, sslInternalSecretNameTmpl} {
+		err = c.deleteSecret(ctx, namespace, fmt.Sprintf(secretTmpl, name))
+		if err != nil && !errors.Is(err, kubectl.ErrNotFound) {
+			c.l.Errorf("cannot delete TLS secret for %s: %v", name, err)
+		}
+	}
+
 	return nil
 }
 
-func (c *K8sClient) deleteSecret(ctx context.Context, secretName string) error {
+func (c *K8sClient) deleteSecret(ctx context.Context, namespace, secretName string) error {
 	secret := &common.Secret{
 		TypeMeta: common.TypeMeta{
 			APIVersion: k8sAPIVersion,
@@ -622,13 +805,13 @@ func (c *K8sClient) deleteSecret(ctx context.Context, secretName string) error {
 		},
 	}
 
-	return c.kubeCtl.Delete(ctx, secret)
+	return c.kube.Delete(ctx, namespace, secret)
 }
 
 // GetXtraDBClusterCredentials returns an XtraDB cluster credentials.
-func (c *K8sClient) GetXtraDBClusterCredentials(ctx context.Context, name string) (*XtraDBCredentials, error) {
+func (c *K8sClient) GetXtraDBClusterCredentials(ctx context.Context, namespace, name string) (*XtraDBCredentials, error) {
 	var cluster pxc.PerconaXtraDBCluster
-	err := c.kubeCtl.Get(ctx, string(perconaXtraDBClusterKind), name, &cluster)
+	err := c.kube.Get(ctx, namespace, string(perconaXtraDBClusterKind), name, &cluster)
 	if err != nil {
 		if errors.Is(err, kubectl.ErrNotFound) {
 			return nil, errors.Wrap(ErrNotFound, fmt.Sprintf(canNotGetCredentialsErrTemplate, "XtraDb"))
@@ -645,73 +828,56 @@ func (c *K8sClient) GetXtraDBClusterCredentials(ctx context.Context, name string
 	var secret common.Secret
 	// Retrieve secrets only for initializing or ready cluster.
 	if cluster.Status.Status == pxc.AppStateReady || cluster.Status.Status == pxc.AppStateInit {
-		err = c.kubeCtl.Get(ctx, k8sMetaKindSecret, fmt.Sprintf(pxcSecretNameTmpl, name), &secret)
+		err = c.kube.Get(ctx, namespace, k8sMetaKindSecret, fmt.Sprintf(pxcSecretNameTmpl, name), &secret)
 		if err != nil {
 			return nil, errors.Wrap(err, "cannot get XtraDb cluster secrets")
 		}
 		password = string(secret.Data["root"])
 	}
 
+	sslSecretName := cluster.Spec.SSLSecretName
+	if sslSecretName == "" {
+		sslSecretName = fmt.Sprintf(sslSecretNameTmpl, name)
+	}
+	caBundle, err := c.getCABundle(ctx, namespace, sslSecretName)
+	if err != nil {
+		return nil, err
+	}
+
 	credentials := &XtraDBCredentials{
 		Host:     cluster.Status.Host,
 		Port:     3306,
 		Username: "root",
 		Password: password,
+		CABundle: caBundle,
 	}
 
 	return credentials, nil
 }
 
-func (c *K8sClient) getStorageClass(ctx context.Context) (*StorageClass, error) {
-	var storageClass *StorageClass
-
-	err := c.kubeCtl.Get(ctx, "storageclass", "", &storageClass)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot get storageClass")
-	}
-
-	return storageClass, nil
+func (c *K8sClient) restartDBClusterCmd(namespace, name, kind string) []string {
+	return withNamespace([]string{"rollout", "restart", "StatefulSets", fmt.Sprintf("%s-%s", name, kind)}, namespace)
 }
 
-// GetKubernetesClusterType returns k8s cluster type based on storage class.
-func (c *K8sClient) GetKubernetesClusterType(ctx context.Context) kubernetesClusterType {
-	sc, err := c.getStorageClass(ctx)
-	if err != nil {
-		c.l.Error(errors.Wrap(err, "failed to get k8s cluster type"))
-		return clusterTypeUnknown
-	}
-
-	if len(sc.Items) == 0 {
-		return clusterTypeUnknown
+// withNamespace appends namespace flag to kubectl arguments. Empty namespace means the default one.
+func withNamespace(args []string, namespace string) []string {
+	if namespace == "" {
+		return args
 	}
-
-	for _, class := range sc.Items {
-		if strings.Contains(class.Provisioner, "aws") {
-			return AmazonEKSClusterType
-		}
-		if strings.Contains(class.Provisioner, "minikube") {
-			return MinikubeClusterType
-		}
-	}
-
-	return clusterTypeUnknown
-}
-
-func (c *K8sClient) restartDBClusterCmd(name, kind string) []string {
-	return []string{"rollout", "restart", "StatefulSets", fmt.Sprintf("%s-%s", name, kind)}
+	return append(args, "--namespace="+namespace)
 }
 
 // RestartXtraDBCluster restarts Percona XtraDB cluster with provided name.
 // FIXME: https://jira.percona.com/browse/PMM-6980
-func (c *K8sClient) RestartXtraDBCluster(ctx context.Context, name string) error {
-	_, err := c.kubeCtl.Run(ctx, c.restartDBClusterCmd(name, "pxc"), nil)
+func (c *K8sClient) RestartXtraDBCluster(ctx context.Context, namespace, name string) error {
+	_, err := c.kube.Run(ctx, c.restartDBClusterCmd(namespace, name, "pxc"), nil)
 	if err != nil {
 		return err
 	}
 
 	for _, proxy := range []string{"proxysql", "haproxy"} {
-		if _, err := c.kubeCtl.Run(ctx, []string{"get", "statefulset", name + "-" + proxy}, nil); err == nil {
-			_, err = c.kubeCtl.Run(ctx, c.restartDBClusterCmd(name, proxy), nil)
+		if _, err := c.kube.Run(ctx, withNamespace([]string{"get", "statefulset", name + "-" + proxy}, namespace), nil); err == nil {
+			_, err = c.kube.Run(ctx, c.restartDBClusterCmd(namespace, name, proxy), nil)
 			return err
 		}
 	}
@@ -720,52 +886,61 @@ func (c *K8sClient) RestartXtraDBCluster(ctx context.Context, name string) error
 }
 
 // getPerconaXtraDBClusters returns Percona XtraDB clusters.
-func (c *K8sClient) getPerconaXtraDBClusters(ctx context.Context) ([]XtraDBCluster, error) {
+// Pods are given by getClusterPods.
+func (c *K8sClient) getPerconaXtraDBClusters(ctx context.Context, namespace string, pods map[string][]common.Pod) ([]XtraDBCluster, error) {
 	var list pxc.PerconaXtraDBClusterList
-	err := c.kubeCtl.Get(ctx, string(perconaXtraDBClusterKind), "", &list)
+	err := c.kube.Get(ctx, namespace, string(perconaXtraDBClusterKind), "", &list)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get Percona XtraDB clusters")
 	}
 
 	res := make([]XtraDBCluster, len(list.Items))
-	for i, cluster := range list.Items {
-		val := XtraDBCluster{
-			Name:    cluster.Name,
-			Size:    cluster.Spec.PXC.Size,
-			State:   getPXCState(cluster.Status.Status),
-			Message: strings.Join(cluster.Status.Messages, ";"),
-			PXC: &PXC{
-				DiskSize:         c.getDiskSize(cluster.Spec.PXC.VolumeSpec),
-				ComputeResources: c.getComputeResources(cluster.Spec.PXC.Resources),
-			},
-			Pause: cluster.Spec.Pause,
-			DetailedState: []appStatus{
-				{size: cluster.Status.PMM.Size, ready: cluster.Status.PMM.Ready},
-				{size: cluster.Status.HAProxy.Size, ready: cluster.Status.HAProxy.Ready},
-				{size: cluster.Status.ProxySQL.Size, ready: cluster.Status.ProxySQL.Ready},
-				{size: cluster.Status.PXC.Size, ready: cluster.Status.PXC.Ready},
-			},
-		}
-		if cluster.Spec.ProxySQL != nil {
-			val.ProxySQL = &ProxySQL{
-				DiskSize:         c.getDiskSize(cluster.Spec.ProxySQL.VolumeSpec),
-				ComputeResources: c.getComputeResources(cluster.Spec.ProxySQL.Resources),
-			}
-			val.Exposed = cluster.Spec.ProxySQL.ServiceType != "" &&
-				cluster.Spec.ProxySQL.ServiceType != common.ServiceTypeClusterIP
-			res[i] = val
-			continue
+	for i := range list.Items {
+		cluster := &list.Items[i]
+		res[i] = c.getXtraDBCluster(cluster, pods[clusterPodsKey(cluster.Namespace, cluster.Name)])
+	}
+	return res, nil
+}
+
+// getXtraDBCluster converts Percona XtraDB cluster custom resource with its pods.
+func (c *K8sClient) getXtraDBCluster(cluster *pxc.PerconaXtraDBCluster, pods []common.Pod) XtraDBCluster {
+	res := XtraDBCluster{
+		Name:    cluster.Name,
+		Size:    cluster.Spec.PXC.Size,
+		State:   getPXCState(cluster.Status.Status),
+		Message: strings.Join(cluster.Status.Messages, ";"),
+		PXC: &PXC{
+			DiskSize:         c.getDiskSize(cluster.Spec.PXC.VolumeSpec),
+			StorageClass:     getStorageClassName(cluster.Spec.PXC.VolumeSpec),
+			ComputeResources: c.getComputeResources(cluster.Spec.PXC.Resources),
+		},
+		Pause: cluster.Spec.Pause,
+		DetailedState: []appStatus{
+			{size: cluster.Status.PMM.Size, ready: cluster.Status.PMM.Ready},
+			{size: cluster.Status.HAProxy.Size, ready: cluster.Status.HAProxy.Ready},
+			{size: cluster.Status.ProxySQL.Size, ready: cluster.Status.ProxySQL.Ready},
+			{size: cluster.Status.PXC.Size, ready: cluster.Status.PXC.Ready},
+		},
+		Health: getPXCHealth(cluster, pods),
+	}
+	if cluster.Spec.ProxySQL != nil {
+		res.ProxySQL = &ProxySQL{
+			DiskSize:         c.getDiskSize(cluster.Spec.ProxySQL.VolumeSpec),
+			StorageClass:     getStorageClassName(cluster.Spec.ProxySQL.VolumeSpec),
+			ComputeResources: c.getComputeResources(cluster.Spec.ProxySQL.Resources),
 		}
-		if cluster.Spec.HAProxy != nil {
-			val.HAProxy = &HAProxy{
-				ComputeResources: c.getComputeResources(cluster.Spec.HAProxy.Resources),
-			}
-			val.Exposed = cluster.Spec.HAProxy.ServiceType != "" &&
-				cluster.Spec.HAProxy.ServiceType != common.ServiceTypeClusterIP
+		res.Exposed = cluster.Spec.ProxySQL.ServiceType != "" &&
+			cluster.Spec.ProxySQL.ServiceType != common.ServiceTypeClusterIP
+		return res
+	}
+	if cluster.Spec.HAProxy != nil {
+		res.HAProxy = &HAProxy{
+			ComputeResources: c.getComputeResources(cluster.Spec.HAProxy.Resources),
 		}
-		res[i] = val
+		res.Exposed = cluster.Spec.HAProxy.ServiceType != "" &&
+			cluster.Spec.HAProxy.ServiceType != common.ServiceTypeClusterIP
 	}
-	return res, nil
+	return res
 }
 
 func getPXCState(state pxc.AppState) ClusterState {
@@ -779,47 +954,30 @@ func getPXCState(state pxc.AppState) ClusterState {
 	return clusterState
 }
 
-// getDeletingClusters returns clusters which are not fully deleted yet.
-func (c *K8sClient) getDeletingClusters(ctx context.Context, managedBy string, runningClusters map[string]struct{}) ([]Cluster, error) {
-	var list common.PodList
-
-	err := c.kubeCtl.Get(ctx, "pods", "", &list)
-	if err != nil {
-		return nil, errors.Wrap(err, "couldn't get kubernetes pods")
-	}
-
+// getDeletingClusters returns clusters which are not fully deleted yet sorted by name.
+// Pods are given by getClusterPods.
+func getDeletingClusters(pods map[string][]common.Pod, runningClusters map[string]struct{}) []Cluster {
 	res := []Cluster{}
-	for _, pod := range list.Items {
-		clusterName := pod.Labels["app.kubernetes.io/instance"]
+	for _, clusterPods := range pods {
+		clusterName := clusterPods[0].Labels["app.kubernetes.io/instance"]
 		if _, ok := runningClusters[clusterName]; ok {
 			continue
 		}
-
-		if pod.Labels["app.kubernetes.io/managed-by"] != managedBy {
-			continue
-		}
-
-		cluster := Cluster{
-			Name: clusterName,
-		}
-		res = append(res, cluster)
-
+		res = append(res, Cluster{Name: clusterName})
 		runningClusters[clusterName] = struct{}{}
 	}
-	return res, nil
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
 }
 
 // getDeletingXtraDBClusters returns Percona XtraDB clusters which are not fully deleted yet.
-func (c *K8sClient) getDeletingXtraDBClusters(ctx context.Context, clusters []XtraDBCluster) ([]XtraDBCluster, error) {
+func getDeletingXtraDBClusters(clusters []XtraDBCluster, pods map[string][]common.Pod) []XtraDBCluster {
 	runningClusters := make(map[string]struct{}, len(clusters))
 	for _, cluster := range clusters {
 		runningClusters[cluster.Name] = struct{}{}
 	}
 
-	deletingClusters, err := c.getDeletingClusters(ctx, "percona-xtradb-cluster-operator", runningClusters)
-	if err != nil {
-		return nil, err
-	}
+	deletingClusters := getDeletingClusters(pods, runningClusters)
 
 	xtradbClusters := make([]XtraDBCluster, len(deletingClusters))
 	for i, cluster := range deletingClusters {
@@ -833,20 +991,28 @@ func (c *K8sClient) getDeletingXtraDBClusters(ctx context.Context, clusters []Xt
 			DetailedState: []appStatus{},
 		}
 	}
-	return xtradbClusters, nil
+	return xtradbClusters
 }
 
-// ListPSMDBClusters returns list of psmdb clusters and their statuses.
-func (c *K8sClient) ListPSMDBClusters(ctx context.Context) ([]PSMDBCluster, error) {
-	clusters, err := c.getPSMDBClusters(ctx)
+// ListPSMDBClusters returns list of psmdb clusters and their statuses in a given namespace.
+// Empty namespace means the default one.
+func (c *K8sClient) ListPSMDBClusters(ctx context.Context, namespace string) ([]PSMDBCluster, error) {
+	pods, err := c.getClusterPods(ctx, namespace, psmdbOperatorDeploymentName)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get PSMDB cluster pods")
+	}
+	clusters, err := c.getPSMDBClusters(ctx, namespace, pods)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot get PSMDB clusters")
 	}
 
-	deletingClusters, err := c.getDeletingPSMDBClusters(ctx, clusters)
+	restores, err := c.ListPSMDBClusterRestores(ctx, namespace, "")
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot get deleting PSMDB clusters")
+		c.l.Warnf("Cannot get PSMDB cluster restores: %s.", err)
 	}
+	setPSMDBRestoreStates(clusters, restores)
+
+	deletingClusters := getDeletingPSMDBClusters(clusters, pods)
 	res := append(clusters, deletingClusters...)
 
 	return res, nil
@@ -855,11 +1021,28 @@ func (c *K8sClient) ListPSMDBClusters(ctx context.Context) ([]PSMDBCluster, erro
 // CreatePSMDBCluster creates percona server for mongodb cluster with provided parameters.
 func (c *K8sClient) CreatePSMDBCluster(ctx context.Context, params *PSMDBParams) error {
 	var cluster psmdb.PerconaServerMongoDB
-	err := c.kubeCtl.Get(ctx, string(perconaServerMongoDBKind), params.Name, &cluster)
+	err := c.kube.Get(ctx, params.Namespace, string(perconaServerMongoDBKind), params.Name, &cluster)
 	if err == nil {
 		return fmt.Errorf(clusterWithSameNameExistsErrTemplate, params.Name)
 	}
 
+	if params.BackupStorage != nil {
+		if err = params.BackupStorage.validate(); err != nil {
+			return err
+		}
+	}
+	if params.TLS != nil {
+		if err = params.TLS.validate(); err != nil {
+			return err
+		}
+		if params.TLS.Issuer != nil {
+			return errors.New("cert-manager issuer is not supported for PSMDB clusters")
+		}
+	}
+	if err = params.validateConfiguration(); err != nil {
+		return err
+	}
+
 	secretName := fmt.Sprintf(psmdbSecretNameTmpl, params.Name)
 	secrets, err := generatePSMDBPasswords()
 	if err != nil {
@@ -892,6 +1075,32 @@ func (c *K8sClient) CreatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 	if params.Image != "" {
 		psmdbImage = params.Image
 	}
+	shards, err := psmdbShards(params)
+	if err != nil {
+		return err
+	}
+	storageClasses := make([]string, len(shards))
+	for i, shard := range shards {
+		storageClasses[i] = shard.StorageClass
+	}
+	if err = c.checkStorageClasses(ctx, storageClasses...); err != nil {
+		return err
+	}
+	replsets := make([]*psmdb.ReplsetSpec, len(shards))
+	for i, shard := range shards {
+		if replsets[i], err = c.psmdbReplsetSpec(shard, affinity, psmdbCRVersion); err != nil {
+			return err
+		}
+	}
+	mongosResources := shards[0].ComputeResources
+	if params.Replicaset != nil {
+		mongosResources = params.Replicaset.ComputeResources
+	}
+	mongosSize := params.Size
+	if mongosSize <= 0 {
+		mongosSize = shards[0].Size
+	}
+
 	res := &psmdb.PerconaServerMongoDB{
 		TypeMeta: common.TypeMeta{
 			APIVersion: psmdbAPIVersion,
@@ -905,7 +1114,9 @@ func (c *K8sClient) CreatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 			CRVersion: psmdbCRVersion,
 			Image:     psmdbImage,
 			Secrets: &psmdb.SecretsSpec{
-				Users: secretName,
+				Users:       secretName,
+				SSL:         fmt.Sprintf(sslSecretNameTmpl, params.Name),
+				SSLInternal: fmt.Sprintf(sslInternalSecretNameTmpl, params.Name),
 			},
 			Mongod: &psmdb.MongodSpec{
 				Net: &psmdb.MongodSpecNet{
@@ -944,7 +1155,7 @@ func (c *K8sClient) CreatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 				Enabled: true,
 				ConfigsvrReplSet: &psmdb.ReplsetSpec{
 					Size:       3,
-					VolumeSpec: c.volumeSpec(params.Replicaset.DiskSize),
+					VolumeSpec: c.volumeSpec(shards[0].DiskSize, shards[0].StorageClass),
 					Arbiter: psmdb.Arbiter{
 						Enabled: false,
 						Size:    1,
@@ -964,7 +1175,8 @@ func (c *K8sClient) CreatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 							Affinity: affinity,
 						},
 					},
-					Size: params.Size,
+					Size:      mongosSize,
+					Resources: c.setComputeResources(mongosResources),
 					MultiAZ: psmdb.MultiAZ{
 						Affinity: affinity,
 					},
@@ -974,27 +1186,7 @@ func (c *K8sClient) CreatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 					Mode: psmdb.OperationProfilingModeSlowOp,
 				},
 			},
-			Replsets: []*psmdb.ReplsetSpec{
-				{
-					Name:      "rs0",
-					Size:      params.Size,
-					Resources: c.setComputeResources(params.Replicaset.ComputeResources),
-					Arbiter: psmdb.Arbiter{
-						Enabled: false,
-						Size:    1,
-						MultiAZ: psmdb.MultiAZ{
-							Affinity: affinity,
-						},
-					},
-					VolumeSpec: c.volumeSpec(params.Replicaset.DiskSize),
-					PodDisruptionBudget: &common.PodDisruptionBudgetSpec{
-						MaxUnavailable: pointer.ToInt(1),
-					},
-					MultiAZ: psmdb.MultiAZ{
-						Affinity: affinity,
-					},
-				},
-			},
+			Replsets: replsets,
 
 			PMM: psmdb.PmmSpec{
 				Enabled: false,
@@ -1002,14 +1194,16 @@ func (c *K8sClient) CreatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 
 			Backup: psmdb.BackupSpec{
 				Enabled:            true,
-				Image:              psmdbBackupImage,
+				Image:              params.backupImage(),
 				ServiceAccountName: "percona-server-mongodb-operator",
 			},
 		},
 	}
-	if params.Replicaset != nil {
-		res.Spec.Replsets[0].Resources = c.setComputeResources(params.Replicaset.ComputeResources)
-		res.Spec.Sharding.Mongos.Resources = c.setComputeResources(params.Replicaset.ComputeResources)
+	if params.Topology == PSMDBTopologyReplicaset {
+		res.Spec.Sharding = &psmdb.ShardingSpec{
+			Enabled: false,
+		}
+		res.Spec.Replsets[0].Expose = expose
 	}
 	if params.PMM != nil {
 		res.Spec.PMM = psmdb.PmmSpec{
@@ -1027,18 +1221,50 @@ func (c *K8sClient) CreatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 		secrets["PMM_SERVER_PASSWORD"] = []byte(params.PMM.Password)
 	}
 
-	err = c.CreateSecret(ctx, secretName, secrets)
+	s3SecretName := fmt.Sprintf(psmdbBackupS3SecretNameTmpl, params.Name)
+	if params.BackupStorage != nil {
+		res.Spec.Backup.Storages = map[string]psmdb.BackupStorageSpec{
+			fmt.Sprintf(psmdbBackupStorageName, params.Name): params.BackupStorage.psmdbSpec(s3SecretName),
+		}
+	}
+	if params.BackupTasks != nil {
+		res.Spec.Backup.Tasks, err = psmdbBackupTasks(params.Name, params.BackupTasks, res.Spec.Backup.Storages)
+		if err != nil {
+			return err
+		}
+	}
+
+	if params.BackupStorage != nil {
+		err = c.createBackupS3Secret(ctx, params.Namespace, s3SecretName, params.BackupStorage)
+		if err != nil {
+			return err
+		}
+	}
+
+	if params.TLS != nil && params.TLS.userSupplied() {
+		if err = c.createTLSSecrets(ctx, params.Namespace, params.Name, params.TLS); err != nil {
+			return err
+		}
+	}
+
+	err = c.CreateSecret(ctx, params.Namespace, secretName, secrets)
 	if err != nil {
 		return errors.Wrap(err, "cannot create secret for PXC")
 	}
 
-	return c.kubeCtl.Apply(ctx, res)
+	return c.kube.Apply(ctx, params.Namespace, res)
 }
 
 // UpdatePSMDBCluster changes size of provided percona server for mongodb cluster.
+// The operator restarts pods one by one when mongod configuration of a replica set is changed.
+// Replica set volumes are expanded if disk size is increased, decreasing is not allowed.
 func (c *K8sClient) UpdatePSMDBCluster(ctx context.Context, params *PSMDBParams) error {
+	if err := params.validateConfiguration(); err != nil {
+		return err
+	}
+
 	var cluster psmdb.PerconaServerMongoDB
-	err := c.kubeCtl.Get(ctx, string(perconaServerMongoDBKind), params.Name, &cluster)
+	err := c.kube.Get(ctx, params.Namespace, string(perconaServerMongoDBKind), params.Name, &cluster)
 	if err != nil {
 		return errors.Wrap(err, "UpdatePSMDBCluster get error")
 	}
@@ -1048,8 +1274,12 @@ func (c *K8sClient) UpdatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 		return errors.Wrapf(ErrPSMDBClusterNotReady, "state is %v", cluster.Status.Status) //nolint:wrapcheck
 	}
 
-	if params.Size > 0 {
-		cluster.Spec.Replsets[0].Size = params.Size
+	resizes, err := c.preparePSMDBVolumesResize(ctx, &cluster, params)
+	if err != nil {
+		return err
+	}
+	if err = c.updatePSMDBReplsets(&cluster, params); err != nil {
+		return err
 	}
 
 	if params.Resume {
@@ -1059,15 +1289,38 @@ func (c *K8sClient) UpdatePSMDBCluster(ctx context.Context, params *PSMDBParams)
 		cluster.Spec.Pause = true
 	}
 
-	if params.Replicaset != nil {
-		cluster.Spec.Replsets[0].Resources = c.updateComputeResources(params.Replicaset.ComputeResources, cluster.Spec.Replsets[0].Resources)
+	s3SecretName := fmt.Sprintf(psmdbBackupS3SecretNameTmpl, params.Name)
+	if params.BackupStorage != nil {
+		if err = params.BackupStorage.validate(); err != nil {
+			return err
+		}
+		if cluster.Spec.Backup.Storages == nil {
+			cluster.Spec.Backup.Storages = make(map[string]psmdb.BackupStorageSpec)
+		}
+		cluster.Spec.Backup.Storages[fmt.Sprintf(psmdbBackupStorageName, params.Name)] = params.BackupStorage.psmdbSpec(s3SecretName)
+	}
+	if params.BackupTasks != nil {
+		cluster.Spec.Backup.Tasks, err = psmdbBackupTasks(params.Name, params.BackupTasks, cluster.Spec.Backup.Storages)
+		if err != nil {
+			return err
+		}
 	}
 
-	return c.kubeCtl.Apply(ctx, cluster)
+	if params.BackupStorage != nil {
+		err = c.createBackupS3Secret(ctx, params.Namespace, s3SecretName, params.BackupStorage)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = c.kube.Apply(ctx, params.Namespace, cluster); err != nil {
+		return err
+	}
+	return c.resizeVolumes(ctx, params.Namespace, resizes)
 }
 
-// DeletePSMDBCluster deletes percona server for mongodb cluster with provided name.
-func (c *K8sClient) DeletePSMDBCluster(ctx context.Context, name string) error {
+// DeletePSMDBCluster deletes percona server for mongodb cluster with provided name from a given namespace.
+func (c *K8sClient) DeletePSMDBCluster(ctx context.Context, namespace, name string) error {
 	res := &psmdb.PerconaServerMongoDB{
 		TypeMeta: common.TypeMeta{
 			APIVersion: psmdbAPIVersion,
@@ -1077,20 +1330,25 @@ func (c *K8sClient) DeletePSMDBCluster(ctx context.Context, name string) error {
 			Name: name,
 		},
 	}
-	err := c.kubeCtl.Delete(ctx, res)
+	err := c.kube.Delete(ctx, namespace, res)
 	if err != nil {
 		return errors.Wrap(err, "cannot delete PSMDB")
 	}
 
-	err = c.deleteSecret(ctx, fmt.Sprintf(psmdbSecretNameTmpl, name))
+	err = c.deleteSecret(ctx, namespace, fmt.Sprintf(psmdbSecretNameTmpl, name))
 	if err != nil {
 		c.l.Errorf("cannot delete secret for %s: %v", name, err)
 	}
 
+	err = c.deleteBackupS3Secret(ctx, namespace, fmt.Sprintf(psmdbBackupS3SecretNameTmpl, name))
+	if err != nil {
+		c.l.Errorf("cannot delete S3 backup storage secret for %s: %v", name, err)
+	}
+
 	psmdbInternalSecrets := []string{"internal-%s-users", "%s-ssl", "%s-ssl-internal", "%-mongodb-keyfile", "%s-mongodb-encryption-key"}
 
 	for _, secretTmpl := range psmdbInternalSecrets {
-		err = c.deleteSecret(ctx, fmt.Sprintf(secretTmpl, name))
+		err = c.deleteSecret(ctx, namespace, fmt.Sprintf(secretTmpl, name))
 		if err != nil {
 			c.l.Errorf("cannot delete internal secret for %s: %v", name, err)
 		}
@@ -1101,16 +1359,32 @@ func (c *K8sClient) DeletePSMDBCluster(ctx context.Context, name string) error {
 
 // RestartPSMDBCluster restarts Percona server for mongodb cluster with provided name.
 // FIXME: https://jira.percona.com/browse/PMM-6980
-func (c *K8sClient) RestartPSMDBCluster(ctx context.Context, name string) error {
-	_, err := c.kubeCtl.Run(ctx, c.restartDBClusterCmd(name, "rs0"), nil)
+func (c *K8sClient) RestartPSMDBCluster(ctx context.Context, namespace, name string) error {
+	var cluster psmdb.PerconaServerMongoDB
+	err := c.kube.Get(ctx, namespace, string(perconaServerMongoDBKind), name, &cluster)
+	if err != nil {
+		return errors.Wrap(err, "cannot get PSMDB cluster")
+	}
 
-	return err
+	statefulSets := make([]string, 0, len(cluster.Spec.Replsets)+1)
+	for _, rs := range cluster.Spec.Replsets {
+		statefulSets = append(statefulSets, rs.Name)
+	}
+	if getPSMDBTopology(&cluster) == PSMDBTopologySharded {
+		statefulSets = append(statefulSets, psmdbConfigReplsetName)
+	}
+	for _, statefulSet := range statefulSets {
+		if _, err = c.kube.Run(ctx, c.restartDBClusterCmd(namespace, name, statefulSet), nil); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetPSMDBClusterCredentials returns a PSMDB cluster.
-func (c *K8sClient) GetPSMDBClusterCredentials(ctx context.Context, name string) (*PSMDBCredentials, error) {
+func (c *K8sClient) GetPSMDBClusterCredentials(ctx context.Context, namespace, name string) (*PSMDBCredentials, error) {
 	var cluster psmdb.PerconaServerMongoDB
-	err := c.kubeCtl.Get(ctx, string(perconaServerMongoDBKind), name, &cluster)
+	err := c.kube.Get(ctx, namespace, string(perconaServerMongoDBKind), name, &cluster)
 	if err != nil {
 		if errors.Is(err, kubectl.ErrNotFound) {
 			return nil, errors.Wrap(ErrNotFound, fmt.Sprintf(canNotGetCredentialsErrTemplate, "PSMDB"))
@@ -1126,7 +1400,7 @@ func (c *K8sClient) GetPSMDBClusterCredentials(ctx context.Context, name string)
 	var secret common.Secret
 	// Retrieve secrets only for initializing or ready cluster.
 	if cluster.Status.Status == psmdb.AppStateReady || cluster.Status.Status == psmdb.AppStateInit {
-		err = c.kubeCtl.Get(ctx, k8sMetaKindSecret, fmt.Sprintf(psmdbSecretNameTmpl, name), &secret)
+		err = c.kube.Get(ctx, namespace, k8sMetaKindSecret, fmt.Sprintf(psmdbSecretNameTmpl, name), &secret)
 		if err != nil {
 			return nil, errors.Wrap(err, "cannot get PSMDB cluster secrets")
 		}
@@ -1134,59 +1408,116 @@ func (c *K8sClient) GetPSMDBClusterCredentials(ctx context.Context, name string)
 		password = string(secret.Data["MONGODB_USER_ADMIN_PASSWORD"])
 	}
 
+	sslSecretName := fmt.Sprintf(sslSecretNameTmpl, name)
+	if cluster.Spec.Secrets != nil && cluster.Spec.Secrets.SSL != "" {
+		sslSecretName = cluster.Spec.Secrets.SSL
+	}
+	caBundle, err := c.getCABundle(ctx, namespace, sslSecretName)
+	if err != nil {
+		return nil, err
+	}
+
 	credentials := &PSMDBCredentials{
-		Username:   username,
-		Password:   password,
-		Host:       cluster.Status.Host,
-		Port:       27017,
-		Replicaset: "rs0",
+		Username: username,
+		Password: password,
+		Host:     cluster.Status.Host,
+		Port:     27017,
+		CABundle: caBundle,
+	}
+	// Sharded cluster is accessed via mongos, replica set name is used for unsharded cluster only.
+	if getPSMDBTopology(&cluster) == PSMDBTopologyReplicaset && len(cluster.Spec.Replsets) > 0 {
+		credentials.Replicaset = cluster.Spec.Replsets[0].Name
 	}
 
 	return credentials, nil
 }
 
 // getPSMDBClusters returns Percona Server for MongoDB clusters.
-func (c *K8sClient) getPSMDBClusters(ctx context.Context) ([]PSMDBCluster, error) {
+// Pods are given by getClusterPods.
+func (c *K8sClient) getPSMDBClusters(ctx context.Context, namespace string, pods map[string][]common.Pod) ([]PSMDBCluster, error) {
 	var list psmdb.PerconaServerMongoDBList
-	err := c.kubeCtl.Get(ctx, string(perconaServerMongoDBKind), "", &list)
+	err := c.kube.Get(ctx, namespace, string(perconaServerMongoDBKind), "", &list)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get percona server MongoDB clusters")
 	}
 
 	res := make([]PSMDBCluster, len(list.Items))
-	for i, cluster := range list.Items {
-		message := cluster.Status.Message
-		conditions := cluster.Status.Conditions
-		if message == "" && len(conditions) > 0 {
-			message = conditions[len(conditions)-1].Message
-		}
+	for i := range list.Items {
+		cluster := &list.Items[i]
+		res[i] = c.getPSMDBCluster(cluster, pods[clusterPodsKey(cluster.Namespace, cluster.Name)])
+	}
+	return res, nil
+}
 
-		status := make([]appStatus, 0, len(cluster.Status.Replsets)+1)
-		for _, rs := range cluster.Status.Replsets {
-			status = append(status, appStatus{rs.Size, rs.Ready})
+// getPSMDBCluster converts Percona Server for MongoDB cluster custom resource with its pods.
+func (c *K8sClient) getPSMDBCluster(cluster *psmdb.PerconaServerMongoDB, pods []common.Pod) PSMDBCluster {
+	message := cluster.Status.Message
+	conditions := cluster.Status.Conditions
+	if message == "" && len(conditions) > 0 {
+		message = conditions[len(conditions)-1].Message
+	}
+
+	status := make([]appStatus, 0, len(cluster.Status.Replsets)+1)
+	for _, rs := range cluster.Status.Replsets {
+		status = append(status, appStatus{rs.Size, rs.Ready})
+	}
+	status = append(status, appStatus{
+		size:  cluster.Status.Mongos.Size,
+		ready: cluster.Status.Mongos.Ready,
+	})
+
+	shards := make([]*Replicaset, len(cluster.Spec.Replsets))
+	var arbiters int32
+	for j, rs := range cluster.Spec.Replsets {
+		shards[j] = &Replicaset{
+			Name:             rs.Name,
+			Size:             rs.Size,
+			DiskSize:         c.getDiskSize(rs.VolumeSpec),
+			StorageClass:     getStorageClassName(rs.VolumeSpec),
+			ComputeResources: c.getComputeResources(rs.Resources),
+			Arbiters:         pointer.ToInt32(0),
+			NonVotingMembers: pointer.ToInt32(0),
+		}
+		if rs.Arbiter.Enabled {
+			*shards[j].Arbiters = rs.Arbiter.Size
+			arbiters += rs.Arbiter.Size
+		}
+		if rs.NonVoting != nil && rs.NonVoting.Enabled {
+			*shards[j].NonVotingMembers = rs.NonVoting.Size
+		}
+	}
+	if arbiters > 0 {
+		// The operator does not report arbiters in replica set status.
+		var readyArbiters int32
+		for _, pod := range pods {
+			if pod.Labels["app.kubernetes.io/component"] == "arbiter" && common.IsPodReady(pod) {
+				readyArbiters++
+			}
 		}
 		status = append(status, appStatus{
-			size:  cluster.Status.Mongos.Size,
-			ready: cluster.Status.Mongos.Ready,
+			size:  arbiters,
+			ready: readyArbiters,
 		})
+	}
 
-		val := PSMDBCluster{
-			Name:    cluster.Name,
-			Size:    cluster.Spec.Replsets[0].Size,
-			State:   getReplicasetStatus(cluster),
-			Pause:   cluster.Spec.Pause,
-			Message: message,
-			Replicaset: &Replicaset{
-				DiskSize:         c.getDiskSize(cluster.Spec.Replsets[0].VolumeSpec),
-				ComputeResources: c.getComputeResources(cluster.Spec.Replsets[0].Resources),
-			},
-			DetailedState: status,
-			Exposed:       cluster.Spec.Sharding.Mongos.Expose.Enabled,
-		}
-
-		res[i] = val
+	res := PSMDBCluster{
+		Name:          cluster.Name,
+		Size:          cluster.Spec.Replsets[0].Size,
+		State:         getReplicasetStatus(*cluster),
+		Pause:         cluster.Spec.Pause,
+		Message:       message,
+		Replicaset:    shards[0],
+		DetailedState: status,
+		Topology:      getPSMDBTopology(cluster),
+		Shards:        shards,
+		Health:        getPSMDBHealth(cluster, pods),
+	}
+	if res.Topology == PSMDBTopologySharded && cluster.Spec.Sharding.Mongos != nil {
+		res.Exposed = cluster.Spec.Sharding.Mongos.Expose.Enabled
+	} else {
+		res.Exposed = cluster.Spec.Replsets[0].Expose.Enabled
 	}
-	return res, nil
+	return res
 }
 
 /*
@@ -1220,17 +1551,14 @@ func getReplicasetStatus(cluster psmdb.PerconaServerMongoDB) ClusterState {
 	return status
 }
 
-// getDeletingXtraDBClusters returns Percona XtraDB clusters which are not fully deleted yet.
-func (c *K8sClient) getDeletingPSMDBClusters(ctx context.Context, clusters []PSMDBCluster) ([]PSMDBCluster, error) {
+// getDeletingPSMDBClusters returns Percona Server for MongoDB clusters which are not fully deleted yet.
+func getDeletingPSMDBClusters(clusters []PSMDBCluster, pods map[string][]common.Pod) []PSMDBCluster {
 	runningClusters := make(map[string]struct{}, len(clusters))
 	for _, cluster := range clusters {
 		runningClusters[cluster.Name] = struct{}{}
 	}
 
-	deletingClusters, err := c.getDeletingClusters(ctx, "percona-server-mongodb-operator", runningClusters)
-	if err != nil {
-		return nil, err
-	}
+	deletingClusters := getDeletingClusters(pods, runningClusters)
 
 	xtradbClusters := make([]PSMDBCluster, len(deletingClusters))
 	for i, cluster := range deletingClusters {
@@ -1242,7 +1570,7 @@ func (c *K8sClient) getDeletingPSMDBClusters(ctx context.Context, clusters []PSM
 			DetailedState: []appStatus{},
 		}
 	}
-	return xtradbClusters, nil
+	return xtradbClusters
 }
 
 func (c *K8sClient) getComputeResources(resources *common.PodResources) *ComputeResources {
@@ -1297,8 +1625,10 @@ func (c *K8sClient) getDiskSize(volumeSpec *common.VolumeSpec) string {
 	return quantity
 }
 
-func (c *K8sClient) volumeSpec(diskSize string) *common.VolumeSpec {
-	return &common.VolumeSpec{
+// volumeSpec returns spec of persistent volumes of a given size and storage class,
+// empty storage class means the default one.
+func (c *K8sClient) volumeSpec(diskSize, storageClass string) *common.VolumeSpec {
+	res := &common.VolumeSpec{
 		PersistentVolumeClaim: &common.PersistentVolumeClaimSpec{
 			Resources: common.ResourceRequirements{
 				Requests: common.ResourceList{
@@ -1307,21 +1637,62 @@ func (c *K8sClient) volumeSpec(diskSize string) *common.VolumeSpec {
 			},
 		},
 	}
+	if storageClass != "" {
+		res.PersistentVolumeClaim.StorageClassName = pointer.ToString(storageClass)
+	}
+	return res
 }
 
-// CheckOperators checks if operator installed and have required API version.
-func (c *K8sClient) CheckOperators(ctx context.Context) (*Operators, error) {
-	output, err := c.kubeCtl.Run(ctx, []string{"api-versions"}, "")
+// CheckOperators checks if operator installed into a given namespace and have required API version.
+// Operators watch only their own namespace, so operator with CRDs installed,
+// but without deployment in that namespace is reported as not installed.
+func (c *K8sClient) CheckOperators(ctx context.Context, namespace string) (*Operators, error) {
+	output, err := c.kube.Run(ctx, []string{"api-versions"}, "")
 	if err != nil {
 		return nil, errors.Wrap(err, "can't get api versions list")
 	}
 
 	apiVersions := strings.Split(string(output), "\n")
 
-	return &Operators{
+	res := &Operators{
 		Xtradb: c.checkOperatorStatus(apiVersions, pxcAPIVersion),
 		Psmdb:  c.checkOperatorStatus(apiVersions, psmdbAPIVersion),
-	}, nil
+	}
+	for _, op := range []struct {
+		operator   *Operator
+		deployment string
+		bundleDir  string
+	}{
+		{&res.Xtradb, pxcOperatorDeploymentName, pxcOperatorBundleDir},
+		{&res.Psmdb, psmdbOperatorDeploymentName, psmdbOperatorBundleDir},
+	} {
+		if op.operator.Status == OperatorStatusNotInstalled {
+			continue
+		}
+		installed, err := c.isOperatorDeployed(ctx, namespace, op.deployment)
+		if err != nil {
+			return nil, err
+		}
+		if !installed {
+			*op.operator = Operator{Status: OperatorStatusNotInstalled}
+			continue
+		}
+		op.operator.EmbeddedVersion = embeddedOperatorVersion(op.bundleDir, op.operator.Version)
+	}
+	return res, nil
+}
+
+// isOperatorDeployed returns true if operator deployment exists in a given namespace.
+func (c *K8sClient) isOperatorDeployed(ctx context.Context, namespace, name string) (bool, error) {
+	var deployment json.RawMessage
+	err := c.kube.Get(ctx, namespace, "deployment", name, &deployment)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, kubectl.ErrNotFound) {
+		return false, nil
+	}
+	return false, errors.Wrapf(err, "can't get %s deployment", name)
 }
 
 // checkOperatorStatus returns if operator is installed and operators version.
@@ -1378,7 +1749,7 @@ func sumVolumesSize(pvs *common.PersistentVolumeList) (sum uint64, err error) {
 func (c *K8sClient) GetPersistentVolumes(ctx context.Context) (*common.PersistentVolumeList, error) {
 	list := new(common.PersistentVolumeList)
 	args := []string{"get", "pv", "-ojson"}
-	out, err := c.kubeCtl.Run(ctx, args, nil)
+	out, err := c.kube.Run(ctx, args, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get persistent volumes")
 	}
@@ -1389,14 +1760,14 @@ func (c *K8sClient) GetPersistentVolumes(ctx context.Context) (*common.Persisten
 	return list, nil
 }
 
-// GetPods returns list of pods based on given filters. Filters are args to
-// kubectl command. For example "-lyour-label=value", "-ntest-namespace".
-func (c *K8sClient) GetPods(ctx context.Context, filters ...string) (*common.PodList, error) {
+// GetPods returns list of pods in a given namespace based on given filters. Filters are args to
+// kubectl command. For example "-lyour-label=value", "--all-namespaces".
+func (c *K8sClient) GetPods(ctx context.Context, namespace string, filters ...string) (*common.PodList, error) {
 	list := new(common.PodList)
-	args := []string{"get", "pods"}
+	args := withNamespace([]string{"get", "pods"}, namespace)
 	args = append(args, filters...)
 	args = append(args, "-ojson")
-	out, err := c.kubeCtl.Run(ctx, args, nil)
+	out, err := c.kube.Run(ctx, args, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get kubernetes pods")
 	}
@@ -1412,13 +1783,14 @@ func (c *K8sClient) GetPods(ctx context.Context, filters ...string) (*common.Pod
 func (c *K8sClient) GetLogs(
 	ctx context.Context,
 	containerStatuses []common.ContainerStatus,
+	namespace,
 	pod,
 	container string,
 ) ([]string, error) {
 	if common.IsContainerInState(containerStatuses, common.ContainerStateWaiting, container) {
 		return []string{}, nil
 	}
-	stdout, err := c.kubeCtl.Run(ctx, []string{"logs", pod, container}, nil)
+	stdout, err := c.kube.Run(ctx, withNamespace([]string{"logs", pod, container}, namespace), nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get logs")
 	}
@@ -1428,43 +1800,33 @@ func (c *K8sClient) GetLogs(
 	return strings.Split(string(stdout), "\n"), nil
 }
 
-// GetEvents returns pod's events as a slice of strings.
-func (c *K8sClient) GetEvents(ctx context.Context, pod string) ([]string, error) {
-	stdout, err := c.kubeCtl.Run(ctx, []string{"describe", "pod", pod}, nil)
+// GetPreviousLogs returns logs of the previous terminated instance of given pod's container
+// as slice of log lines. An error is returned if there is no previous instance, for example
+// if the container was not restarted or the instance was garbage collected.
+func (c *K8sClient) GetPreviousLogs(ctx context.Context, namespace, pod, container string) ([]string, error) {
+	stdout, err := c.kube.Run(ctx, withNamespace([]string{"logs", pod, container, "--previous"}, namespace), nil)
 	if err != nil {
-		return nil, errors.Wrap(err, "couldn't describe pod")
+		return nil, errors.Wrap(err, "couldn't get previous logs")
 	}
-	lines := strings.Split(string(stdout), "\n")
-	var line string
-	var i int
-	for i, line = range lines {
-		if strings.Contains(line, "Events") {
-			break
-		}
+	if string(stdout) == "" {
+		return []string{}, nil
 	}
-	// Add name of the pod to the Events line so it's clear what pod events we got.
-	lines[i] = pod + " " + lines[i]
-	return lines[i:], nil
+	return strings.Split(string(stdout), "\n"), nil
 }
 
 // getWorkerNodes returns list of cluster workers nodes.
 func (c *K8sClient) getWorkerNodes(ctx context.Context) ([]common.Node, error) {
-	nodes := new(common.NodeList)
-	out, err := c.kubeCtl.Run(ctx, []string{"get", "nodes", "-ojson"}, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not get nodes of Kubernetes cluster")
-	}
-	err = json.Unmarshal(out, nodes)
+	nodes, err := c.getNodes(ctx)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not get nodes of Kubernetes cluster")
+		return nil, err
 	}
 	forbidenTaints := map[string]string{
 		"node.cloudprovider.kubernetes.io/uninitialized": "NoSchedule",
 		"node.kubernetes.io/unschedulable":               "NoSchedule",
 		"node-role.kubernetes.io/master":                 "NoSchedule",
 	}
-	workers := make([]common.Node, 0, len(nodes.Items))
-	for _, node := range nodes.Items {
+	workers := make([]common.Node, 0, len(nodes))
+	for _, node := range nodes {
 		if len(node.Spec.Taints) == 0 {
 			workers = append(workers, node)
 			continue
@@ -1480,6 +1842,8 @@ func (c *K8sClient) getWorkerNodes(ctx context.Context) ([]common.Node, error) {
 }
 
 // GetAllClusterResources goes through all cluster nodes and sums their allocatable resources.
+// Disk size is computed by a strategy of a given cluster type, see diskStrategy.
+// Persistent volumes are used by cloud platforms, they are requested if nil.
 func (c *K8sClient) GetAllClusterResources(ctx context.Context, clusterType kubernetesClusterType, volumes *common.PersistentVolumeList) (
 	cpuMillis uint64, memoryBytes uint64, diskSizeBytes uint64, err error,
 ) {
@@ -1487,7 +1851,8 @@ func (c *K8sClient) GetAllClusterResources(ctx context.Context, clusterType kube
 	if err != nil {
 		return 0, 0, 0, errors.Wrap(err, "could not get a list of nodes")
 	}
-	var volumeCountEKS uint64
+	strategy, limits := diskStrategy(clusterType, nodes)
+	var volumeCount uint64
 	for _, node := range nodes {
 		cpu, memory, err := getResources(node.Status.Allocatable)
 		if err != nil {
@@ -1496,8 +1861,8 @@ func (c *K8sClient) GetAllClusterResources(ctx context.Context, clusterType kube
 		cpuMillis += cpu
 		memoryBytes += memory
 
-		switch clusterType {
-		case MinikubeClusterType:
+		switch strategy {
+		case diskStrategyNodeStorage:
 			storage, ok := node.Status.Allocatable[common.ResourceEphemeralStorage]
 			if !ok {
 				return 0, 0, 0, errors.Errorf("could not get storage size of the node")
@@ -1507,46 +1872,36 @@ func (c *K8sClient) GetAllClusterResources(ctx context.Context, clusterType kube
 				return 0, 0, 0, errors.Wrapf(err, "could not convert storage size '%s' to bytes", storage)
 			}
 			diskSizeBytes += bytes
-		case AmazonEKSClusterType:
+		case diskStrategyVolumeLimits:
 			// See https://kubernetes.io/docs/tasks/administer-cluster/out-of-resource/#scheduler.
 			if common.IsNodeInCondition(node, common.NodeConditionDiskPressure) {
 				continue
 			}
-
-			// Get nodes's type.
-			nodeType, ok := node.Labels["beta.kubernetes.io/instance-type"]
-			if !ok {
-				return 0, 0, 0, errors.New("dealing with AWS EKS cluster but the node does not have label 'beta.kubernetes.io/instance-type'")
-			}
-			// 39 is a default limit for EKS cluster nodes ...
-			var volumeLimitPerNode uint64 = 39
-			typeAndSize := strings.Split(strings.ToLower(nodeType), ".")
-			if len(typeAndSize) < 2 {
-				return 0, 0, 0, errors.Errorf("failed to parse EKS node type '%s', it's not in expected format 'type.size'", nodeType)
-			}
-			// ... however, if the node type is one of M5, C5, R5, T3, Z1D it's 25.
-			limitedVolumesSet := map[string]struct{}{
-				"m5": {}, "c5": {}, "r5": {}, "t3": {}, "t1d": {},
-			}
-			if _, ok := limitedVolumesSet[typeAndSize[0]]; ok {
-				volumeLimitPerNode = 25
+			count, err := limits.nodeLimit(node)
+			if err != nil {
+				return 0, 0, 0, err
 			}
-			volumeCountEKS += volumeLimitPerNode
+			volumeCount += count
 		}
 	}
-	if clusterType == AmazonEKSClusterType {
-		volumeCountEKSBackup := volumeCountEKS
-		volumeCountEKS -= uint64(len(volumes.Items))
-		if volumeCountEKS > volumeCountEKSBackup {
+	if strategy == diskStrategyVolumeLimits {
+		if volumes == nil {
+			if volumes, err = c.GetPersistentVolumes(ctx); err != nil {
+				return 0, 0, 0, err
+			}
+		}
+		volumeCountBackup := volumeCount
+		volumeCount -= uint64(len(volumes.Items))
+		if volumeCount > volumeCountBackup {
 			// handle uint underflow
-			volumeCountEKS = 0
+			volumeCount = 0
 		}
 
 		consumedBytes, err := sumVolumesSize(volumes)
 		if err != nil {
 			return 0, 0, 0, errors.Wrap(err, "failed to sum persistent volumes storage sizes")
 		}
-		diskSizeBytes = (volumeCountEKS * maxVolumeSizeEBS) + consumedBytes
+		diskSizeBytes = (volumeCount * limits.maxVolumeSize) + consumedBytes
 	}
 	return cpuMillis, memoryBytes, diskSizeBytes, nil
 }
@@ -1577,18 +1932,21 @@ func (c *K8sClient) GetConsumedCPUAndMemory(ctx context.Context, namespaces ...s
 	cpuMillis uint64, memoryBytes uint64, err error,
 ) {
 	// Get CPU and Memory Requests of Pods' containers.
+	pods := new(common.PodList)
 	if len(namespaces) == 0 {
-		namespaces = []string{"--all-namespaces"}
-	} else {
-		for i := 0; i < len(namespaces); i++ {
-			namespaces[i] = "-n" + namespaces[i]
+		pods, err = c.GetPods(ctx, "", "--all-namespaces")
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "failed to get consumed resources")
 		}
 	}
-
-	pods, err := c.GetPods(ctx, namespaces...)
-	if err != nil {
-		return 0, 0, errors.Wrap(err, "failed to get consumed resources")
+	for _, namespace := range namespaces {
+		list, err := c.GetPods(ctx, namespace)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "failed to get consumed resources")
+		}
+		pods.Items = append(pods.Items, list.Items...)
 	}
+
 	for _, ppod := range pods.Items {
 		if ppod.Status.Phase != common.PodPhaseRunning {
 			continue
@@ -1615,25 +1973,39 @@ func (c *K8sClient) GetConsumedCPUAndMemory(ctx context.Context, namespaces ...s
 }
 
 // GetConsumedDiskBytes returns consumed bytes. The strategy differs based on k8s cluster type.
+// Persistent volumes are used by cloud platforms, they are requested if nil.
 func (c *K8sClient) GetConsumedDiskBytes(ctx context.Context, clusterType kubernetesClusterType, volumes *common.PersistentVolumeList) (consumedBytes uint64, err error) {
-	switch clusterType {
-	case MinikubeClusterType:
+	var nodes []common.Node
+	if clusterType == OpenShiftClusterType {
+		// strategy of OpenShift depends on its infrastructure
+		if nodes, err = c.getNodes(ctx); err != nil {
+			return 0, errors.Wrap(err, "can't compute consumed disk size")
+		}
+	}
+
+	strategy, _ := diskStrategy(clusterType, nodes)
+	switch strategy {
+	case diskStrategyNodeStorage:
 		nodes, err := c.getWorkerNodes(ctx)
 		if err != nil {
 			return 0, errors.Wrap(err, "can't compute consumed disk size: failed to get worker nodes")
 		}
 		for _, node := range nodes {
-			method := "GET"
 			endpoint := fmt.Sprintf("/v1/nodes/%s/proxy/stats/summary", node.Name)
 			summary := new(common.NodeSummary)
-			err = c.doAPIRequest(ctx, method, endpoint, &summary)
+			err = c.doAPIRequest(ctx, endpoint, &summary)
 			if err != nil {
 				return 0, errors.Wrap(err, "can't compute consumed disk size: failed to get worker nodes summary")
 			}
 			consumedBytes += summary.Node.FileSystem.UsedBytes
 		}
 		return consumedBytes, nil
-	case AmazonEKSClusterType:
+	case diskStrategyVolumeLimits:
+		if volumes == nil {
+			if volumes, err = c.GetPersistentVolumes(ctx); err != nil {
+				return 0, err
+			}
+		}
 		consumedBytes, err := sumVolumesSize(volumes)
 		if err != nil {
 			return 0, errors.Wrap(err, "failed to sum persistent volumes storage sizes")
@@ -1644,39 +2016,18 @@ func (c *K8sClient) GetConsumedDiskBytes(ctx context.Context, clusterType kubern
 	return 0, nil
 }
 
-// doAPIRequest starts kubectl proxy, does the request described using given endpoint and method,
-// unmarshals the result into the variable out and stops kubectl proxy.
-func (c *K8sClient) doAPIRequest(ctx context.Context, method, endpoint string, out interface{}) error {
+// doAPIRequest does the GET request to Kubernetes API server described using given endpoint
+// and unmarshals the result into the variable out.
+func (c *K8sClient) doAPIRequest(ctx context.Context, endpoint string, out interface{}) error {
 	if reflect.ValueOf(out).Kind() != reflect.Ptr {
 		return errors.New("output expected to be pointer")
 	}
 
-	port, err := c.kubeCtl.RunProxy(ctx)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		err := c.kubeCtl.StopProxy(port)
-		if err != nil {
-			c.l.Error(err)
-		}
-	}()
-
-	client := http.Client{
-		Timeout: time.Second * 5,
-		Transport: &http.Transport{
-			MaxIdleConns:    1,
-			IdleConnTimeout: 10 * time.Second,
-		},
-	}
-	req, err := http.NewRequestWithContext(ctx, method, "http://localhost:"+port+"/api"+endpoint, nil)
-	if err != nil {
-		return errors.Wrap(err, "failed to create Kubernetes API request")
-	}
-	var resp *http.Response
-	err = retry.Do(
+	var body []byte
+	err := retry.Do(
 		func() error {
-			resp, err = client.Do(req)
+			var err error
+			body, err = c.kube.Run(ctx, []string{"get", "--raw", "/api" + endpoint}, nil)
 			return err
 		},
 		retry.Context(ctx),
@@ -1685,22 +2036,29 @@ func (c *K8sClient) doAPIRequest(ctx context.Context, method, endpoint string, o
 		return errors.Wrap(err, "failed to do Kubernetes API request")
 	}
 
-	defer resp.Body.Close() //nolint:errcheck
-	return json.NewDecoder(resp.Body).Decode(out)
+	return json.Unmarshal(body, out)
 }
 
-func (c *K8sClient) InstallXtraDBOperator(ctx context.Context) error {
-	file, err := dbaascontroller.DeployDir.ReadFile("deploy/pxc-operator.yaml")
+// InstallXtraDBOperator installs XtraDB operator of a given version into a given namespace.
+// Empty version means the newest embedded version supported by Kubernetes cluster.
+// Operator watches database clusters only in that namespace.
+func (c *K8sClient) InstallXtraDBOperator(ctx context.Context, namespace, operatorVersion string) error {
+	v, file, err := c.selectOperatorBundle(ctx, pxcOperatorBundleDir, operatorVersion)
 	if err != nil {
 		return err
 	}
-	return c.kubeCtl.Apply(ctx, file)
+	c.l.Infof("Installing XtraDB operator %s.", v)
+	return c.kube.Apply(ctx, namespace, file)
 }
 
-func (c *K8sClient) InstallPSMDBOperator(ctx context.Context) error {
-	file, err := dbaascontroller.DeployDir.ReadFile("deploy/psmdb-operator.yaml")
+// InstallPSMDBOperator installs PSMDB operator of a given version into a given namespace.
+// Empty version means the newest embedded version supported by Kubernetes cluster.
+// Operator watches database clusters only in that namespace.
+func (c *K8sClient) InstallPSMDBOperator(ctx context.Context, namespace, operatorVersion string) error {
+	v, file, err := c.selectOperatorBundle(ctx, psmdbOperatorBundleDir, operatorVersion)
 	if err != nil {
 		return err
 	}
-	return c.kubeCtl.Apply(ctx, file)
+	c.l.Infof("Installing PSMDB operator %s.", v)
+	return c.kube.Apply(ctx, namespace, file)
 }
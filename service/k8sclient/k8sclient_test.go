@@ -47,13 +47,26 @@ type pod struct {
 	containers []string
 }
 
+// backends are tested against the same suite.
+var backends = []Backend{BackendNative, BackendKubectl} //nolint:gochecknoglobals
+
 func TestK8sClient(t *testing.T) {
+	for _, backend := range backends {
+		backend := backend
+		t.Run(string(backend), func(t *testing.T) {
+			testK8sClient(t, backend)
+		})
+	}
+}
+
+func testK8sClient(t *testing.T, backend Backend) {
+	t.Helper()
 	ctx := app.Context()
 
 	kubeconfig, err := ioutil.ReadFile(os.Getenv("HOME") + "/.kube/config")
 	require.NoError(t, err)
 
-	client, err := New(ctx, string(kubeconfig))
+	client, err := newWithBackend(ctx, string(kubeconfig), backend)
 	require.NoError(t, err)
 
 	t.Cleanup(func() {
@@ -64,41 +77,21 @@ func TestK8sClient(t *testing.T) {
 	l := logger.Get(ctx)
 
 	t.Run("Install operators", func(t *testing.T) { //nolint:paralleltest
-		err = client.InstallXtraDBOperator(ctx)
-		require.NoError(t, err)
-
-		err = client.InstallPSMDBOperator(ctx)
+		err = client.InstallXtraDBOperator(ctx, DefaultNamespace, "")
 		require.NoError(t, err)
 
-		for i := 0; i < 5; i++ {
-			_, err = client.kubeCtl.Run(ctx, []string{"wait", "--for=condition=Available", "deployment", "percona-xtradb-cluster-operator"}, nil) //nolint:paralleltest
-			if err == nil {
-				break
-			}
-			time.Sleep(3 * time.Second)
-		}
-		require.NoError(t, err)
-		var res interface{}
-		err = client.kubeCtl.Get(ctx, "deployment", "percona-xtradb-cluster-operator", &res)
+		err = client.InstallPSMDBOperator(ctx, DefaultNamespace, "")
 		require.NoError(t, err)
 
-		for i := 0; i < 5; i++ {
-			_, err = client.kubeCtl.Run(ctx, []string{"wait", "--for=condition=Available", "deployment", "percona-server-mongodb-operator"}, nil) //nolint:paralleltest
-			if err == nil {
-				break
-			}
-			time.Sleep(3 * time.Second)
-		}
-		require.NoError(t, err)
-		err = client.kubeCtl.Get(ctx, "deployment", "percona-server-mongodb-operator", &res)
-		require.NoError(t, err)
+		waitForDeploymentAvailable(ctx, t, client, "percona-xtradb-cluster-operator")
+		waitForDeploymentAvailable(ctx, t, client, "percona-server-mongodb-operator")
 	})
 
 	t.Run("Get non-existing clusters", func(t *testing.T) {
 		t.Parallel()
-		_, err := client.GetPSMDBClusterCredentials(ctx, "d0ca1166b638c-psmdb")
+		_, err := client.GetPSMDBClusterCredentials(ctx, DefaultNamespace, "d0ca1166b638c-psmdb")
 		assert.EqualError(t, errors.Cause(err), ErrNotFound.Error())
-		_, err = client.GetXtraDBClusterCredentials(ctx, "871f766d43f8e-xtradb")
+		_, err = client.GetXtraDBClusterCredentials(ctx, DefaultNamespace, "871f766d43f8e-xtradb")
 		assert.EqualError(t, errors.Cause(err), ErrNotFound.Error())
 	})
 
@@ -106,7 +99,7 @@ func TestK8sClient(t *testing.T) {
 	t.Run("XtraDB", func(t *testing.T) {
 		t.Parallel()
 		name := "test-cluster-xtradb"
-		_ = client.DeleteXtraDBCluster(ctx, name)
+		_ = client.DeleteXtraDBCluster(ctx, DefaultNamespace, name)
 
 		assertListXtraDBCluster(ctx, t, client, name, func(cluster *XtraDBCluster) bool {
 			return cluster == nil
@@ -129,7 +122,7 @@ func TestK8sClient(t *testing.T) {
 			return cluster != nil
 		})
 		t.Run("Get credentials of cluster that is not Ready", func(t *testing.T) {
-			_, err := client.GetXtraDBClusterCredentials(ctx, name)
+			_, err := client.GetXtraDBClusterCredentials(ctx, DefaultNamespace, name)
 			assert.EqualError(t, errors.Cause(err), ErrXtraDBClusterNotReady.Error())
 		})
 
@@ -157,7 +150,7 @@ func TestK8sClient(t *testing.T) {
 		})
 
 		t.Run("Get logs", func(t *testing.T) {
-			pods, err := client.GetPods(ctx, "-lapp.kubernetes.io/instance="+name)
+			pods, err := client.GetPods(ctx, DefaultNamespace, "-lapp.kubernetes.io/instance="+name)
 			require.NoError(t, err)
 
 			expectedPods := []pod{
@@ -205,7 +198,7 @@ func TestK8sClient(t *testing.T) {
 						container.Name,
 					)
 
-					logs, err := client.GetLogs(ctx, ppod.Status.ContainerStatuses, ppod.Name, container.Name)
+					logs, err := client.GetLogs(ctx, ppod.Status.ContainerStatuses, DefaultNamespace, ppod.Name, container.Name)
 					require.NoError(t, err, "failed to get logs")
 					assert.Greater(t, len(logs), 0)
 					for _, l := range logs {
@@ -215,7 +208,7 @@ func TestK8sClient(t *testing.T) {
 			}
 		})
 
-		err = client.RestartXtraDBCluster(ctx, name)
+		err = client.RestartXtraDBCluster(ctx, DefaultNamespace, name)
 		require.NoError(t, err)
 		assertListXtraDBCluster(ctx, t, client, name, func(cluster *XtraDBCluster) bool {
 			return cluster != nil && cluster.State == ClusterStateChanging
@@ -241,7 +234,7 @@ func TestK8sClient(t *testing.T) {
 			return false
 		})
 
-		err = client.DeleteXtraDBCluster(ctx, name)
+		err = client.DeleteXtraDBCluster(ctx, DefaultNamespace, name)
 		require.NoError(t, err)
 
 		assertListXtraDBCluster(ctx, t, client, name, func(cluster *XtraDBCluster) bool {
@@ -266,7 +259,7 @@ func TestK8sClient(t *testing.T) {
 		})
 
 		// Test listing.
-		clusters, err := client.ListXtraDBClusters(ctx)
+		clusters, err := client.ListXtraDBClusters(ctx, DefaultNamespace)
 		require.NoError(t, err)
 		assert.Conditionf(t,
 			func(clusters []XtraDBCluster, clusterName string) assert.Comparison {
@@ -283,14 +276,14 @@ func TestK8sClient(t *testing.T) {
 			clusterName,
 		)
 
-		err = client.DeleteXtraDBCluster(ctx, clusterName)
+		err = client.DeleteXtraDBCluster(ctx, DefaultNamespace, clusterName)
 		require.NoError(t, err)
 	})
 
 	t.Run("PSMDB", func(t *testing.T) {
 		t.Parallel()
 		name := "test-cluster-psmdb"
-		_ = client.DeletePSMDBCluster(ctx, name)
+		_ = client.DeletePSMDBCluster(ctx, DefaultNamespace, name)
 
 		assertListPSMDBCluster(ctx, t, client, name, func(cluster *PSMDBCluster) bool {
 			return cluster == nil
@@ -313,7 +306,7 @@ func TestK8sClient(t *testing.T) {
 		})
 
 		t.Run("Get credentials of cluster that is not Ready", func(t *testing.T) {
-			_, err := client.GetPSMDBClusterCredentials(ctx, name)
+			_, err := client.GetPSMDBClusterCredentials(ctx, DefaultNamespace, name)
 			assert.EqualError(t, errors.Cause(err), ErrPSMDBClusterNotReady.Error())
 		})
 
@@ -339,7 +332,7 @@ func TestK8sClient(t *testing.T) {
 			assert.Equal(t, int32(9), cluster.DetailedState.CountAllPods())
 		})
 
-		err = client.RestartPSMDBCluster(ctx, name)
+		err = client.RestartPSMDBCluster(ctx, DefaultNamespace, name)
 		require.NoError(t, err)
 
 		assertListPSMDBCluster(ctx, t, client, name, func(cluster *PSMDBCluster) bool {
@@ -366,7 +359,7 @@ func TestK8sClient(t *testing.T) {
 			return false
 		})
 
-		err = client.DeletePSMDBCluster(ctx, name)
+		err = client.DeletePSMDBCluster(ctx, DefaultNamespace, name)
 		require.NoError(t, err)
 
 		assertListPSMDBCluster(ctx, t, client, name, func(cluster *PSMDBCluster) bool {
@@ -377,7 +370,7 @@ func TestK8sClient(t *testing.T) {
 
 	t.Run("CheckOperators", func(t *testing.T) {
 		t.Parallel()
-		operators, err := client.CheckOperators(ctx)
+		operators, err := client.CheckOperators(ctx, DefaultNamespace)
 		require.NoError(t, err)
 		assert.Equal(t, &Operators{
 			Xtradb: Operator{
@@ -393,12 +386,37 @@ func TestK8sClient(t *testing.T) {
 	})
 }
 
+// waitForDeploymentAvailable waits the same way as `kubectl wait --for=condition=Available` does
+// but works with all backends.
+func waitForDeploymentAvailable(ctx context.Context, t *testing.T, client *K8sClient, name string) {
+	t.Helper()
+	var deployment struct {
+		Status struct {
+			Conditions []struct {
+				Type   string `json:"type"`
+				Status string `json:"status"`
+			} `json:"conditions"`
+		} `json:"status"`
+	}
+	for i := 0; i < 20; i++ {
+		err := client.kube.Get(ctx, DefaultNamespace, "deployment", name, &deployment)
+		require.NoError(t, err)
+		for _, condition := range deployment.Status.Conditions {
+			if condition.Type == "Available" && condition.Status == "True" {
+				return
+			}
+		}
+		time.Sleep(3 * time.Second)
+	}
+	t.Fatalf("deployment %s is not available", name)
+}
+
 // ErrNoSuchCluster indicates that no cluster with given name was found.
 var ErrNoSuchCluster error = errors.New("no cluster found with given name")
 
 func getPSMDBCluster(ctx context.Context, client *K8sClient, name string) (*PSMDBCluster, error) {
 	l := logger.Get(ctx)
-	clusters, err := client.ListPSMDBClusters(ctx)
+	clusters, err := client.ListPSMDBClusters(ctx, DefaultNamespace)
 	if err != nil {
 		return nil, err
 	}
@@ -413,7 +431,7 @@ func getPSMDBCluster(ctx context.Context, client *K8sClient, name string) (*PSMD
 
 func getXtraDBCluster(ctx context.Context, client *K8sClient, name string) (*XtraDBCluster, error) {
 	l := logger.Get(ctx)
-	clusters, err := client.ListXtraDBClusters(ctx)
+	clusters, err := client.ListXtraDBClusters(ctx, DefaultNamespace)
 	if err != nil {
 		return nil, err
 	}
@@ -467,9 +485,23 @@ func TestGetConsumedCPUAndMemory(t *testing.T) {
 	kubeconfig, err := ioutil.ReadFile(os.Getenv("HOME") + "/.kube/config")
 	require.NoError(t, err)
 
-	client, err := New(ctx, string(kubeconfig))
+	// Namespace and test pods are managed with kubectl, consumed resources are checked with all backends.
+	client, err := newWithBackend(ctx, string(kubeconfig), BackendKubectl)
 	require.NoError(t, err)
 
+	assertConsumed := func(expectedCPUMillis, expectedMemoryBytes uint64, namespace string) {
+		t.Helper()
+		for _, backend := range backends {
+			c, err := newWithBackend(ctx, string(kubeconfig), backend)
+			require.NoError(t, err)
+			cpuMillis, memoryBytes, err := c.GetConsumedCPUAndMemory(ctx, namespace)
+			require.NoError(t, err)
+			assert.Equal(t, expectedCPUMillis, cpuMillis, backend)
+			assert.Equal(t, expectedMemoryBytes, memoryBytes, backend)
+			require.NoError(t, c.Cleanup())
+		}
+	}
+
 	b := make([]byte, 4)
 	n, err := rand.Read(b)
 	require.NoError(t, err)
@@ -477,32 +509,29 @@ func TestGetConsumedCPUAndMemory(t *testing.T) {
 	consumedResourcesTestNamespace := "consumed-resources-test-" + hex.EncodeToString(b)
 
 	t.Cleanup(func() {
-		_, err := client.kubeCtl.Run(ctx, []string{"delete", "ns", consumedResourcesTestNamespace}, nil)
+		_, err := client.kube.Run(ctx, []string{"delete", "ns", consumedResourcesTestNamespace}, nil)
 		require.NoError(t, err)
 		err = client.Cleanup()
 		require.NoError(t, err)
 	})
 
-	_, err = client.kubeCtl.Run(ctx, []string{"create", "ns", consumedResourcesTestNamespace}, nil)
+	_, err = client.kube.Run(ctx, []string{"create", "ns", consumedResourcesTestNamespace}, nil)
 	require.NoError(t, err)
 
 	args := []string{
 		"apply", "-f", consumedResourcesTestPodsManifestPath,
 		"-n" + consumedResourcesTestNamespace,
 	}
-	_, err = client.kubeCtl.Run(ctx, args, nil)
+	_, err = client.kube.Run(ctx, args, nil)
 	require.NoError(t, err)
 	args = []string{
 		"wait", "--for=condition=ready", "--timeout=20s",
 		"pods", "hello1", "hello2", "-n" + consumedResourcesTestNamespace,
 	}
-	_, err = client.kubeCtl.Run(ctx, args, nil)
+	_, err = client.kube.Run(ctx, args, nil)
 	require.NoError(t, err)
 
-	cpuMillis, memoryBytes, err := client.GetConsumedCPUAndMemory(ctx, consumedResourcesTestNamespace)
-	require.NoError(t, err)
-	assert.Equal(t, uint64(40), cpuMillis)
-	assert.Equal(t, uint64(192928615), memoryBytes)
+	assertConsumed(40, 192928615, consumedResourcesTestNamespace)
 
 	// Test we dont include succeeded and failed pods into consumed resources.
 	// Wait for test pods to be completed:
@@ -516,7 +545,7 @@ func TestGetConsumedCPUAndMemory(t *testing.T) {
 			return
 		default:
 		}
-		list, err := client.GetPods(ctx, "-n"+consumedResourcesTestNamespace, "hello1", "hello2")
+		list, err := client.GetPods(ctx, consumedResourcesTestNamespace, "hello1", "hello2")
 		require.NoError(t, err)
 		var failed, succeeded bool
 		for _, pod := range list.Items {
@@ -534,20 +563,28 @@ func TestGetConsumedCPUAndMemory(t *testing.T) {
 		}
 	}
 
-	cpuMillis, memoryBytes, err = client.GetConsumedCPUAndMemory(ctx, consumedResourcesTestNamespace)
-	require.NoError(t, err)
-	assert.Equal(t, uint64(0), cpuMillis)
-	assert.Equal(t, uint64(0), memoryBytes)
+	assertConsumed(0, 0, consumedResourcesTestNamespace)
 }
 
 func TestGetAllClusterResources(t *testing.T) {
 	t.Parallel()
+	for _, backend := range backends {
+		backend := backend
+		t.Run(string(backend), func(t *testing.T) {
+			t.Parallel()
+			testGetAllClusterResources(t, backend)
+		})
+	}
+}
+
+func testGetAllClusterResources(t *testing.T, backend Backend) {
+	t.Helper()
 	ctx := app.Context()
 
 	kubeconfig, err := ioutil.ReadFile(os.Getenv("HOME") + "/.kube/config")
 	require.NoError(t, err)
 
-	client, err := New(ctx, string(kubeconfig))
+	client, err := newWithBackend(ctx, string(kubeconfig), backend)
 	require.NoError(t, err)
 
 	t.Cleanup(func() {
@@ -0,0 +1,208 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+
+	dbaascontroller "github.com/percona-platform/dbaas-controller"
+)
+
+// Embedded operator bundles are stored as <dir>/<version>.yaml.
+const (
+	pxcOperatorBundleDir   = "deploy/pxc-operator"
+	psmdbOperatorBundleDir = "deploy/psmdb-operator"
+)
+
+// CustomResourceDefinition API versions and Kubernetes minor versions serving them.
+const (
+	crdAPIVersionV1      = "apiextensions.k8s.io/v1"
+	crdAPIVersionV1beta1 = "apiextensions.k8s.io/v1beta1"
+	// crdV1MinKubernetesMinor is the first Kubernetes 1.x version serving apiextensions.k8s.io/v1.
+	crdV1MinKubernetesMinor = 16
+	// crdV1beta1MaxKubernetesMinor is the last Kubernetes 1.x version serving apiextensions.k8s.io/v1beta1.
+	crdV1beta1MaxKubernetesMinor = 21
+)
+
+// kubernetesVersion represents Kubernetes server version.
+type kubernetesVersion struct {
+	Major int
+	Minor int
+}
+
+// kubernetesRange is a range of Kubernetes 1.x minor versions, zero maxMinor means no upper limit.
+type kubernetesRange struct {
+	minMinor int
+	maxMinor int
+}
+
+// operatorBundleKubernetesRanges contains Kubernetes versions supported by embedded operator bundles
+// according to operators' release notes, keyed by bundle file. Bundles without a range are limited
+// by API versions of their custom resource definitions only.
+var operatorBundleKubernetesRanges = map[string]kubernetesRange{ //nolint:gochecknoglobals
+	path.Join(pxcOperatorBundleDir, "1.7.0.yaml"):   {minMinor: 11, maxMinor: 19},
+	path.Join(pxcOperatorBundleDir, "1.8.0.yaml"):   {minMinor: 16},
+	path.Join(psmdbOperatorBundleDir, "1.7.0.yaml"): {minMinor: 11, maxMinor: 19},
+	path.Join(psmdbOperatorBundleDir, "1.8.0.yaml"): {minMinor: 16},
+}
+
+// contains returns true if a given Kubernetes version is in the range.
+func (r kubernetesRange) contains(kube *kubernetesVersion) bool {
+	if kube.Major != 1 {
+		return kube.Major > 1 && r.maxMinor == 0
+	}
+	return kube.Minor >= r.minMinor && (r.maxMinor == 0 || kube.Minor <= r.maxMinor)
+}
+
+// operatorBundlePath returns path of an embedded operator bundle of a given version.
+func operatorBundlePath(dir, operatorVersion string) string {
+	return path.Join(dir, operatorVersion+".yaml")
+}
+
+// operatorBundleVersions returns versions of operator bundles embedded in a given directory, newest first.
+func operatorBundleVersions(dir string) ([]string, error) {
+	entries, err := fs.ReadDir(dbaascontroller.DeployDir, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read embedded operator bundles")
+	}
+
+	versions := make([]*version.Version, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || name == entry.Name() {
+			continue
+		}
+		v, err := version.NewVersion(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse embedded operator bundle version %s", name)
+		}
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(version.Collection(versions)))
+
+	res := make([]string, len(versions))
+	for i, v := range versions {
+		res[i] = v.Original()
+	}
+	return res, nil
+}
+
+// operatorBundle returns manifest of an embedded operator bundle of a given version.
+func operatorBundle(dir, operatorVersion string) ([]byte, error) {
+	manifest, err := dbaascontroller.DeployDir.ReadFile(operatorBundlePath(dir, operatorVersion))
+	if err != nil {
+		return nil, errors.Errorf("operator version %s is not available", operatorVersion)
+	}
+	return manifest, nil
+}
+
+// supportsKubernetes returns true if custom resource definitions of a given manifest
+// are served by Kubernetes of a given version.
+func supportsKubernetes(manifest []byte, kube *kubernetesVersion) bool {
+	if kube.Major != 1 {
+		return kube.Major > 1 && !bytes.Contains(manifest, []byte(crdAPIVersionV1beta1))
+	}
+	for _, line := range bytes.Split(manifest, []byte("\n")) {
+		switch strings.TrimSpace(strings.TrimPrefix(string(line), "apiVersion:")) {
+		case crdAPIVersionV1:
+			if kube.Minor < crdV1MinKubernetesMinor {
+				return false
+			}
+		case crdAPIVersionV1beta1:
+			if kube.Minor > crdV1beta1MaxKubernetesMinor {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// getKubernetesVersion returns Kubernetes server version.
+func (c *K8sClient) getKubernetesVersion(ctx context.Context) (*kubernetesVersion, error) {
+	out, err := c.kube.Run(ctx, []string{"get", "--raw", "/version"}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get Kubernetes server version")
+	}
+
+	var info struct {
+		Major string `json:"major"`
+		Minor string `json:"minor"`
+	}
+	if err = json.Unmarshal(out, &info); err != nil {
+		return nil, errors.Wrap(err, "cannot parse Kubernetes server version")
+	}
+	var res kubernetesVersion
+	if res.Major, err = strconv.Atoi(info.Major); err != nil {
+		return nil, errors.Wrap(err, "cannot parse Kubernetes server version")
+	}
+	// EKS returns minor versions like "16+"
+	if res.Minor, err = strconv.Atoi(strings.TrimSuffix(info.Minor, "+")); err != nil {
+		return nil, errors.Wrap(err, "cannot parse Kubernetes server version")
+	}
+	return &res, nil
+}
+
+// selectOperatorBundle returns version and manifest of a requested embedded operator bundle.
+// If version is not requested, the newest bundle supported by Kubernetes server is returned,
+// so older Kubernetes versions fall back to older operators.
+func (c *K8sClient) selectOperatorBundle(ctx context.Context, dir, operatorVersion string) (string, []byte, error) {
+	if operatorVersion != "" {
+		manifest, err := operatorBundle(dir, operatorVersion)
+		return operatorVersion, manifest, err
+	}
+
+	versions, err := operatorBundleVersions(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	kube, err := c.getKubernetesVersion(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, v := range versions {
+		manifest, err := operatorBundle(dir, v)
+		if err != nil {
+			return "", nil, err
+		}
+		if supportsKubernetes(manifest, kube) && operatorBundleKubernetesRanges[operatorBundlePath(dir, v)].contains(kube) {
+			return v, manifest, nil
+		}
+		c.l.Debugf("Operator %s %s does not support Kubernetes %d.%d.", dir, v, kube.Major, kube.Minor)
+	}
+	return "", nil, errors.Errorf("no operator version supports Kubernetes %d.%d", kube.Major, kube.Minor)
+}
+
+// embeddedOperatorVersion returns a given operator version if it is embedded, empty string otherwise.
+func embeddedOperatorVersion(dir, operatorVersion string) string {
+	if operatorVersion == "" {
+		return ""
+	}
+	if _, err := operatorBundle(dir, operatorVersion); err != nil {
+		return ""
+	}
+	return operatorVersion
+}
@@ -0,0 +1,116 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+func TestOperatorBundles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Versions", func(t *testing.T) {
+		t.Parallel()
+
+		for _, dir := range []string{pxcOperatorBundleDir, psmdbOperatorBundleDir} {
+			versions, err := operatorBundleVersions(dir)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"1.8.0", "1.7.0"}, versions)
+
+			_, err = operatorBundle(dir, "0.1.0")
+			assert.EqualError(t, err, "operator version 0.1.0 is not available")
+
+			assert.Equal(t, "1.8.0", embeddedOperatorVersion(dir, "1.8.0"))
+			assert.Equal(t, "", embeddedOperatorVersion(dir, "0.1.0"))
+		}
+	})
+
+	t.Run("SupportsKubernetes", func(t *testing.T) {
+		t.Parallel()
+
+		v1beta1 := []byte("apiVersion: apiextensions.k8s.io/v1beta1\nkind: CustomResourceDefinition\n---\napiVersion: apps/v1\nkind: Deployment\n")
+		assert.True(t, supportsKubernetes(v1beta1, &kubernetesVersion{Major: 1, Minor: 15}))
+		assert.True(t, supportsKubernetes(v1beta1, &kubernetesVersion{Major: 1, Minor: 21}))
+		assert.False(t, supportsKubernetes(v1beta1, &kubernetesVersion{Major: 1, Minor: 22}))
+
+		v1 := []byte("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n")
+		assert.False(t, supportsKubernetes(v1, &kubernetesVersion{Major: 1, Minor: 15}))
+		assert.True(t, supportsKubernetes(v1, &kubernetesVersion{Major: 1, Minor: 22}))
+	})
+
+	t.Run("KubernetesRange", func(t *testing.T) {
+		t.Parallel()
+
+		r := kubernetesRange{minMinor: 11, maxMinor: 19}
+		assert.False(t, r.contains(&kubernetesVersion{Major: 1, Minor: 10}))
+		assert.True(t, r.contains(&kubernetesVersion{Major: 1, Minor: 19}))
+		assert.False(t, r.contains(&kubernetesVersion{Major: 1, Minor: 20}))
+		assert.False(t, r.contains(&kubernetesVersion{Major: 2, Minor: 0}))
+
+		assert.True(t, kubernetesRange{minMinor: 16}.contains(&kubernetesVersion{Major: 1, Minor: 30}))
+		assert.True(t, kubernetesRange{}.contains(&kubernetesVersion{Major: 1, Minor: 0}))
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		for _, tt := range []struct {
+			minor    int
+			expected string
+		}{
+			{minor: 20, expected: "1.8.0"},
+			{minor: 16, expected: "1.8.0"},
+			// fallback to the older operator
+			{minor: 15, expected: "1.7.0"},
+			{minor: 11, expected: "1.7.0"},
+		} {
+			kube := &fakeDetailsClient{
+				outputs: map[string]string{"--raw": fmt.Sprintf(`{"major": "1", "minor": "%d+"}`, tt.minor)},
+			}
+			client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+			for _, dir := range []string{pxcOperatorBundleDir, psmdbOperatorBundleDir} {
+				v, manifest, err := client.selectOperatorBundle(ctx, dir, "")
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, v, "%s on Kubernetes 1.%d", dir, tt.minor)
+				assert.Contains(t, string(manifest), "-operator:"+tt.expected)
+			}
+		}
+
+		for _, minor := range []int{10, 22} {
+			kube := &fakeDetailsClient{
+				outputs: map[string]string{"--raw": fmt.Sprintf(`{"major": "1", "minor": "%d"}`, minor)},
+			}
+			client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+			_, _, err := client.selectOperatorBundle(ctx, pxcOperatorBundleDir, "")
+			assert.EqualError(t, err, fmt.Sprintf("no operator version supports Kubernetes 1.%d", minor))
+		}
+
+		// requested version is returned regardless of Kubernetes version
+		client := &K8sClient{kube: &fakeDetailsClient{}, l: logger.Get(ctx)}
+		v, _, err := client.selectOperatorBundle(ctx, psmdbOperatorBundleDir, "1.7.0")
+		require.NoError(t, err)
+		assert.Equal(t, "1.7.0", v)
+	})
+}
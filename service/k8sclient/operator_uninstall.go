@@ -0,0 +1,231 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+const customResourceDefinitionKind = "CustomResourceDefinition"
+
+// ErrOperatorInUse is returned when the operator to uninstall still has database clusters.
+var ErrOperatorInUse = errors.New("operator has database clusters")
+
+// OperatorUninstallParams contains parameters of an operator uninstall.
+type OperatorUninstallParams struct {
+	// Namespace of the operator, empty namespace means the default one.
+	Namespace string
+	// Force uninstalls the operator even if database clusters exist.
+	Force bool
+	// DeleteCRDs deletes custom resource definitions too, that deletes all database clusters,
+	// backups and restores of the operator in all namespaces.
+	DeleteCRDs bool
+}
+
+// KubernetesObject identifies a Kubernetes object.
+type KubernetesObject struct {
+	Kind string
+	Name string
+}
+
+// manifestObject is a Kubernetes object of a manifest, it is enough to delete the object.
+type manifestObject struct {
+	common.TypeMeta   // anonymous for embedding
+	common.ObjectMeta `json:"metadata,omitempty"`
+}
+
+// manifestObjects returns objects of a given multi-document manifest.
+func manifestObjects(manifest []byte) ([]manifestObject, error) {
+	var res []manifestObject
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+	for {
+		var obj manifestObject
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode manifest")
+		}
+		if obj.Kind == "" {
+			continue
+		}
+		res = append(res, obj)
+	}
+}
+
+// uninstallOrder returns objects in order of deletion: the operator deployment is stopped first,
+// then its permissions are revoked, custom resource definitions are deleted last if requested.
+func uninstallOrder(objects []manifestObject, deleteCRDs bool) []manifestObject {
+	var deployments, crds, rest []manifestObject
+	for _, obj := range objects {
+		switch obj.Kind {
+		case "Deployment":
+			deployments = append(deployments, obj)
+		case customResourceDefinitionKind:
+			crds = append(crds, obj)
+		default:
+			rest = append(rest, obj)
+		}
+	}
+
+	res := make([]manifestObject, 0, len(objects))
+	res = append(res, deployments...)
+	res = append(res, rest...)
+	if deleteCRDs {
+		res = append(res, crds...)
+	}
+	return res
+}
+
+// UninstallXtraDBOperator deletes XtraDB operator from a given namespace and returns deleted objects.
+// It refuses to uninstall the operator with existing clusters unless forced. With DeleteCRDs,
+// custom resources of the operator in all namespaces are checked.
+func (c *K8sClient) UninstallXtraDBOperator(ctx context.Context, params *OperatorUninstallParams) ([]KubernetesObject, error) {
+	operators, err := c.CheckOperators(ctx, params.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if operators.Xtradb.Status != OperatorStatusNotInstalled && !params.Force && !params.DeleteCRDs {
+		var list pxc.PerconaXtraDBClusterList
+		err = c.kube.Get(ctx, params.Namespace, string(perconaXtraDBClusterKind), "", &list)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't get Percona XtraDB clusters")
+		}
+		if len(list.Items) != 0 {
+			return nil, errors.Wrapf(ErrOperatorInUse, "XtraDB operator has %d clusters", len(list.Items))
+		}
+	}
+
+	return c.uninstallOperator(ctx, params, pxcOperatorBundleDir, operators.Xtradb.EmbeddedVersion)
+}
+
+// UninstallPSMDBOperator deletes PSMDB operator from a given namespace and returns deleted objects.
+// It refuses to uninstall the operator with existing clusters unless forced. With DeleteCRDs,
+// custom resources of the operator in all namespaces are checked.
+func (c *K8sClient) UninstallPSMDBOperator(ctx context.Context, params *OperatorUninstallParams) ([]KubernetesObject, error) {
+	operators, err := c.CheckOperators(ctx, params.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if operators.Psmdb.Status != OperatorStatusNotInstalled && !params.Force && !params.DeleteCRDs {
+		var list psmdb.PerconaServerMongoDBList
+		err = c.kube.Get(ctx, params.Namespace, string(perconaServerMongoDBKind), "", &list)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't get percona server MongoDB clusters")
+		}
+		if len(list.Items) != 0 {
+			return nil, errors.Wrapf(ErrOperatorInUse, "PSMDB operator has %d clusters", len(list.Items))
+		}
+	}
+
+	return c.uninstallOperator(ctx, params, psmdbOperatorBundleDir, operators.Psmdb.EmbeddedVersion)
+}
+
+// uninstallOperator deletes objects of an embedded operator bundle of a given version.
+// The newest bundle is used if version is not known, operator object names do not change between versions.
+func (c *K8sClient) uninstallOperator(ctx context.Context, params *OperatorUninstallParams, dir, operatorVersion string) ([]KubernetesObject, error) {
+	if operatorVersion == "" {
+		versions, err := operatorBundleVersions(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, errors.Errorf("no embedded operator bundles in %s", dir)
+		}
+		operatorVersion = versions[0]
+	}
+	manifest, err := operatorBundle(dir, operatorVersion)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := manifestObjects(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.DeleteCRDs && !params.Force {
+		count, err := c.countCustomResources(ctx, objects)
+		if err != nil {
+			return nil, err
+		}
+		if count != 0 {
+			return nil, errors.Wrapf(ErrOperatorInUse, "operator has %d custom resources in all namespaces", count)
+		}
+	}
+
+	var res []KubernetesObject
+	for _, obj := range uninstallOrder(objects, params.DeleteCRDs) {
+		obj := obj
+		err = c.kube.Delete(ctx, params.Namespace, &obj)
+		if errors.Is(err, kubectl.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return res, errors.Wrapf(err, "cannot delete %s %s", obj.Kind, obj.Name)
+		}
+		c.l.Infof("Deleted %s %s.", obj.Kind, obj.Name)
+		res = append(res, KubernetesObject{Kind: obj.Kind, Name: obj.Name})
+	}
+	return res, nil
+}
+
+// countCustomResources returns a number of custom resources of all kinds defined by given objects
+// in all namespaces. Custom resource definitions that don't exist are skipped.
+func (c *K8sClient) countCustomResources(ctx context.Context, objects []manifestObject) (int, error) {
+	var count int
+	for _, obj := range objects {
+		if obj.Kind != customResourceDefinitionKind {
+			continue
+		}
+		var crd json.RawMessage
+		err := c.kube.Get(ctx, "", customResourceDefinitionKind, obj.Name, &crd)
+		if errors.Is(err, kubectl.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot get %s %s", obj.Kind, obj.Name)
+		}
+
+		// CRD name is <plural>.<group>, that is a fully qualified resource name
+		out, err := c.kube.Run(ctx, []string{"get", obj.Name, "--all-namespaces", "-ojson"}, nil)
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot get %s", obj.Name)
+		}
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err = json.Unmarshal(out, &list); err != nil {
+			return 0, errors.Wrapf(err, "cannot get %s", obj.Name)
+		}
+		count += len(list.Items)
+	}
+	return count, nil
+}
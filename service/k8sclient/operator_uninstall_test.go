@@ -0,0 +1,122 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakeUninstallClient returns no API versions, given objects and outputs of fakeDetailsClient,
+// and records deleted objects.
+type fakeUninstallClient struct {
+	fakeDetailsClient
+	deleted []string
+}
+
+func (f *fakeUninstallClient) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
+	if args[0] == "api-versions" {
+		return nil, nil
+	}
+	return f.fakeDetailsClient.Run(ctx, args, stdin)
+}
+
+func (f *fakeUninstallClient) Delete(ctx context.Context, namespace string, res interface{}) error {
+	obj := res.(*manifestObject)
+	f.deleted = append(f.deleted, obj.Kind+"/"+obj.Name)
+	return nil
+}
+
+func TestOperatorUninstall(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := operatorBundle(psmdbOperatorBundleDir, "1.8.0")
+	require.NoError(t, err)
+	objects, err := manifestObjects(manifest)
+	require.NoError(t, err)
+
+	kinds := func(objects []manifestObject) []string {
+		res := make([]string, len(objects))
+		for i, obj := range objects {
+			res[i] = obj.Kind + "/" + obj.Name
+		}
+		return res
+	}
+
+	assert.Equal(t, []string{
+		"Deployment/percona-server-mongodb-operator",
+		"Role/percona-server-mongodb-operator",
+		"ServiceAccount/percona-server-mongodb-operator",
+		"RoleBinding/service-account-percona-server-mongodb-operator",
+	}, kinds(uninstallOrder(objects, false)))
+
+	withCRDs := kinds(uninstallOrder(objects, true))
+	require.Len(t, withCRDs, 7)
+	assert.Equal(t, "CustomResourceDefinition/perconaservermongodbs.psmdb.percona.com", withCRDs[4])
+}
+
+func TestOperatorUninstallDeleteCRDs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	const crd = "perconaservermongodbbackups.psmdb.percona.com"
+
+	t.Run("ResourcesInOtherNamespaces", func(t *testing.T) {
+		t.Parallel()
+
+		// operator is not installed, but its custom resources exist
+		kube := &fakeUninstallClient{fakeDetailsClient: fakeDetailsClient{
+			objects: map[string]string{customResourceDefinitionKind + "/" + crd: `{}`},
+			outputs: map[string]string{crd: `{"items": [{"metadata": {"name": "backup", "namespace": "other"}}]}`},
+		}}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		_, err := client.UninstallPSMDBOperator(ctx, &OperatorUninstallParams{Namespace: "test", DeleteCRDs: true})
+		assert.True(t, errors.Is(err, ErrOperatorInUse))
+		assert.Empty(t, kube.deleted)
+	})
+
+	t.Run("Force", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeUninstallClient{fakeDetailsClient: fakeDetailsClient{
+			objects: map[string]string{customResourceDefinitionKind + "/" + crd: `{}`},
+			outputs: map[string]string{crd: `{"items": [{"metadata": {"name": "backup", "namespace": "other"}}]}`},
+		}}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		deleted, err := client.UninstallPSMDBOperator(ctx, &OperatorUninstallParams{Namespace: "test", DeleteCRDs: true, Force: true})
+		require.NoError(t, err)
+		assert.Len(t, deleted, 7)
+	})
+
+	t.Run("NoResources", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeUninstallClient{fakeDetailsClient: fakeDetailsClient{
+			objects: map[string]string{customResourceDefinitionKind + "/" + crd: `{}`},
+		}}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		deleted, err := client.UninstallPSMDBOperator(ctx, &OperatorUninstallParams{Namespace: "test", DeleteCRDs: true})
+		require.NoError(t, err)
+		assert.Len(t, deleted, 7)
+	})
+}
@@ -0,0 +1,253 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+// operatorSupportedCRMinorVersions is a number of minor versions of custom resources managed by the operator:
+// its own version and the two previous ones.
+const operatorSupportedCRMinorVersions = 3
+
+// ErrOperatorNotInstalled is returned when the operator to upgrade is not installed.
+var ErrOperatorNotInstalled = errors.New("operator is not installed")
+
+// OperatorUpgradeParams contains parameters of an operator upgrade.
+type OperatorUpgradeParams struct {
+	// Namespace of the operator, empty namespace means the default one.
+	Namespace string
+	// Version is a target operator version.
+	Version string
+	// UpdateCRVersion changes crVersion of all clusters to the target version after the upgrade.
+	UpdateCRVersion bool
+}
+
+// OperatorRolloutStatus contains rollout status of the operator deployment.
+type OperatorRolloutStatus struct {
+	Image             string
+	Replicas          int32
+	UpdatedReplicas   int32
+	ReadyReplicas     int32
+	AvailableReplicas int32
+	// Done is true when all operator pods are updated and available.
+	Done    bool
+	Message string
+}
+
+// checkOperatorCRVersions checks that the operator of a given version manages clusters
+// with given crVersions keyed by cluster names.
+func checkOperatorCRVersions(operatorVersion string, crVersions map[string]string) error {
+	target, err := version.NewVersion(operatorVersion)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse operator version %s", operatorVersion)
+	}
+	targetSegments := target.Segments()
+
+	names := make([]string, 0, len(crVersions))
+	for name := range crVersions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// the operator sets its own version for clusters without crVersion
+		if crVersions[name] == "" {
+			continue
+		}
+		crVersion, err := version.NewVersion(crVersions[name])
+		if err != nil {
+			return errors.Wrapf(err, "cannot parse crVersion of cluster %s", name)
+		}
+		segments := crVersion.Segments()
+		switch {
+		case crVersion.GreaterThan(target):
+			return errors.Errorf("cluster %s crVersion %s is newer than operator version %s", name, crVersion, target)
+		case segments[0] != targetSegments[0] || targetSegments[1]-segments[1] >= operatorSupportedCRMinorVersions:
+			return errors.Errorf("cluster %s crVersion %s is not supported by operator version %s", name, crVersion, target)
+		}
+	}
+	return nil
+}
+
+// UpgradeXtraDBOperator upgrades XtraDB operator in a given namespace to a given version.
+// crVersion of existing clusters are checked before the upgrade.
+func (c *K8sClient) UpgradeXtraDBOperator(ctx context.Context, params *OperatorUpgradeParams) error {
+	if _, err := operatorBundle(pxcOperatorBundleDir, params.Version); err != nil {
+		return errors.Wrap(err, "XtraDB")
+	}
+	installed, err := c.isOperatorDeployed(ctx, params.Namespace, pxcOperatorDeploymentName)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return errors.Wrap(ErrOperatorNotInstalled, "XtraDB")
+	}
+
+	var list pxc.PerconaXtraDBClusterList
+	err = c.kube.Get(ctx, params.Namespace, string(perconaXtraDBClusterKind), "", &list)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get Percona XtraDB clusters")
+	}
+	crVersions := make(map[string]string, len(list.Items))
+	for _, cluster := range list.Items {
+		crVersions[cluster.Name] = cluster.Spec.CRVersion
+	}
+	if err = checkOperatorCRVersions(params.Version, crVersions); err != nil {
+		return err
+	}
+
+	if err = c.InstallXtraDBOperator(ctx, params.Namespace, params.Version); err != nil {
+		return err
+	}
+
+	if !params.UpdateCRVersion {
+		return nil
+	}
+	for i := range list.Items {
+		cluster := &list.Items[i]
+		if cluster.Spec.CRVersion == params.Version {
+			continue
+		}
+		cluster.Spec.CRVersion = params.Version
+		if err = c.kube.Apply(ctx, params.Namespace, cluster); err != nil {
+			return errors.Wrapf(err, "cannot update crVersion of cluster %s", cluster.Name)
+		}
+	}
+	return nil
+}
+
+// UpgradePSMDBOperator upgrades PSMDB operator in a given namespace to a given version.
+// crVersion of existing clusters are checked before the upgrade.
+func (c *K8sClient) UpgradePSMDBOperator(ctx context.Context, params *OperatorUpgradeParams) error {
+	if _, err := operatorBundle(psmdbOperatorBundleDir, params.Version); err != nil {
+		return errors.Wrap(err, "PSMDB")
+	}
+	installed, err := c.isOperatorDeployed(ctx, params.Namespace, psmdbOperatorDeploymentName)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return errors.Wrap(ErrOperatorNotInstalled, "PSMDB")
+	}
+
+	var list psmdb.PerconaServerMongoDBList
+	err = c.kube.Get(ctx, params.Namespace, string(perconaServerMongoDBKind), "", &list)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get percona server MongoDB clusters")
+	}
+	crVersions := make(map[string]string, len(list.Items))
+	for _, cluster := range list.Items {
+		crVersions[cluster.Name] = cluster.Spec.CRVersion
+	}
+	if err = checkOperatorCRVersions(params.Version, crVersions); err != nil {
+		return err
+	}
+
+	if err = c.InstallPSMDBOperator(ctx, params.Namespace, params.Version); err != nil {
+		return err
+	}
+
+	if !params.UpdateCRVersion {
+		return nil
+	}
+	for i := range list.Items {
+		cluster := &list.Items[i]
+		if cluster.Spec.CRVersion == params.Version {
+			continue
+		}
+		cluster.Spec.CRVersion = params.Version
+		if err = c.kube.Apply(ctx, params.Namespace, cluster); err != nil {
+			return errors.Wrapf(err, "cannot update crVersion of cluster %s", cluster.Name)
+		}
+	}
+	return nil
+}
+
+// GetXtraDBOperatorRolloutStatus returns rollout status of XtraDB operator deployment.
+func (c *K8sClient) GetXtraDBOperatorRolloutStatus(ctx context.Context, namespace string) (*OperatorRolloutStatus, error) {
+	return c.getOperatorRolloutStatus(ctx, namespace, pxcOperatorDeploymentName)
+}
+
+// GetPSMDBOperatorRolloutStatus returns rollout status of PSMDB operator deployment.
+func (c *K8sClient) GetPSMDBOperatorRolloutStatus(ctx context.Context, namespace string) (*OperatorRolloutStatus, error) {
+	return c.getOperatorRolloutStatus(ctx, namespace, psmdbOperatorDeploymentName)
+}
+
+// getOperatorRolloutStatus returns rollout status of a given operator deployment.
+func (c *K8sClient) getOperatorRolloutStatus(ctx context.Context, namespace, name string) (*OperatorRolloutStatus, error) {
+	var deployment common.Deployment
+	if err := c.kube.Get(ctx, namespace, "deployment", name, &deployment); err != nil {
+		return nil, errors.Wrapf(err, "can't get %s deployment", name)
+	}
+	return deploymentRolloutStatus(&deployment), nil
+}
+
+// deploymentRolloutStatus returns rollout status of a given deployment the same way as `kubectl rollout status` does.
+func deploymentRolloutStatus(deployment *common.Deployment) *OperatorRolloutStatus {
+	res := &OperatorRolloutStatus{
+		Replicas:          deployment.Status.Replicas,
+		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+		ReadyReplicas:     deployment.Status.ReadyReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+	}
+	images := make([]string, len(deployment.Spec.Template.Spec.Containers))
+	for i, container := range deployment.Spec.Template.Spec.Containers {
+		images[i] = container.Image
+	}
+	res.Image = strings.Join(images, ",")
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == "Progressing" && condition.Reason == "ProgressDeadlineExceeded" {
+			res.Message = fmt.Sprintf("Deployment %s exceeded its progress deadline.", deployment.Name)
+			return res
+		}
+	}
+
+	switch {
+	case deployment.Generation > deployment.Status.ObservedGeneration:
+		res.Message = "Waiting for deployment spec update to be observed."
+	case res.UpdatedReplicas < replicas:
+		res.Message = fmt.Sprintf("Waiting for deployment rollout to finish: %d out of %d new replicas have been updated.",
+			res.UpdatedReplicas, replicas)
+	case res.Replicas > res.UpdatedReplicas:
+		res.Message = fmt.Sprintf("Waiting for deployment rollout to finish: %d old replicas are pending termination.",
+			res.Replicas-res.UpdatedReplicas)
+	case res.AvailableReplicas < res.UpdatedReplicas:
+		res.Message = fmt.Sprintf("Waiting for deployment rollout to finish: %d of %d updated replicas are available.",
+			res.AvailableReplicas, res.UpdatedReplicas)
+	default:
+		res.Done = true
+		res.Message = fmt.Sprintf("Deployment %s successfully rolled out.", deployment.Name)
+	}
+	return res
+}
@@ -0,0 +1,73 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+func TestOperatorUpgrade(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CRVersions", func(t *testing.T) {
+		t.Parallel()
+
+		err := checkOperatorCRVersions("1.8.0", map[string]string{"a": "1.6.0", "b": "1.8.0", "c": ""})
+		require.NoError(t, err)
+
+		err = checkOperatorCRVersions("1.8.0", map[string]string{"a": "1.5.0"})
+		assert.EqualError(t, err, "cluster a crVersion 1.5.0 is not supported by operator version 1.8.0")
+
+		err = checkOperatorCRVersions("1.8.0", map[string]string{"a": "1.9.0"})
+		assert.EqualError(t, err, "cluster a crVersion 1.9.0 is newer than operator version 1.8.0")
+	})
+
+	t.Run("RolloutStatus", func(t *testing.T) {
+		t.Parallel()
+
+		deployment := &common.Deployment{
+			ObjectMeta: common.ObjectMeta{Name: "percona-xtradb-cluster-operator", Generation: 2},
+			Spec: common.DeploymentSpec{
+				Replicas: pointer.ToInt32(1),
+				Template: common.PodTemplateSpec{Spec: common.PodSpec{Containers: []common.ContainerSpec{
+					{Name: "percona-xtradb-cluster-operator", Image: "percona/percona-xtradb-cluster-operator:1.8.0"},
+				}}},
+			},
+			Status: common.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, AvailableReplicas: 1},
+		}
+		status := deploymentRolloutStatus(deployment)
+		assert.False(t, status.Done)
+		assert.Equal(t, "percona/percona-xtradb-cluster-operator:1.8.0", status.Image)
+		assert.Equal(t, "Waiting for deployment spec update to be observed.", status.Message)
+
+		deployment.Status = common.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1}
+		status = deploymentRolloutStatus(deployment)
+		assert.False(t, status.Done)
+		assert.Equal(t, "Waiting for deployment rollout to finish: 1 old replicas are pending termination.", status.Message)
+
+		deployment.Status = common.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1}
+		status = deploymentRolloutStatus(deployment)
+		assert.True(t, status.Done)
+		assert.Equal(t, "Deployment percona-xtradb-cluster-operator successfully rolled out.", status.Message)
+	})
+}
@@ -0,0 +1,297 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+const (
+	// Max size of volume for Google Compute Engine persistent disk is 64TiB.
+	maxVolumeSizeGCEPD uint64 = 64 * 1024 * 1024 * 1024 * 1024
+	// Max size of volume for Azure managed disk is 32TiB.
+	maxVolumeSizeAzureDisk uint64 = 32 * 1024 * 1024 * 1024 * 1024
+
+	instanceTypeLabel     = "node.kubernetes.io/instance-type"
+	betaInstanceTypeLabel = "beta.kubernetes.io/instance-type"
+)
+
+// clusterTypeNames contains names of cluster types used in logs.
+var clusterTypeNames = map[kubernetesClusterType]string{ //nolint:gochecknoglobals
+	clusterTypeUnknown:   "unknown",
+	AmazonEKSClusterType: "EKS",
+	MinikubeClusterType:  "minikube",
+	GoogleGKEClusterType: "GKE",
+	AzureAKSClusterType:  "AKS",
+	OpenShiftClusterType: "OpenShift",
+	K3sClusterType:       "k3s",
+	KindClusterType:      "kind",
+	GenericClusterType:   "generic",
+}
+
+// openShiftAPIGroups are API groups served by OpenShift only.
+var openShiftAPIGroups = []string{"config.openshift.io/", "route.openshift.io/"} //nolint:gochecknoglobals
+
+// nodeLabelClusterTypes maps node labels set by platforms to cluster types.
+var nodeLabelClusterTypes = []struct { //nolint:gochecknoglobals
+	label       string
+	clusterType kubernetesClusterType
+}{
+	{"minikube.k8s.io/name", MinikubeClusterType},
+	{"eks.amazonaws.com/nodegroup", AmazonEKSClusterType},
+	{"cloud.google.com/gke-nodepool", GoogleGKEClusterType},
+	{"kubernetes.azure.com/cluster", AzureAKSClusterType},
+}
+
+// providerIDClusterTypes maps node provider ID schemes to cluster types.
+// Self-managed clusters on a cloud are handled as managed ones, they use the same block storage.
+var providerIDClusterTypes = map[string]kubernetesClusterType{ //nolint:gochecknoglobals
+	"aws":   AmazonEKSClusterType,
+	"gce":   GoogleGKEClusterType,
+	"azure": AzureAKSClusterType,
+	"k3s":   K3sClusterType,
+	"kind":  KindClusterType,
+}
+
+// provisionerClusterTypes maps substrings of storage class provisioners to cluster types.
+var provisionerClusterTypes = []struct { //nolint:gochecknoglobals
+	provisioner string
+	clusterType kubernetesClusterType
+}{
+	{"aws", AmazonEKSClusterType},
+	{"minikube", MinikubeClusterType},
+	{"gce-pd", GoogleGKEClusterType},
+	{"pd.csi.storage.gke.io", GoogleGKEClusterType},
+	{"azure", AzureAKSClusterType},
+}
+
+// String returns a name of the cluster type.
+func (t kubernetesClusterType) String() string {
+	if name, ok := clusterTypeNames[t]; ok {
+		return name
+	}
+	return clusterTypeNames[clusterTypeUnknown]
+}
+
+// getNodes returns all nodes of Kubernetes cluster.
+func (c *K8sClient) getNodes(ctx context.Context) ([]common.Node, error) {
+	nodes := new(common.NodeList)
+	out, err := c.kube.Run(ctx, []string{"get", "nodes", "-ojson"}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get nodes of Kubernetes cluster")
+	}
+	err = json.Unmarshal(out, nodes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get nodes of Kubernetes cluster")
+	}
+	return nodes.Items, nil
+}
+
+// GetKubernetesClusterType returns k8s cluster type based on API groups, node labels and provider IDs,
+// and storage class provisioners, in that order. Clusters of other platforms are generic ones.
+// Unknown type is returned if none of them can be read.
+func (c *K8sClient) GetKubernetesClusterType(ctx context.Context) kubernetesClusterType {
+	var known bool
+	output, err := c.kube.Run(ctx, []string{"api-versions"}, "")
+	if err != nil {
+		c.l.Warnf("Cannot get API versions to detect cluster type: %s.", err)
+	} else {
+		known = true
+		for _, version := range strings.Split(string(output), "\n") {
+			for _, group := range openShiftAPIGroups {
+				if strings.HasPrefix(version, group) {
+					return OpenShiftClusterType
+				}
+			}
+		}
+	}
+
+	nodes, err := c.getNodes(ctx)
+	if err != nil {
+		c.l.Warnf("Cannot get nodes to detect cluster type: %s.", err)
+	} else {
+		known = true
+		if clusterType := nodesClusterType(nodes); clusterType != clusterTypeUnknown {
+			return clusterType
+		}
+	}
+
+	classes, err := c.ListStorageClasses(ctx)
+	if err != nil {
+		c.l.Warnf("Cannot get storage classes to detect cluster type: %s.", err)
+	} else {
+		known = true
+		for _, class := range classes {
+			for _, p := range provisionerClusterTypes {
+				if strings.Contains(class.Provisioner, p.provisioner) {
+					return p.clusterType
+				}
+			}
+		}
+	}
+
+	if !known {
+		c.l.Error("Failed to get k8s cluster type.")
+		return clusterTypeUnknown
+	}
+	return GenericClusterType
+}
+
+// nodesClusterType returns cluster type based on labels and provider IDs of given nodes.
+func nodesClusterType(nodes []common.Node) kubernetesClusterType {
+	for _, node := range nodes {
+		for _, l := range nodeLabelClusterTypes {
+			if _, ok := node.Labels[l.label]; ok {
+				return l.clusterType
+			}
+		}
+	}
+	for _, node := range nodes {
+		if clusterType := providerIDClusterType(node.Spec.ProviderID); clusterType != clusterTypeUnknown {
+			return clusterType
+		}
+	}
+	return clusterTypeUnknown
+}
+
+// providerIDClusterType returns cluster type based on a scheme of a given node provider ID.
+func providerIDClusterType(providerID string) kubernetesClusterType {
+	i := strings.Index(providerID, "://")
+	if i < 0 {
+		return clusterTypeUnknown
+	}
+	return providerIDClusterTypes[providerID[:i]]
+}
+
+// diskStrategyType is a way to compute disk size of a cluster.
+type diskStrategyType uint8
+
+const (
+	// diskStrategyNone means that disk size is not known.
+	diskStrategyNone diskStrategyType = iota
+	// diskStrategyNodeStorage sums storage of nodes, it is used by local volumes provisioners.
+	diskStrategyNodeStorage
+	// diskStrategyVolumeLimits multiplies a number of volumes that can be attached to nodes
+	// by max volume size, it is used by cloud block storage.
+	diskStrategyVolumeLimits
+)
+
+// volumeLimits describes limits of cloud block storage volumes attached to nodes.
+type volumeLimits struct {
+	// allocatable is a node resource with a number of attachable volumes reported by the cloud provider.
+	allocatable common.ResourceName
+	// defaultLimit returns a number of attachable volumes of a node without allocatable resource.
+	defaultLimit  func(node common.Node) (uint64, error)
+	maxVolumeSize uint64
+}
+
+// nodeLimit returns a number of volumes that can be attached to a given node.
+func (l *volumeLimits) nodeLimit(node common.Node) (uint64, error) {
+	if value, ok := node.Status.Allocatable[l.allocatable]; ok {
+		if limit, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return limit, nil
+		}
+	}
+	return l.defaultLimit(node)
+}
+
+// cloudVolumeLimits contains volume limits of cloud platforms.
+var cloudVolumeLimits = map[kubernetesClusterType]*volumeLimits{ //nolint:gochecknoglobals
+	AmazonEKSClusterType: {
+		allocatable:   "attachable-volumes-aws-ebs",
+		defaultLimit:  eksVolumeLimit,
+		maxVolumeSize: maxVolumeSizeEBS,
+	},
+	GoogleGKEClusterType: {
+		allocatable:   "attachable-volumes-gce-pd",
+		defaultLimit:  gkeVolumeLimit,
+		maxVolumeSize: maxVolumeSizeGCEPD,
+	},
+	AzureAKSClusterType: {
+		allocatable: "attachable-volumes-azure-disk",
+		// the smallest VM sizes support 4 data disks
+		defaultLimit:  func(common.Node) (uint64, error) { return 4, nil },
+		maxVolumeSize: maxVolumeSizeAzureDisk,
+	},
+}
+
+// diskStrategy returns a strategy of computing disk size for a given cluster type.
+// Strategy of OpenShift depends on its infrastructure detected by given nodes.
+func diskStrategy(clusterType kubernetesClusterType, nodes []common.Node) (diskStrategyType, *volumeLimits) {
+	if clusterType == OpenShiftClusterType {
+		clusterType = GenericClusterType
+		for _, node := range nodes {
+			if infra := providerIDClusterType(node.Spec.ProviderID); cloudVolumeLimits[infra] != nil {
+				clusterType = infra
+				break
+			}
+		}
+	}
+
+	switch clusterType {
+	case AmazonEKSClusterType, GoogleGKEClusterType, AzureAKSClusterType:
+		return diskStrategyVolumeLimits, cloudVolumeLimits[clusterType]
+	case MinikubeClusterType, K3sClusterType, KindClusterType, GenericClusterType:
+		return diskStrategyNodeStorage, nil
+	default:
+		return diskStrategyNone, nil
+	}
+}
+
+// eksVolumeLimit returns a number of EBS volumes that can be attached to a given node based on its instance type.
+func eksVolumeLimit(node common.Node) (uint64, error) {
+	nodeType, ok := node.Labels[betaInstanceTypeLabel]
+	if !ok {
+		return 0, errors.New("dealing with AWS EKS cluster but the node does not have label 'beta.kubernetes.io/instance-type'")
+	}
+	// 39 is a default limit for EKS cluster nodes ...
+	var volumeLimitPerNode uint64 = 39
+	typeAndSize := strings.Split(strings.ToLower(nodeType), ".")
+	if len(typeAndSize) < 2 {
+		return 0, errors.Errorf("failed to parse EKS node type '%s', it's not in expected format 'type.size'", nodeType)
+	}
+	// ... however, if the node type is one of M5, C5, R5, T3, Z1D it's 25.
+	limitedVolumesSet := map[string]struct{}{
+		"m5": {}, "c5": {}, "r5": {}, "t3": {}, "t1d": {},
+	}
+	if _, ok := limitedVolumesSet[typeAndSize[0]]; ok {
+		volumeLimitPerNode = 25
+	}
+	return volumeLimitPerNode, nil
+}
+
+// gkeVolumeLimit returns a number of persistent disks that can be attached to a given node based on its machine type.
+func gkeVolumeLimit(node common.Node) (uint64, error) {
+	machineType, ok := node.Labels[instanceTypeLabel]
+	if !ok {
+		machineType = node.Labels[betaInstanceTypeLabel]
+	}
+	// shared-core machine types support 16 disks, others support 128 including the boot disk
+	switch machineType {
+	case "e2-micro", "e2-small", "e2-medium", "f1-micro", "g1-small":
+		return 15, nil
+	default:
+		return 127, nil
+	}
+}
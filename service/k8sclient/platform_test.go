@@ -0,0 +1,181 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakePlatformClient returns given API versions in addition to objects and outputs of fakeDetailsClient.
+type fakePlatformClient struct {
+	fakeDetailsClient
+	apiVersions string
+}
+
+func (f *fakePlatformClient) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
+	if args[0] == "api-versions" {
+		return []byte(f.apiVersions), nil
+	}
+	return f.fakeDetailsClient.Run(ctx, args, stdin)
+}
+
+func TestGetKubernetesClusterType(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	const apiVersions = "apps/v1\nstorage.k8s.io/v1\nv1\n"
+
+	for _, tt := range []struct {
+		name        string
+		apiVersions string
+		nodes       string
+		classes     string
+		expected    kubernetesClusterType
+	}{{
+		name:        "OpenShift",
+		apiVersions: apiVersions + "config.openshift.io/v1\nroute.openshift.io/v1\n",
+		nodes:       `{"items": [{"spec": {"providerID": "aws:///us-east-1a/i-1"}}]}`,
+		expected:    OpenShiftClusterType,
+	}, {
+		name:        "GKE by label",
+		apiVersions: apiVersions,
+		nodes:       `{"items": [{"metadata": {"labels": {"cloud.google.com/gke-nodepool": "default-pool"}}}]}`,
+		expected:    GoogleGKEClusterType,
+	}, {
+		name:        "AKS by label",
+		apiVersions: apiVersions,
+		nodes:       `{"items": [{"metadata": {"labels": {"kubernetes.azure.com/cluster": "MC_test"}}}]}`,
+		expected:    AzureAKSClusterType,
+	}, {
+		name:        "EKS by provider ID",
+		apiVersions: apiVersions,
+		nodes:       `{"items": [{"spec": {"providerID": "aws:///us-east-1a/i-1"}}]}`,
+		expected:    AmazonEKSClusterType,
+	}, {
+		name:        "kind by provider ID",
+		apiVersions: apiVersions,
+		nodes:       `{"items": [{"spec": {"providerID": "kind://docker/kind/kind-control-plane"}}]}`,
+		expected:    KindClusterType,
+	}, {
+		name:        "minikube by provisioner",
+		apiVersions: apiVersions,
+		nodes:       `{"items": [{"metadata": {"name": "node"}}]}`,
+		classes:     `{"items": [{"metadata": {"name": "standard"}, "provisioner": "k8s.io/minikube-hostpath"}]}`,
+		expected:    MinikubeClusterType,
+	}, {
+		name:        "generic",
+		apiVersions: apiVersions,
+		nodes:       `{"items": [{"metadata": {"name": "node"}}]}`,
+		classes:     `{"items": [{"metadata": {"name": "local"}, "provisioner": "rancher.io/local-path"}]}`,
+		expected:    GenericClusterType,
+	}} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			kube := &fakePlatformClient{
+				fakeDetailsClient: fakeDetailsClient{
+					objects: map[string]string{"storageclass/": tt.classes},
+					outputs: map[string]string{"nodes": tt.nodes},
+				},
+				apiVersions: tt.apiVersions,
+			}
+			if tt.classes == "" {
+				kube.objects = nil
+			}
+			client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+			assert.Equal(t, tt.expected, client.GetKubernetesClusterType(ctx))
+		})
+	}
+}
+
+func TestDiskStrategy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Clouds", func(t *testing.T) {
+		t.Parallel()
+
+		for clusterType, allocatable := range map[kubernetesClusterType]common.ResourceName{
+			AmazonEKSClusterType: "attachable-volumes-aws-ebs",
+			GoogleGKEClusterType: "attachable-volumes-gce-pd",
+			AzureAKSClusterType:  "attachable-volumes-azure-disk",
+		} {
+			strategy, limits := diskStrategy(clusterType, nil)
+			assert.Equal(t, diskStrategyVolumeLimits, strategy, clusterType.String())
+			require.NotNil(t, limits)
+			assert.Equal(t, allocatable, limits.allocatable)
+		}
+	})
+
+	t.Run("NodeStorage", func(t *testing.T) {
+		t.Parallel()
+
+		for _, clusterType := range []kubernetesClusterType{MinikubeClusterType, K3sClusterType, KindClusterType, GenericClusterType} {
+			strategy, limits := diskStrategy(clusterType, nil)
+			assert.Equal(t, diskStrategyNodeStorage, strategy, clusterType.String())
+			assert.Nil(t, limits)
+		}
+
+		strategy, _ := diskStrategy(clusterTypeUnknown, nil)
+		assert.Equal(t, diskStrategyNone, strategy)
+	})
+
+	t.Run("OpenShift", func(t *testing.T) {
+		t.Parallel()
+
+		strategy, limits := diskStrategy(OpenShiftClusterType, []common.Node{{Spec: common.NodeSpec{ProviderID: "gce://project/zone/node"}}})
+		assert.Equal(t, diskStrategyVolumeLimits, strategy)
+		assert.Equal(t, cloudVolumeLimits[GoogleGKEClusterType], limits)
+
+		strategy, limits = diskStrategy(OpenShiftClusterType, []common.Node{{Spec: common.NodeSpec{ProviderID: "baremetalhost:///node"}}})
+		assert.Equal(t, diskStrategyNodeStorage, strategy)
+		assert.Nil(t, limits)
+	})
+}
+
+func TestVolumeLimits(t *testing.T) {
+	t.Parallel()
+
+	node := func(labels map[string]string, allocatable common.ResourceList) common.Node {
+		var n common.Node
+		n.Labels = labels
+		n.Status.Allocatable = allocatable
+		return n
+	}
+
+	gke := cloudVolumeLimits[GoogleGKEClusterType]
+	limit, err := gke.nodeLimit(node(nil, common.ResourceList{"attachable-volumes-gce-pd": "127"}))
+	require.NoError(t, err)
+	assert.EqualValues(t, 127, limit)
+	limit, err = gke.nodeLimit(node(map[string]string{instanceTypeLabel: "e2-small"}, nil))
+	require.NoError(t, err)
+	assert.EqualValues(t, 15, limit)
+
+	eks := cloudVolumeLimits[AmazonEKSClusterType]
+	limit, err = eks.nodeLimit(node(map[string]string{betaInstanceTypeLabel: "m5.large"}, nil))
+	require.NoError(t, err)
+	assert.EqualValues(t, 25, limit)
+	_, err = eks.nodeLimit(node(nil, nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,322 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+const (
+	prometheusNamespace = "dbaas_controller"
+	prometheusSubsystem = "k8sclient_pool"
+
+	// DefaultPoolSize is a default maximum number of cached connections.
+	DefaultPoolSize = 32
+	// DefaultPoolIdleTimeout is a default time after which unused connection is closed.
+	DefaultPoolIdleTimeout = 10 * time.Minute
+)
+
+// DefaultPool is used by New. It is replaced once on startup.
+var DefaultPool = NewPool(DefaultPoolSize, DefaultPoolIdleTimeout) //nolint:gochecknoglobals
+
+// poolEntry is a cached Kubernetes client shared by K8sClient objects.
+type poolEntry struct {
+	key      string
+	kube     kubeClient
+	refs     int
+	lastUsed time.Time
+	evicted  bool
+}
+
+// Pool caches initialized Kubernetes clients keyed by backend and kubeconfig hash,
+// so handlers could call New and Cleanup on every request without paying
+// for kubectl version detection, kubeconfig files and API discovery every time.
+type Pool struct {
+	size        int
+	idleTimeout time.Duration
+	newClient   func(ctx context.Context, kubeconfig string, backend Backend) (kubeClient, error)
+
+	m       sync.Mutex
+	entries map[string]*poolEntry
+
+	mHits      prometheus.Counter
+	mMisses    prometheus.Counter
+	mEvictions *prometheus.CounterVec
+	mSize      prometheus.GaugeFunc
+}
+
+// NewPool creates a new Pool with a given maximum number of cached clients
+// and a time after which unused client is closed.
+func NewPool(size int, idleTimeout time.Duration) *Pool {
+	p := &Pool{
+		size:        size,
+		idleTimeout: idleTimeout,
+		newClient:   newKubeClient,
+		entries:     make(map[string]*poolEntry),
+
+		mHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "hits_total",
+			Help:      "A total number of requests served by cached Kubernetes client.",
+		}),
+		mMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "misses_total",
+			Help:      "A total number of requests that required a new Kubernetes client.",
+		}),
+		mEvictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "evictions_total",
+			Help:      "A total number of closed cached Kubernetes clients.",
+		}, []string{"reason"}),
+	}
+	p.mSize = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: prometheusSubsystem,
+		Name:      "size",
+		Help:      "A current number of cached Kubernetes clients.",
+	}, func() float64 {
+		return float64(p.Len())
+	})
+
+	// initialize metrics with labels
+	p.mEvictions.WithLabelValues("idle")
+	p.mEvictions.WithLabelValues("size")
+	p.mEvictions.WithLabelValues("shutdown")
+
+	return p
+}
+
+// Get returns K8sClient that uses cached Kubernetes client for given kubeconfig and backend,
+// creating it if needed. Cleanup of returned object returns client to the pool.
+func (p *Pool) Get(ctx context.Context, kubeconfig string, backend Backend) (*K8sClient, error) {
+	l := logger.Get(ctx)
+	l = l.WithField("component", "K8sClient")
+
+	key := poolKey(kubeconfig, backend)
+	if e := p.acquire(key); e != nil {
+		p.mHits.Inc()
+		return &K8sClient{
+			kube:    e.kube,
+			l:       l,
+			release: p.releaseFunc(e),
+		}, nil
+	}
+
+	p.mMisses.Inc()
+	kube, err := p.newClient(ctx, kubeconfig, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	e := p.add(ctx, key, kube)
+	if e == nil {
+		// The pool is full and all cached clients are in use, do not cache that one.
+		return &K8sClient{
+			kube: kube,
+			l:    l,
+		}, nil
+	}
+	return &K8sClient{
+		kube:    e.kube,
+		l:       l,
+		release: p.releaseFunc(e),
+	}, nil
+}
+
+// Len returns a number of cached clients.
+func (p *Pool) Len() int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return len(p.entries)
+}
+
+// Run evicts idle clients until ctx is canceled, then closes all cached clients.
+func (p *Pool) Run(ctx context.Context) {
+	l := logger.Get(ctx).WithField("component", "K8sClientPool")
+
+	interval := p.idleTimeout / 2
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.closeAll(l)
+			return
+		case <-ticker.C:
+			p.evictIdle(l, time.Now())
+		}
+	}
+}
+
+// acquire returns cached entry for a given key with incremented reference counter, or nil.
+func (p *Pool) acquire(key string) *poolEntry {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	e := p.entries[key]
+	if e == nil {
+		return nil
+	}
+	e.refs++
+	e.lastUsed = time.Now()
+	return e
+}
+
+// add adds a new client to the pool and returns its entry. If there is a client
+// for the same key already (created by a concurrent request), the new one is closed
+// and existing entry is returned. It returns nil if the pool is full.
+func (p *Pool) add(ctx context.Context, key string, kube kubeClient) *poolEntry {
+	l := logger.Get(ctx).WithField("component", "K8sClientPool")
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if e := p.entries[key]; e != nil {
+		if err := kube.Cleanup(); err != nil {
+			l.Warnf("Failed to cleanup Kubernetes client: %s.", err)
+		}
+		e.refs++
+		e.lastUsed = time.Now()
+		return e
+	}
+
+	if p.size > 0 && len(p.entries) >= p.size {
+		// evict the least recently used client that is not in use
+		var lru *poolEntry
+		for _, e := range p.entries {
+			if e.refs == 0 && (lru == nil || e.lastUsed.Before(lru.lastUsed)) {
+				lru = e
+			}
+		}
+		if lru == nil {
+			return nil
+		}
+		p.evict(l, lru, "size")
+	}
+
+	e := &poolEntry{
+		key:      key,
+		kube:     kube,
+		refs:     1,
+		lastUsed: time.Now(),
+	}
+	p.entries[key] = e
+	return e
+}
+
+// releaseFunc returns function that releases a given entry once.
+func (p *Pool) releaseFunc(e *poolEntry) func() error {
+	var once sync.Once
+	return func() error {
+		once.Do(func() { p.release(e) })
+		return nil
+	}
+}
+
+// release decrements reference counter of a given entry.
+func (p *Pool) release(e *poolEntry) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	e.refs--
+	e.lastUsed = time.Now()
+	if e.evicted && e.refs == 0 {
+		if err := e.kube.Cleanup(); err != nil {
+			logger.Get(context.Background()).Warnf("Failed to cleanup Kubernetes client: %s.", err)
+		}
+	}
+}
+
+// evictIdle closes clients that are not in use longer than idle timeout.
+func (p *Pool) evictIdle(l logger.Logger, now time.Time) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for _, e := range p.entries {
+		if e.refs == 0 && now.Sub(e.lastUsed) >= p.idleTimeout {
+			p.evict(l, e, "idle")
+		}
+	}
+}
+
+// closeAll removes all clients from the pool.
+func (p *Pool) closeAll(l logger.Logger) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for _, e := range p.entries {
+		p.evict(l, e, "shutdown")
+	}
+}
+
+// evict removes entry from the pool. Caller should hold the lock.
+// Clients that are still in use are closed on release.
+func (p *Pool) evict(l logger.Logger, e *poolEntry, reason string) {
+	delete(p.entries, e.key)
+	e.evicted = true
+	p.mEvictions.WithLabelValues(reason).Inc()
+	if e.refs > 0 {
+		return
+	}
+	if err := e.kube.Cleanup(); err != nil {
+		l.Warnf("Failed to cleanup Kubernetes client: %s.", err)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (p *Pool) Describe(ch chan<- *prometheus.Desc) {
+	p.mHits.Describe(ch)
+	p.mMisses.Describe(ch)
+	p.mEvictions.Describe(ch)
+	p.mSize.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (p *Pool) Collect(ch chan<- prometheus.Metric) {
+	p.mHits.Collect(ch)
+	p.mMisses.Collect(ch)
+	p.mEvictions.Collect(ch)
+	p.mSize.Collect(ch)
+}
+
+// poolKey returns cache key for given kubeconfig and backend. Kubeconfig contains
+// credentials, so only its hash is kept in memory as a key.
+func poolKey(kubeconfig string, backend Backend) string {
+	h := sha256.Sum256([]byte(kubeconfig))
+	return string(backend) + ":" + hex.EncodeToString(h[:])
+}
+
+// check interfaces
+var (
+	_ prometheus.Collector = (*Pool)(nil)
+)
@@ -0,0 +1,146 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakeKubeClient counts Cleanup calls.
+type fakeKubeClient struct {
+	kubeClient
+	kubeconfig string
+	cleanups   int
+}
+
+func (f *fakeKubeClient) Cleanup() error {
+	f.cleanups++
+	return nil
+}
+
+func newTestPool(size int, idleTimeout time.Duration) (*Pool, map[string]*fakeKubeClient) {
+	created := make(map[string]*fakeKubeClient)
+	p := NewPool(size, idleTimeout)
+	p.newClient = func(ctx context.Context, kubeconfig string, backend Backend) (kubeClient, error) {
+		f := &fakeKubeClient{kubeconfig: kubeconfig}
+		created[kubeconfig] = f
+		return f, nil
+	}
+	return p, created
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Reuse", func(t *testing.T) {
+		t.Parallel()
+		p, created := newTestPool(2, time.Minute)
+
+		c1, err := p.Get(ctx, "config1", BackendNative)
+		require.NoError(t, err)
+		c2, err := p.Get(ctx, "config1", BackendNative)
+		require.NoError(t, err)
+		assert.Same(t, c1.kube, c2.kube)
+		assert.Equal(t, 1, p.Len())
+
+		c3, err := p.Get(ctx, "config1", BackendKubectl)
+		require.NoError(t, err)
+		assert.NotSame(t, c1.kube, c3.kube)
+		assert.Equal(t, 2, p.Len())
+
+		require.NoError(t, c1.Cleanup())
+		require.NoError(t, c1.Cleanup())
+		require.NoError(t, c2.Cleanup())
+		require.NoError(t, c3.Cleanup())
+		assert.Equal(t, 0, created["config1"].cleanups)
+		assert.Equal(t, 2, p.Len())
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(p.mHits))
+		assert.Equal(t, float64(2), testutil.ToFloat64(p.mMisses))
+	})
+
+	t.Run("SizeBound", func(t *testing.T) {
+		t.Parallel()
+		p, created := newTestPool(1, time.Minute)
+
+		c1, err := p.Get(ctx, "config1", BackendNative)
+		require.NoError(t, err)
+
+		// the only cached client is in use, so a new one is not cached
+		c2, err := p.Get(ctx, "config2", BackendNative)
+		require.NoError(t, err)
+		assert.Equal(t, 1, p.Len())
+		require.NoError(t, c2.Cleanup())
+		assert.Equal(t, 1, created["config2"].cleanups)
+
+		// released client is evicted for a new one
+		require.NoError(t, c1.Cleanup())
+		c3, err := p.Get(ctx, "config3", BackendNative)
+		require.NoError(t, err)
+		assert.Equal(t, 1, created["config1"].cleanups)
+		assert.Equal(t, 1, p.Len())
+		require.NoError(t, c3.Cleanup())
+		assert.Equal(t, float64(1), testutil.ToFloat64(p.mEvictions.WithLabelValues("size")))
+	})
+
+	t.Run("IdleEviction", func(t *testing.T) {
+		t.Parallel()
+		p, created := newTestPool(2, time.Minute)
+		l := logger.Get(ctx)
+
+		c1, err := p.Get(ctx, "config1", BackendNative)
+		require.NoError(t, err)
+
+		// clients in use are not evicted
+		p.evictIdle(l, time.Now().Add(time.Hour))
+		assert.Equal(t, 1, p.Len())
+
+		require.NoError(t, c1.Cleanup())
+		p.evictIdle(l, time.Now().Add(time.Second))
+		assert.Equal(t, 1, p.Len())
+		p.evictIdle(l, time.Now().Add(time.Hour))
+		assert.Equal(t, 0, p.Len())
+		assert.Equal(t, 1, created["config1"].cleanups)
+	})
+
+	t.Run("Shutdown", func(t *testing.T) {
+		t.Parallel()
+		p, created := newTestPool(2, time.Minute)
+
+		c1, err := p.Get(ctx, "config1", BackendNative)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		p.Run(ctx)
+		assert.Equal(t, 0, p.Len())
+
+		// client in use is closed on release
+		assert.Equal(t, 0, created["config1"].cleanups)
+		require.NoError(t, c1.Cleanup())
+		assert.Equal(t, 1, created["config1"].cleanups)
+	})
+}
@@ -0,0 +1,429 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+)
+
+const (
+	perconaServerMongoDBBackupKind  = "PerconaServerMongoDBBackup"
+	perconaServerMongoDBRestoreKind = "PerconaServerMongoDBRestore"
+	psmdbBackupAPIVersion           = "psmdb.percona.com/v1"
+)
+
+// psmdbBackupStatesMap matches psmdb backup states to backup states.
+var psmdbBackupStatesMap = map[psmdb.BackupState]BackupState{ //nolint:gochecknoglobals
+	psmdb.BackupStateNew:       BackupStateRunning,
+	psmdb.BackupStateWaiting:   BackupStateRunning,
+	psmdb.BackupStateRequested: BackupStateRunning,
+	psmdb.BackupStateRunning:   BackupStateRunning,
+	psmdb.BackupStateRejected:  BackupStateFailed,
+	psmdb.BackupStateError:     BackupStateFailed,
+	psmdb.BackupStateReady:     BackupStateSucceeded,
+}
+
+// psmdbRestoreStatesMap matches psmdb restore states to restore states.
+var psmdbRestoreStatesMap = map[psmdb.RestoreState]RestoreState{ //nolint:gochecknoglobals
+	psmdb.RestoreStateNew:       RestoreStateRunning,
+	psmdb.RestoreStateWaiting:   RestoreStateRunning,
+	psmdb.RestoreStateRequested: RestoreStateRunning,
+	psmdb.RestoreStateRunning:   RestoreStateRunning,
+	psmdb.RestoreStateRejected:  RestoreStateFailed,
+	psmdb.RestoreStateError:     RestoreStateFailed,
+	psmdb.RestoreStateReady:     RestoreStateSucceeded,
+}
+
+// psmdbCompressionTypes contains compression types supported by PSMDB backups.
+var psmdbCompressionTypes = map[string]psmdb.CompressionType{ //nolint:gochecknoglobals
+	"":       "",
+	"none":   psmdb.CompressionTypeNone,
+	"gzip":   psmdb.CompressionTypeGZIP,
+	"pgzip":  psmdb.CompressionTypePGZIP,
+	"snappy": psmdb.CompressionTypeSNAPPY,
+	"lz4":    psmdb.CompressionTypeLZ4,
+	"s2":     psmdb.CompressionTypeS2,
+}
+
+// PSMDBBackupTask describes scheduled backups of PSMDB cluster.
+type PSMDBBackupTask struct {
+	Name string
+	// Schedule in cron format, for example "0 0 * * *".
+	Schedule string
+	// Keep is a number of the latest backups to keep, zero means all backups are kept.
+	Keep int
+	// StorageName is a name of backup storage defined in the cluster, empty means the default one.
+	StorageName string
+	// CompressionType is one of none, gzip, pgzip, snappy, lz4 or s2, empty means the operator default.
+	CompressionType string
+}
+
+// PSMDBBackupParams contains all parameters required to create PSMDB cluster backup.
+type PSMDBBackupParams struct {
+	// Namespace of the cluster, empty namespace means the default one.
+	Namespace   string
+	ClusterName string
+	Name        string
+	// StorageName is a name of backup storage defined in the cluster, empty means the default one.
+	StorageName string
+}
+
+// PSMDBClusterBackup contains information related to PSMDB cluster backup.
+type PSMDBClusterBackup struct {
+	Name        string
+	ClusterName string
+	StorageName string
+	Destination string
+	State       BackupState
+	// Message contains an error message of failed backup.
+	Message   string
+	StartTime time.Time
+	// FinishTime is zero if backup is not finished.
+	FinishTime time.Time
+}
+
+// PSMDBRestoreParams contains all parameters required to restore PSMDB cluster from a backup.
+type PSMDBRestoreParams struct {
+	// Namespace of the backup and the cluster, empty namespace means the default one.
+	Namespace string
+	// Name of the restore, it is generated if empty.
+	Name        string
+	BackupName  string
+	ClusterName string
+	// NewCluster contains parameters of a cluster to create and restore the backup into.
+	// Backup is restored into existing cluster ClusterName if it is nil.
+	NewCluster *PSMDBParams
+}
+
+// PSMDBClusterRestore contains information related to PSMDB cluster restore.
+type PSMDBClusterRestore struct {
+	Name        string
+	ClusterName string
+	BackupName  string
+	State       RestoreState
+	// Message contains the operator state of the restore and an error message if any.
+	Message   string
+	StartTime time.Time
+	// FinishTime is zero if restore is not finished.
+	FinishTime time.Time
+}
+
+// psmdbBackupTasks converts backup tasks to the operator ones checking that used storages are defined.
+func psmdbBackupTasks(clusterName string, tasks []PSMDBBackupTask, storages map[string]psmdb.BackupStorageSpec) ([]psmdb.BackupTaskSpec, error) {
+	res := make([]psmdb.BackupTaskSpec, len(tasks))
+	for i, task := range tasks {
+		if task.Name == "" || task.Schedule == "" {
+			return nil, errors.New("backup task name and schedule should be set")
+		}
+		if task.Keep < 0 {
+			return nil, errors.New("number of backups to keep should not be negative")
+		}
+		compressionType, ok := psmdbCompressionTypes[task.CompressionType]
+		if !ok {
+			return nil, errors.Errorf("unsupported compression type %q", task.CompressionType)
+		}
+		storageName, err := psmdbBackupStorageNameFor(clusterName, storages, task.StorageName)
+		if err != nil {
+			return nil, err
+		}
+
+		res[i] = psmdb.BackupTaskSpec{
+			Name:            task.Name,
+			Enabled:         true,
+			Keep:            task.Keep,
+			Schedule:        task.Schedule,
+			StorageName:     storageName,
+			CompressionType: compressionType,
+		}
+	}
+	return res, nil
+}
+
+// psmdbBackupStorageNameFor checks that given storage is defined in the cluster.
+// Empty name means the storage created with the cluster, or the only storage defined in the cluster.
+func psmdbBackupStorageNameFor(clusterName string, storages map[string]psmdb.BackupStorageSpec, name string) (string, error) {
+	if len(storages) == 0 {
+		return "", errors.Errorf("PSMDB cluster %s has no backup storages", clusterName)
+	}
+
+	if name == "" {
+		name = fmt.Sprintf(psmdbBackupStorageName, clusterName)
+		if _, ok := storages[name]; ok {
+			return name, nil
+		}
+		if len(storages) > 1 {
+			return "", errors.Errorf("PSMDB cluster %s has several backup storages, storage name should be set", clusterName)
+		}
+		for name = range storages {
+			return name, nil
+		}
+	}
+
+	if _, ok := storages[name]; !ok {
+		return "", errors.Errorf("PSMDB cluster %s has no backup storage %s", clusterName, name)
+	}
+	return name, nil
+}
+
+// ListPSMDBClusterBackups returns backups of PSMDB clusters in a given namespace sorted by start time.
+// Empty clusterName means backups of all clusters.
+func (c *K8sClient) ListPSMDBClusterBackups(ctx context.Context, namespace, clusterName string) ([]PSMDBClusterBackup, error) {
+	var list psmdb.PerconaServerMongoDBBackupList
+	err := c.kube.Get(ctx, namespace, perconaServerMongoDBBackupKind, "", &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get PSMDB cluster backups")
+	}
+
+	res := make([]PSMDBClusterBackup, 0, len(list.Items))
+	for _, backup := range list.Items {
+		if clusterName != "" && backup.Spec.PSMDBCluster != clusterName {
+			continue
+		}
+
+		val := PSMDBClusterBackup{
+			Name:        backup.Name,
+			ClusterName: backup.Spec.PSMDBCluster,
+			StorageName: backup.Spec.StorageName,
+			Destination: backup.Status.Destination,
+			State:       getPSMDBBackupState(backup.Status.State),
+			Message:     backup.Status.Error,
+		}
+		if backup.CreationTimestamp != nil {
+			val.StartTime = *backup.CreationTimestamp
+		}
+		if backup.Status.CompletedAt != nil {
+			val.FinishTime = *backup.Status.CompletedAt
+		}
+		res = append(res, val)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].StartTime.Before(res[j].StartTime)
+	})
+	return res, nil
+}
+
+func getPSMDBBackupState(state psmdb.BackupState) BackupState {
+	backupState, ok := psmdbBackupStatesMap[state]
+	if !ok {
+		return BackupStateInvalid
+	}
+	return backupState
+}
+
+// CreatePSMDBClusterBackup makes a new on-demand backup of PSMDB cluster.
+func (c *K8sClient) CreatePSMDBClusterBackup(ctx context.Context, params *PSMDBBackupParams) error {
+	var cluster psmdb.PerconaServerMongoDB
+	err := c.kube.Get(ctx, params.Namespace, string(perconaServerMongoDBKind), params.ClusterName, &cluster)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get PSMDB cluster %s", params.ClusterName)
+	}
+
+	storageName, err := psmdbBackupStorageNameFor(cluster.Name, cluster.Spec.Backup.Storages, params.StorageName)
+	if err != nil {
+		return err
+	}
+
+	var backup psmdb.PerconaServerMongoDBBackup
+	err = c.kube.Get(ctx, params.Namespace, perconaServerMongoDBBackupKind, params.Name, &backup)
+	if err == nil {
+		return errors.Errorf("backup %s already exists", params.Name)
+	}
+	if !errors.Is(err, kubectl.ErrNotFound) {
+		return err
+	}
+
+	res := &psmdb.PerconaServerMongoDBBackup{
+		TypeMeta: common.TypeMeta{
+			APIVersion: psmdbBackupAPIVersion,
+			Kind:       perconaServerMongoDBBackupKind,
+		},
+		ObjectMeta: common.ObjectMeta{
+			Name: params.Name,
+		},
+		Spec: psmdb.PerconaServerMongoDBBackupSpec{
+			PSMDBCluster: params.ClusterName,
+			StorageName:  storageName,
+		},
+	}
+	return c.kube.Apply(ctx, params.Namespace, res)
+}
+
+// DeletePSMDBClusterBackup deletes PSMDB cluster backup with provided name from a given namespace.
+func (c *K8sClient) DeletePSMDBClusterBackup(ctx context.Context, namespace, name string) error {
+	res := &psmdb.PerconaServerMongoDBBackup{
+		TypeMeta: common.TypeMeta{
+			APIVersion: psmdbBackupAPIVersion,
+			Kind:       perconaServerMongoDBBackupKind,
+		},
+		ObjectMeta: common.ObjectMeta{
+			Name: name,
+		},
+	}
+	err := c.kube.Delete(ctx, namespace, res)
+	if err != nil {
+		return errors.Wrapf(err, "cannot delete backup %s", name)
+	}
+	return nil
+}
+
+// RestorePSMDBCluster restores a given backup into existing PSMDB cluster, or into a new cluster.
+func (c *K8sClient) RestorePSMDBCluster(ctx context.Context, params *PSMDBRestoreParams) error {
+	var backup psmdb.PerconaServerMongoDBBackup
+	err := c.kube.Get(ctx, params.Namespace, perconaServerMongoDBBackupKind, params.BackupName, &backup)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get backup %s", params.BackupName)
+	}
+	if backup.Status.State != psmdb.BackupStateReady {
+		return errors.Errorf("backup %s is not ready, state is %q", params.BackupName, backup.Status.State)
+	}
+
+	restores, err := c.ListPSMDBClusterRestores(ctx, params.Namespace, params.ClusterName)
+	if err != nil {
+		return err
+	}
+	for _, restore := range restores {
+		if restore.State == RestoreStateRunning {
+			return errors.Errorf("PSMDB cluster %s is being restored from backup %s already", params.ClusterName, restore.BackupName)
+		}
+	}
+
+	name := params.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", params.ClusterName, time.Now().UTC().Format("20060102150405"))
+	}
+	var existing psmdb.PerconaServerMongoDBRestore
+	err = c.kube.Get(ctx, params.Namespace, perconaServerMongoDBRestoreKind, name, &existing)
+	if err == nil {
+		return errors.Errorf("restore %s already exists", name)
+	}
+	if !errors.Is(err, kubectl.ErrNotFound) {
+		return err
+	}
+
+	if params.NewCluster != nil {
+		newCluster := *params.NewCluster
+		newCluster.Name = params.ClusterName
+		newCluster.Namespace = params.Namespace
+		if err = c.CreatePSMDBCluster(ctx, &newCluster); err != nil {
+			return errors.Wrapf(err, "cannot create PSMDB cluster %s for restore", params.ClusterName)
+		}
+	} else {
+		var cluster psmdb.PerconaServerMongoDB
+		err = c.kube.Get(ctx, params.Namespace, string(perconaServerMongoDBKind), params.ClusterName, &cluster)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get PSMDB cluster %s", params.ClusterName)
+		}
+	}
+
+	res := &psmdb.PerconaServerMongoDBRestore{
+		TypeMeta: common.TypeMeta{
+			APIVersion: psmdbBackupAPIVersion,
+			Kind:       perconaServerMongoDBRestoreKind,
+		},
+		ObjectMeta: common.ObjectMeta{
+			Name: name,
+		},
+		Spec: psmdb.PerconaServerMongoDBRestoreSpec{
+			ClusterName: params.ClusterName,
+			BackupName:  params.BackupName,
+		},
+	}
+	// The operator looks for backup storage in the restored cluster by name,
+	// so the backup of another cluster should be referenced by its location.
+	if backup.Spec.PSMDBCluster != params.ClusterName {
+		source := backup.Status
+		res.Spec.BackupSource = &source
+	}
+	return c.kube.Apply(ctx, params.Namespace, res)
+}
+
+// ListPSMDBClusterRestores returns restores of PSMDB clusters in a given namespace sorted by start time.
+// Empty clusterName means restores of all clusters.
+func (c *K8sClient) ListPSMDBClusterRestores(ctx context.Context, namespace, clusterName string) ([]PSMDBClusterRestore, error) {
+	var list psmdb.PerconaServerMongoDBRestoreList
+	err := c.kube.Get(ctx, namespace, perconaServerMongoDBRestoreKind, "", &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get PSMDB cluster restores")
+	}
+
+	res := make([]PSMDBClusterRestore, 0, len(list.Items))
+	for i := range list.Items {
+		restore := &list.Items[i]
+		if clusterName != "" && restore.Spec.ClusterName != clusterName {
+			continue
+		}
+		res = append(res, getPSMDBClusterRestore(restore))
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].StartTime.Before(res[j].StartTime)
+	})
+	return res, nil
+}
+
+// getPSMDBClusterRestore converts PSMDB cluster restore custom resource.
+func getPSMDBClusterRestore(restore *psmdb.PerconaServerMongoDBRestore) PSMDBClusterRestore {
+	res := PSMDBClusterRestore{
+		Name:        restore.Name,
+		ClusterName: restore.Spec.ClusterName,
+		BackupName:  restore.Spec.BackupName,
+		State:       getPSMDBRestoreState(restore.Status.State),
+		Message:     restoreMessage(string(restore.Status.State), restore.Status.State == psmdb.RestoreStateNew, restore.Status.Error),
+	}
+	if restore.CreationTimestamp != nil {
+		res.StartTime = *restore.CreationTimestamp
+	}
+	if restore.Status.CompletedAt != nil {
+		res.FinishTime = *restore.Status.CompletedAt
+	}
+	return res
+}
+
+func getPSMDBRestoreState(state psmdb.RestoreState) RestoreState {
+	restoreState, ok := psmdbRestoreStatesMap[state]
+	if !ok {
+		return RestoreStateInvalid
+	}
+	return restoreState
+}
+
+// setPSMDBRestoreStates changes state and message of clusters according to their latest restores.
+func setPSMDBRestoreStates(clusters []PSMDBCluster, restores []PSMDBClusterRestore) {
+	latest := make(map[string]PSMDBClusterRestore, len(restores))
+	for _, restore := range restores {
+		// restores are sorted by start time
+		latest[restore.ClusterName] = restore
+	}
+
+	for i, cluster := range clusters {
+		restore, ok := latest[cluster.Name]
+		if !ok {
+			continue
+		}
+		clusters[i].State, clusters[i].Message = clusterRestoreState(cluster.State, cluster.Message, restore.State, restore.BackupName, restore.Message)
+	}
+}
@@ -0,0 +1,80 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+)
+
+func TestPSMDBBackups(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Tasks", func(t *testing.T) {
+		t.Parallel()
+
+		storages := map[string]psmdb.BackupStorageSpec{
+			"psmdb-backup-storage-test-psmdb": {Type: psmdb.BackupStorageS3},
+		}
+		tasks, err := psmdbBackupTasks("test-psmdb", []PSMDBBackupTask{{
+			Name:            "daily",
+			Schedule:        "0 0 * * *",
+			Keep:            5,
+			CompressionType: "gzip",
+		}}, storages)
+		require.NoError(t, err)
+		assert.Equal(t, []psmdb.BackupTaskSpec{{
+			Name:            "daily",
+			Enabled:         true,
+			Keep:            5,
+			Schedule:        "0 0 * * *",
+			StorageName:     "psmdb-backup-storage-test-psmdb",
+			CompressionType: psmdb.CompressionTypeGZIP,
+		}}, tasks)
+
+		_, err = psmdbBackupTasks("test-psmdb", []PSMDBBackupTask{{Name: "daily", Schedule: "0 0 * * *", CompressionType: "zip"}}, storages)
+		assert.EqualError(t, err, `unsupported compression type "zip"`)
+
+		_, err = psmdbBackupTasks("test-psmdb", []PSMDBBackupTask{{Name: "daily", Schedule: "0 0 * * *"}}, nil)
+		assert.EqualError(t, err, "PSMDB cluster test-psmdb has no backup storages")
+
+		tasks, err = psmdbBackupTasks("test-psmdb", []PSMDBBackupTask{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("RestoreStates", func(t *testing.T) {
+		t.Parallel()
+
+		clusters := []PSMDBCluster{
+			{Name: "restoring", State: ClusterStateReady},
+			{Name: "failed", State: ClusterStateChanging},
+		}
+		setPSMDBRestoreStates(clusters, []PSMDBClusterRestore{
+			{ClusterName: "restoring", BackupName: "b1", State: RestoreStateRunning, Message: "running"},
+			{ClusterName: "failed", BackupName: "b2", State: RestoreStateFailed, Message: "error: no backup"},
+		})
+		assert.Equal(t, []PSMDBCluster{
+			{Name: "restoring", State: ClusterStateChanging, Message: "Restoring from backup b1: running"},
+			{Name: "failed", State: ClusterStateFailed, Message: "Restore from backup b2 failed: error: no backup"},
+		}, clusters)
+	})
+}
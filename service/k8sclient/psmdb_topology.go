@@ -0,0 +1,272 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"fmt"
+
+	"github.com/AlekSi/pointer"
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+)
+
+const (
+	// Name of the first replica set, it is the only replica set of unsharded cluster.
+	psmdbDefaultReplsetName = "rs0"
+	// Name of the config server replica set statefulset suffix.
+	psmdbConfigReplsetName = "cfg"
+	// The first crVersion supporting non-voting members of replica sets.
+	psmdbNonVotingMinCRVersion = "1.9.0"
+)
+
+// PSMDBTopology represents PSMDB cluster topology.
+type PSMDBTopology string
+
+const (
+	// PSMDBTopologySharded is a sharded cluster with config servers, mongos and one or more shards.
+	// It is used if topology is not set.
+	PSMDBTopologySharded PSMDBTopology = "sharded"
+	// PSMDBTopologyReplicaset is a single replica set without sharding.
+	PSMDBTopologyReplicaset PSMDBTopology = "replicaset"
+)
+
+// validate checks that topology is known, empty topology means the default one.
+func (t PSMDBTopology) validate() error {
+	switch t {
+	case "", PSMDBTopologySharded, PSMDBTopologyReplicaset:
+		return nil
+	default:
+		return errors.Errorf("unknown PSMDB cluster topology %q", t)
+	}
+}
+
+// psmdbShards returns replica sets of a new cluster with defaults applied.
+// Without Shards, a single replica set is made from Size and Replicaset parameters.
+func psmdbShards(params *PSMDBParams) ([]*Replicaset, error) {
+	if err := params.Topology.validate(); err != nil {
+		return nil, err
+	}
+	if len(params.Shards) == 0 {
+		if params.Replicaset == nil {
+			return nil, errors.New("replicaset or shards parameters should be set")
+		}
+		return []*Replicaset{{
+			Name:             psmdbDefaultReplsetName,
+			Size:             params.Size,
+			ComputeResources: params.Replicaset.ComputeResources,
+			DiskSize:         params.Replicaset.DiskSize,
+			Arbiters:         params.Replicaset.Arbiters,
+			NonVotingMembers: params.Replicaset.NonVotingMembers,
+		}}, nil
+	}
+
+	if params.Topology == PSMDBTopologyReplicaset && len(params.Shards) > 1 {
+		return nil, errors.New("unsharded cluster should have one replica set")
+	}
+
+	res := make([]*Replicaset, len(params.Shards))
+	names := make(map[string]struct{}, len(params.Shards))
+	for i, shard := range params.Shards {
+		val := *shard
+		if val.Name == "" {
+			val.Name = fmt.Sprintf("rs%d", i)
+		}
+		if val.Name == psmdbConfigReplsetName {
+			return nil, errors.Errorf("replica set name %s is reserved", val.Name)
+		}
+		if _, ok := names[val.Name]; ok {
+			return nil, errors.Errorf("duplicate replica set name %s", val.Name)
+		}
+		names[val.Name] = struct{}{}
+
+		if val.Size <= 0 {
+			val.Size = params.Size
+		}
+		if val.Size <= 0 {
+			return nil, errors.Errorf("size of replica set %s should be set", val.Name)
+		}
+		res[i] = &val
+	}
+	return res, nil
+}
+
+// validatePSMDBMembers checks that replica set with arbiter has odd number of voting members.
+// The operator changes replica sets that do not follow its safe configuration.
+func validatePSMDBMembers(name string, size, arbiters int32) error {
+	switch {
+	case arbiters < 0 || arbiters > 1:
+		return errors.Errorf("replica set %s could have one arbiter at most", name)
+	case arbiters == 1 && size%2 != 0:
+		return errors.Errorf("replica set %s with arbiter should have even size", name)
+	default:
+		return nil
+	}
+}
+
+// supportsPSMDBNonVoting returns true if the operator supports non-voting members of clusters with a given crVersion.
+func supportsPSMDBNonVoting(crVersion string) bool {
+	v, err := version.NewVersion(crVersion)
+	if err != nil {
+		return false
+	}
+	return v.GreaterThanOrEqual(version.Must(version.NewVersion(psmdbNonVotingMinCRVersion)))
+}
+
+// setPSMDBMembers changes arbiters and non-voting members of a given replica set if they are set.
+// Non-voting members are rejected for clusters with crVersion not supporting them, the operator ignores them.
+func (c *K8sClient) setPSMDBMembers(spec *psmdb.ReplsetSpec, rs *Replicaset, crVersion string) error {
+	if rs.Arbiters != nil {
+		spec.Arbiter.Enabled = *rs.Arbiters > 0
+		spec.Arbiter.Size = *rs.Arbiters
+		if !spec.Arbiter.Enabled {
+			spec.Arbiter.Size = 1
+		}
+	}
+	if rs.NonVotingMembers != nil {
+		if *rs.NonVotingMembers < 0 {
+			return errors.Errorf("number of non-voting members of replica set %s should not be negative", spec.Name)
+		}
+		switch {
+		case supportsPSMDBNonVoting(crVersion):
+			spec.NonVoting = &psmdb.NonVoting{
+				Enabled:             *rs.NonVotingMembers > 0,
+				Size:                *rs.NonVotingMembers,
+				Resources:           spec.Resources,
+				VolumeSpec:          spec.VolumeSpec,
+				PodDisruptionBudget: spec.PodDisruptionBudget,
+				MultiAZ:             spec.MultiAZ,
+			}
+		case *rs.NonVotingMembers > 0:
+			return errors.Errorf("non-voting members of replica set %s require crVersion %s or newer, cluster has %q",
+				spec.Name, psmdbNonVotingMinCRVersion, crVersion)
+		}
+	}
+
+	var arbiters int32
+	if spec.Arbiter.Enabled {
+		arbiters = spec.Arbiter.Size
+	}
+	return validatePSMDBMembers(spec.Name, spec.Size, arbiters)
+}
+
+// psmdbReplsetSpec returns operator replica set specification for a given replica set parameters
+// of a cluster with a given crVersion.
+func (c *K8sClient) psmdbReplsetSpec(rs *Replicaset, affinity *psmdb.PodAffinity, crVersion string) (*psmdb.ReplsetSpec, error) {
+	spec := &psmdb.ReplsetSpec{
+		Name:      rs.Name,
+		Size:      rs.Size,
+		Resources: c.setComputeResources(rs.ComputeResources),
+		Arbiter: psmdb.Arbiter{
+			Enabled: false,
+			Size:    1,
+			MultiAZ: psmdb.MultiAZ{
+				Affinity: affinity,
+			},
+		},
+		VolumeSpec:    c.volumeSpec(rs.DiskSize, rs.StorageClass),
+		Configuration: pointer.GetString(rs.Configuration),
+		PodDisruptionBudget: &common.PodDisruptionBudgetSpec{
+			MaxUnavailable: pointer.ToInt(1),
+		},
+		MultiAZ: psmdb.MultiAZ{
+			Affinity: affinity,
+		},
+	}
+	if err := c.setPSMDBMembers(spec, rs, crVersion); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// getPSMDBTopology returns topology of a given cluster.
+func getPSMDBTopology(cluster *psmdb.PerconaServerMongoDB) PSMDBTopology {
+	if cluster.Spec.Sharding != nil && cluster.Spec.Sharding.Enabled {
+		return PSMDBTopologySharded
+	}
+	return PSMDBTopologyReplicaset
+}
+
+// updatePSMDBReplsets changes size, resources and configuration of existing replica sets and adds new shards.
+// Without Shards, Size and Replicaset parameters are applied to all replica sets.
+func (c *K8sClient) updatePSMDBReplsets(cluster *psmdb.PerconaServerMongoDB, params *PSMDBParams) error {
+	if err := params.Topology.validate(); err != nil {
+		return err
+	}
+	if params.Topology != "" && params.Topology != getPSMDBTopology(cluster) {
+		return errors.New("PSMDB cluster topology cannot be changed")
+	}
+
+	if len(params.Shards) == 0 {
+		for _, rs := range cluster.Spec.Replsets {
+			if params.Size > 0 {
+				rs.Size = params.Size
+			}
+			// members are validated even if only size is changed
+			members := new(Replicaset)
+			if params.Replicaset != nil {
+				rs.Resources = c.updateComputeResources(params.Replicaset.ComputeResources, rs.Resources)
+				if params.Replicaset.Configuration != nil {
+					rs.Configuration = *params.Replicaset.Configuration
+				}
+				members = params.Replicaset
+			}
+			if err := c.setPSMDBMembers(rs, members, cluster.Spec.CRVersion); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	existing := make(map[string]*psmdb.ReplsetSpec, len(cluster.Spec.Replsets))
+	for _, rs := range cluster.Spec.Replsets {
+		existing[rs.Name] = rs
+	}
+	for _, shard := range params.Shards {
+		rs, ok := existing[shard.Name]
+		if !ok {
+			if getPSMDBTopology(cluster) != PSMDBTopologySharded {
+				return errors.New("unsharded cluster should have one replica set")
+			}
+			if shard.Name == "" || shard.Name == psmdbConfigReplsetName || shard.Size <= 0 || shard.DiskSize == "" {
+				return errors.New("name, size and disk size of a new shard should be set")
+			}
+			// new shards are spread over nodes the same way as existing ones
+			affinity := cluster.Spec.Replsets[0].MultiAZ.Affinity
+			spec, err := c.psmdbReplsetSpec(shard, affinity, cluster.Spec.CRVersion)
+			if err != nil {
+				return err
+			}
+			cluster.Spec.Replsets = append(cluster.Spec.Replsets, spec)
+			continue
+		}
+
+		if shard.Size > 0 {
+			rs.Size = shard.Size
+		}
+		rs.Resources = c.updateComputeResources(shard.ComputeResources, rs.Resources)
+		if shard.Configuration != nil {
+			rs.Configuration = *shard.Configuration
+		}
+		if err := c.setPSMDBMembers(rs, shard, cluster.Spec.CRVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+)
+
+func TestPSMDBTopology(t *testing.T) {
+	t.Parallel()
+
+	t.Run("DefaultShard", func(t *testing.T) {
+		t.Parallel()
+
+		shards, err := psmdbShards(&PSMDBParams{
+			Size:       3,
+			Replicaset: &Replicaset{DiskSize: "1G"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []*Replicaset{{Name: "rs0", Size: 3, DiskSize: "1G"}}, shards)
+	})
+
+	t.Run("Shards", func(t *testing.T) {
+		t.Parallel()
+
+		shards, err := psmdbShards(&PSMDBParams{
+			Size:   3,
+			Shards: []*Replicaset{{DiskSize: "1G"}, {Name: "big", Size: 5, DiskSize: "10G"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []*Replicaset{
+			{Name: "rs0", Size: 3, DiskSize: "1G"},
+			{Name: "big", Size: 5, DiskSize: "10G"},
+		}, shards)
+
+		_, err = psmdbShards(&PSMDBParams{
+			Topology: PSMDBTopologyReplicaset,
+			Shards:   []*Replicaset{{Size: 3}, {Size: 3}},
+		})
+		assert.EqualError(t, err, "unsharded cluster should have one replica set")
+
+		_, err = psmdbShards(&PSMDBParams{
+			Topology:   "replicaSet",
+			Size:       3,
+			Replicaset: &Replicaset{DiskSize: "1G"},
+		})
+		assert.EqualError(t, err, `unknown PSMDB cluster topology "replicaSet"`)
+
+		_, err = psmdbShards(&PSMDBParams{Shards: []*Replicaset{{Name: "cfg", Size: 3}}})
+		assert.EqualError(t, err, "replica set name cfg is reserved")
+
+		_, err = psmdbShards(&PSMDBParams{Shards: []*Replicaset{{Name: "rs1", Size: 3}, {Size: 3}, {Size: 3}}})
+		assert.EqualError(t, err, "duplicate replica set name rs1")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+
+		c := new(K8sClient)
+		cluster := &psmdb.PerconaServerMongoDB{
+			Spec: psmdb.PerconaServerMongoDBSpec{
+				Sharding: &psmdb.ShardingSpec{Enabled: true},
+				Replsets: []*psmdb.ReplsetSpec{{Name: "rs0", Size: 3}},
+			},
+		}
+
+		err := c.updatePSMDBReplsets(cluster, &PSMDBParams{Topology: PSMDBTopologyReplicaset})
+		assert.EqualError(t, err, "PSMDB cluster topology cannot be changed")
+
+		err = c.updatePSMDBReplsets(cluster, &PSMDBParams{Topology: "shard"})
+		assert.EqualError(t, err, `unknown PSMDB cluster topology "shard"`)
+
+		err = c.updatePSMDBReplsets(cluster, &PSMDBParams{Size: 5})
+		require.NoError(t, err)
+		assert.Equal(t, int32(5), cluster.Spec.Replsets[0].Size)
+
+		err = c.updatePSMDBReplsets(cluster, &PSMDBParams{Shards: []*Replicaset{{Name: "rs1", Size: 3, DiskSize: "1G"}}})
+		require.NoError(t, err)
+		require.Len(t, cluster.Spec.Replsets, 2)
+		assert.Equal(t, "rs1", cluster.Spec.Replsets[1].Name)
+		assert.Equal(t, int32(5), cluster.Spec.Replsets[0].Size)
+
+		cluster.Spec.Sharding = nil
+		err = c.updatePSMDBReplsets(cluster, &PSMDBParams{Shards: []*Replicaset{{Name: "rs2", Size: 3, DiskSize: "1G"}}})
+		assert.EqualError(t, err, "unsharded cluster should have one replica set")
+	})
+
+	t.Run("Members", func(t *testing.T) {
+		t.Parallel()
+
+		c := new(K8sClient)
+		spec, err := c.psmdbReplsetSpec(&Replicaset{Name: "rs0", Size: 2, DiskSize: "1G", Arbiters: pointer.ToInt32(1)}, nil, psmdbCRVersion)
+		require.NoError(t, err)
+		assert.Equal(t, psmdb.Arbiter{Enabled: true, Size: 1}, spec.Arbiter)
+		assert.Nil(t, spec.NonVoting)
+
+		_, err = c.psmdbReplsetSpec(&Replicaset{Name: "rs0", Size: 3, DiskSize: "1G", Arbiters: pointer.ToInt32(1)}, nil, psmdbCRVersion)
+		assert.EqualError(t, err, "replica set rs0 with arbiter should have even size")
+
+		_, err = c.psmdbReplsetSpec(&Replicaset{Name: "rs0", Size: 2, DiskSize: "1G", Arbiters: pointer.ToInt32(2)}, nil, psmdbCRVersion)
+		assert.EqualError(t, err, "replica set rs0 could have one arbiter at most")
+
+		_, err = c.psmdbReplsetSpec(&Replicaset{Name: "rs0", Size: 3, DiskSize: "1G", NonVotingMembers: pointer.ToInt32(2)}, nil, psmdbCRVersion)
+		assert.EqualError(t, err, `non-voting members of replica set rs0 require crVersion 1.9.0 or newer, cluster has "1.8.0"`)
+
+		spec, err = c.psmdbReplsetSpec(&Replicaset{Name: "rs0", Size: 2, DiskSize: "1G", Arbiters: pointer.ToInt32(1), NonVotingMembers: pointer.ToInt32(0)}, nil, psmdbCRVersion)
+		require.NoError(t, err)
+		assert.Nil(t, spec.NonVoting)
+
+		cluster := &psmdb.PerconaServerMongoDB{
+			Spec: psmdb.PerconaServerMongoDBSpec{
+				CRVersion: "1.9.0",
+				Replsets:  []*psmdb.ReplsetSpec{spec},
+			},
+		}
+		err = c.updatePSMDBReplsets(cluster, &PSMDBParams{
+			Size:       3,
+			Replicaset: &Replicaset{Arbiters: pointer.ToInt32(0), NonVotingMembers: pointer.ToInt32(2)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, psmdb.Arbiter{Enabled: false, Size: 1}, spec.Arbiter)
+		require.NotNil(t, spec.NonVoting)
+		assert.True(t, spec.NonVoting.Enabled)
+		assert.Equal(t, int32(2), spec.NonVoting.Size)
+		assert.Equal(t, spec.VolumeSpec, spec.NonVoting.VolumeSpec)
+
+		err = c.updatePSMDBReplsets(cluster, &PSMDBParams{Replicaset: &Replicaset{NonVotingMembers: pointer.ToInt32(-1)}})
+		assert.EqualError(t, err, "number of non-voting members of replica set rs0 should not be negative")
+
+		// size change alone breaks the replica set with arbiter
+		spec.Arbiter = psmdb.Arbiter{Enabled: true, Size: 1}
+		spec.Size = 4
+		err = c.updatePSMDBReplsets(cluster, &PSMDBParams{Size: 3})
+		assert.EqualError(t, err, "replica set rs0 with arbiter should have even size")
+	})
+}
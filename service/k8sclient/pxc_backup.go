@@ -0,0 +1,386 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+	"github.com/percona-platform/dbaas-controller/utils/convertors"
+)
+
+const (
+	perconaXtraDBClusterBackupKind = "PerconaXtraDBClusterBackup"
+	pxcBackupAPIVersion            = "pxc.percona.com/v1"
+
+	pxcDefaultBackupScheduleName = "test"
+	pxcDefaultBackupSchedule     = "*/30 * * * *"
+	pxcDefaultBackupKeep         = 3
+
+	// filesystem backups are stored in PVC, their destination is "pvc/<PVC name>".
+	pvcBackupDestinationPrefix = "pvc/"
+)
+
+// BackupState represents database cluster backup state.
+type BackupState int32
+
+const (
+	// BackupStateInvalid represents unknown state.
+	BackupStateInvalid BackupState = 0
+	// BackupStateRunning represents a backup being made.
+	BackupStateRunning BackupState = 1
+	// BackupStateSucceeded represents a finished backup.
+	BackupStateSucceeded BackupState = 2
+	// BackupStateFailed represents a failed backup.
+	BackupStateFailed BackupState = 3
+)
+
+// pxcBackupStatesMap matches pxc backup states to backup states.
+var pxcBackupStatesMap = map[pxc.PXCBackupState]BackupState{ //nolint:gochecknoglobals
+	pxc.BackupStateNew:       BackupStateRunning,
+	pxc.BackupStateStarting:  BackupStateRunning,
+	pxc.BackupStateRunning:   BackupStateRunning,
+	pxc.BackupStateSucceeded: BackupStateSucceeded,
+	pxc.BackupStateFailed:    BackupStateFailed,
+}
+
+// ErrBackupScheduleNotFound is returned when backup schedule with given name does not exist.
+var ErrBackupScheduleNotFound = errors.New("backup schedule was not found")
+
+// BackupSchedule describes scheduled backups of a database cluster.
+type BackupSchedule struct {
+	Name string
+	// Schedule in cron format, for example "0 0 * * *".
+	Schedule string
+	// Keep is a number of the latest backups to keep, zero means all backups are kept.
+	Keep int
+	// StorageName is a name of backup storage defined in the cluster.
+	StorageName string
+}
+
+// XtraDBBackupParams contains all parameters required to create XtraDB cluster backup.
+type XtraDBBackupParams struct {
+	// Namespace of the cluster, empty namespace means the default one.
+	Namespace   string
+	ClusterName string
+	Name        string
+	// StorageName is a name of backup storage defined in the cluster, empty means the default one.
+	StorageName string
+}
+
+// XtraDBClusterBackup contains information related to XtraDB cluster backup.
+type XtraDBClusterBackup struct {
+	Name        string
+	ClusterName string
+	StorageName string
+	Destination string
+	State       BackupState
+	StartTime   time.Time
+	// FinishTime is zero if backup is not finished.
+	FinishTime time.Time
+	// SizeBytes is known only for filesystem backups, it is zero otherwise.
+	SizeBytes uint64
+}
+
+// ListXtraDBClusterBackups returns backups of XtraDB clusters in a given namespace sorted by start time.
+// Empty clusterName means backups of all clusters.
+func (c *K8sClient) ListXtraDBClusterBackups(ctx context.Context, namespace, clusterName string) ([]XtraDBClusterBackup, error) {
+	var list pxc.PerconaXtraDBClusterBackupList
+	err := c.kube.Get(ctx, namespace, perconaXtraDBClusterBackupKind, "", &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get Percona XtraDB cluster backups")
+	}
+
+	// sizes of filesystem backups are known from their PVCs, get them once for all backups
+	var pvcSizes map[string]uint64
+	for _, backup := range list.Items {
+		if strings.HasPrefix(backup.Status.Destination, pvcBackupDestinationPrefix) {
+			if pvcSizes, err = c.getPVCSizes(ctx, namespace); err != nil {
+				c.l.Warnf("Cannot get sizes of filesystem backups: %s.", err)
+			}
+			break
+		}
+	}
+
+	res := make([]XtraDBClusterBackup, 0, len(list.Items))
+	for _, backup := range list.Items {
+		if clusterName != "" && backup.Spec.PXCCluster != clusterName {
+			continue
+		}
+
+		val := XtraDBClusterBackup{
+			Name:        backup.Name,
+			ClusterName: backup.Spec.PXCCluster,
+			StorageName: backup.Spec.StorageName,
+			Destination: backup.Status.Destination,
+			State:       getPXCBackupState(backup.Status.State),
+		}
+		if backup.CreationTimestamp != nil {
+			val.StartTime = *backup.CreationTimestamp
+		}
+		if backup.Status.CompletedAt != nil {
+			val.FinishTime = *backup.Status.CompletedAt
+		}
+		if strings.HasPrefix(val.Destination, pvcBackupDestinationPrefix) {
+			val.SizeBytes = pvcSizes[strings.TrimPrefix(val.Destination, pvcBackupDestinationPrefix)]
+		}
+		res = append(res, val)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].StartTime.Before(res[j].StartTime)
+	})
+	return res, nil
+}
+
+// getPVCSizes returns capacities of PVCs in a given namespace in bytes keyed by PVC name.
+// PVCs without known capacity are skipped.
+func (c *K8sClient) getPVCSizes(ctx context.Context, namespace string) (map[string]uint64, error) {
+	var list common.PersistentVolumeClaimList
+	if err := c.kube.Get(ctx, namespace, "pvc", "", &list); err != nil {
+		return nil, err
+	}
+	res := make(map[string]uint64, len(list.Items))
+	for _, pvc := range list.Items {
+		if pvc.Status.Capacity.Storage == "" {
+			continue
+		}
+		size, err := convertors.StrToBytes(pvc.Status.Capacity.Storage)
+		if err != nil {
+			c.l.Warnf("Cannot get size of %s: %s.", pvc.Name, err)
+			continue
+		}
+		res[pvc.Name] = size
+	}
+	return res, nil
+}
+
+func getPXCBackupState(state pxc.PXCBackupState) BackupState {
+	backupState, ok := pxcBackupStatesMap[state]
+	if !ok {
+		return BackupStateInvalid
+	}
+	return backupState
+}
+
+// CreateXtraDBClusterBackup makes a new on-demand backup of XtraDB cluster.
+func (c *K8sClient) CreateXtraDBClusterBackup(ctx context.Context, params *XtraDBBackupParams) error {
+	var cluster pxc.PerconaXtraDBCluster
+	err := c.kube.Get(ctx, params.Namespace, string(perconaXtraDBClusterKind), params.ClusterName, &cluster)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get XtraDB cluster %s", params.ClusterName)
+	}
+
+	storageName, err := pxcBackupStorageNameFor(&cluster, params.StorageName)
+	if err != nil {
+		return err
+	}
+
+	var backup pxc.PerconaXtraDBClusterBackup
+	err = c.kube.Get(ctx, params.Namespace, perconaXtraDBClusterBackupKind, params.Name, &backup)
+	if err == nil {
+		return errors.Errorf("backup %s already exists", params.Name)
+	}
+	if !errors.Is(err, kubectl.ErrNotFound) {
+		return err
+	}
+
+	res := &pxc.PerconaXtraDBClusterBackup{
+		TypeMeta: common.TypeMeta{
+			APIVersion: pxcBackupAPIVersion,
+			Kind:       perconaXtraDBClusterBackupKind,
+		},
+		ObjectMeta: common.ObjectMeta{
+			Name: params.Name,
+		},
+		Spec: pxc.PXCBackupSpec{
+			PXCCluster:  params.ClusterName,
+			StorageName: storageName,
+		},
+	}
+	return c.kube.Apply(ctx, params.Namespace, res)
+}
+
+// pxcBackupStorageNameFor checks that given storage is defined in the cluster.
+// Empty name means the storage created with the cluster, or the only storage defined in the cluster.
+func pxcBackupStorageNameFor(cluster *pxc.PerconaXtraDBCluster, name string) (string, error) {
+	if cluster.Spec.Backup == nil || len(cluster.Spec.Backup.Storages) == 0 {
+		return "", errors.Errorf("XtraDB cluster %s has no backup storages", cluster.Name)
+	}
+
+	if name == "" {
+		name = fmt.Sprintf(pxcBackupStorageName, cluster.Name)
+		if _, ok := cluster.Spec.Backup.Storages[name]; ok {
+			return name, nil
+		}
+		if len(cluster.Spec.Backup.Storages) > 1 {
+			return "", errors.Errorf("XtraDB cluster %s has several backup storages, storage name should be set", cluster.Name)
+		}
+		for name = range cluster.Spec.Backup.Storages {
+			return name, nil
+		}
+	}
+
+	if _, ok := cluster.Spec.Backup.Storages[name]; !ok {
+		return "", errors.Errorf("XtraDB cluster %s has no backup storage %s", cluster.Name, name)
+	}
+	return name, nil
+}
+
+// DeleteXtraDBClusterBackup deletes XtraDB cluster backup with provided name from a given namespace.
+// The operator removes backup data as well.
+func (c *K8sClient) DeleteXtraDBClusterBackup(ctx context.Context, namespace, name string) error {
+	res := &pxc.PerconaXtraDBClusterBackup{
+		TypeMeta: common.TypeMeta{
+			APIVersion: pxcBackupAPIVersion,
+			Kind:       perconaXtraDBClusterBackupKind,
+		},
+		ObjectMeta: common.ObjectMeta{
+			Name: name,
+		},
+	}
+	err := c.kube.Delete(ctx, namespace, res)
+	if err != nil {
+		return errors.Wrapf(err, "cannot delete backup %s", name)
+	}
+	return nil
+}
+
+// ListXtraDBBackupSchedules returns backup schedules of XtraDB cluster.
+func (c *K8sClient) ListXtraDBBackupSchedules(ctx context.Context, namespace, clusterName string) ([]BackupSchedule, error) {
+	var cluster pxc.PerconaXtraDBCluster
+	err := c.kube.Get(ctx, namespace, string(perconaXtraDBClusterKind), clusterName, &cluster)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get XtraDB cluster %s", clusterName)
+	}
+	if cluster.Spec.Backup == nil {
+		return []BackupSchedule{}, nil
+	}
+
+	res := make([]BackupSchedule, len(cluster.Spec.Backup.Schedule))
+	for i, schedule := range cluster.Spec.Backup.Schedule {
+		res[i] = BackupSchedule{
+			Name:        schedule.Name,
+			Schedule:    schedule.Schedule,
+			Keep:        schedule.Keep,
+			StorageName: schedule.StorageName,
+		}
+	}
+	return res, nil
+}
+
+// SetXtraDBBackupSchedule adds backup schedule to XtraDB cluster or replaces the schedule with the same name.
+// Empty storage name means the default one, see CreateXtraDBClusterBackup.
+func (c *K8sClient) SetXtraDBBackupSchedule(ctx context.Context, namespace, clusterName string, schedule BackupSchedule) error {
+	if schedule.Name == "" || schedule.Schedule == "" {
+		return errors.New("backup schedule name and schedule should be set")
+	}
+	if schedule.Keep < 0 {
+		return errors.New("number of backups to keep should not be negative")
+	}
+
+	var cluster pxc.PerconaXtraDBCluster
+	err := c.kube.Get(ctx, namespace, string(perconaXtraDBClusterKind), clusterName, &cluster)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get XtraDB cluster %s", clusterName)
+	}
+
+	storageName, err := pxcBackupStorageNameFor(&cluster, schedule.StorageName)
+	if err != nil {
+		return err
+	}
+
+	val := pxc.PXCScheduledBackupSchedule{
+		Name:        schedule.Name,
+		Schedule:    schedule.Schedule,
+		Keep:        schedule.Keep,
+		StorageName: storageName,
+	}
+	var found bool
+	for i, s := range cluster.Spec.Backup.Schedule {
+		if s.Name == schedule.Name {
+			cluster.Spec.Backup.Schedule[i] = val
+			found = true
+			break
+		}
+	}
+	if !found {
+		cluster.Spec.Backup.Schedule = append(cluster.Spec.Backup.Schedule, val)
+	}
+
+	return c.kube.Apply(ctx, namespace, &cluster)
+}
+
+// DeleteXtraDBBackupSchedule removes backup schedule with provided name from XtraDB cluster.
+// Backups made by that schedule are kept.
+func (c *K8sClient) DeleteXtraDBBackupSchedule(ctx context.Context, namespace, clusterName, name string) error {
+	var cluster pxc.PerconaXtraDBCluster
+	err := c.kube.Get(ctx, namespace, string(perconaXtraDBClusterKind), clusterName, &cluster)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get XtraDB cluster %s", clusterName)
+	}
+	if cluster.Spec.Backup == nil {
+		return errors.Wrap(ErrBackupScheduleNotFound, name)
+	}
+
+	schedules := make([]pxc.PXCScheduledBackupSchedule, 0, len(cluster.Spec.Backup.Schedule))
+	for _, s := range cluster.Spec.Backup.Schedule {
+		if s.Name != name {
+			schedules = append(schedules, s)
+		}
+	}
+	if len(schedules) == len(cluster.Spec.Backup.Schedule) {
+		return errors.Wrap(ErrBackupScheduleNotFound, name)
+	}
+	cluster.Spec.Backup.Schedule = schedules
+
+	return c.kube.Apply(ctx, namespace, &cluster)
+}
+
+// pxcBackupSchedules converts backup schedules to the operator ones. Nil means the default schedule.
+func pxcBackupSchedules(schedules []BackupSchedule, defaultStorage string) []pxc.PXCScheduledBackupSchedule {
+	if schedules == nil {
+		return []pxc.PXCScheduledBackupSchedule{{
+			Name:        pxcDefaultBackupScheduleName,
+			Schedule:    pxcDefaultBackupSchedule,
+			Keep:        pxcDefaultBackupKeep,
+			StorageName: defaultStorage,
+		}}
+	}
+
+	res := make([]pxc.PXCScheduledBackupSchedule, len(schedules))
+	for i, schedule := range schedules {
+		res[i] = pxc.PXCScheduledBackupSchedule{
+			Name:        schedule.Name,
+			Schedule:    schedule.Schedule,
+			Keep:        schedule.Keep,
+			StorageName: schedule.StorageName,
+		}
+		if res[i].StorageName == "" {
+			res[i].StorageName = defaultStorage
+		}
+	}
+	return res
+}
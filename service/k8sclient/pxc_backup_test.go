@@ -0,0 +1,123 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+func TestPXCBackups(t *testing.T) {
+	t.Parallel()
+
+	t.Run("StorageName", func(t *testing.T) {
+		t.Parallel()
+
+		cluster := &pxc.PerconaXtraDBCluster{
+			ObjectMeta: common.ObjectMeta{Name: "test-pxc"},
+			Spec: pxc.PerconaXtraDBClusterSpec{
+				Backup: &pxc.PXCScheduledBackup{
+					Storages: map[string]*pxc.BackupStorageSpec{
+						"pxc-backup-storage-test-pxc": {Type: pxc.BackupStorageFilesystem},
+						"s3":                          {Type: pxc.BackupStorageS3},
+					},
+				},
+			},
+		}
+
+		name, err := pxcBackupStorageNameFor(cluster, "")
+		require.NoError(t, err)
+		assert.Equal(t, "pxc-backup-storage-test-pxc", name)
+
+		name, err = pxcBackupStorageNameFor(cluster, "s3")
+		require.NoError(t, err)
+		assert.Equal(t, "s3", name)
+
+		_, err = pxcBackupStorageNameFor(cluster, "missing")
+		assert.EqualError(t, err, "XtraDB cluster test-pxc has no backup storage missing")
+
+		delete(cluster.Spec.Backup.Storages, "pxc-backup-storage-test-pxc")
+		name, err = pxcBackupStorageNameFor(cluster, "")
+		require.NoError(t, err)
+		assert.Equal(t, "s3", name)
+
+		cluster.Spec.Backup = nil
+		_, err = pxcBackupStorageNameFor(cluster, "")
+		assert.EqualError(t, err, "XtraDB cluster test-pxc has no backup storages")
+	})
+
+	t.Run("Schedules", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, []pxc.PXCScheduledBackupSchedule{{
+			Name:        "test",
+			Schedule:    "*/30 * * * *",
+			Keep:        3,
+			StorageName: "fs",
+		}}, pxcBackupSchedules(nil, "fs"))
+
+		assert.Empty(t, pxcBackupSchedules([]BackupSchedule{}, "fs"))
+
+		assert.Equal(t, []pxc.PXCScheduledBackupSchedule{{
+			Name:        "daily",
+			Schedule:    "0 0 * * *",
+			Keep:        7,
+			StorageName: "fs",
+		}}, pxcBackupSchedules([]BackupSchedule{{Name: "daily", Schedule: "0 0 * * *", Keep: 7}}, "fs"))
+	})
+
+	t.Run("State", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, BackupStateRunning, getPXCBackupState(pxc.BackupStateNew))
+		assert.Equal(t, BackupStateRunning, getPXCBackupState(pxc.BackupStateStarting))
+		assert.Equal(t, BackupStateSucceeded, getPXCBackupState(pxc.BackupStateSucceeded))
+		assert.Equal(t, BackupStateFailed, getPXCBackupState(pxc.BackupStateFailed))
+		assert.Equal(t, BackupStateInvalid, getPXCBackupState("Unknown"))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		kube := &fakeDetailsClient{objects: map[string]string{
+			perconaXtraDBClusterBackupKind + "/": `{"items": [
+				{"metadata": {"name": "fs1"}, "spec": {"pxcCluster": "test"}, "status": {"destination": "pvc/xb-fs1"}},
+				{"metadata": {"name": "fs2"}, "spec": {"pxcCluster": "test"}, "status": {"destination": "pvc/xb-fs2"}},
+				{"metadata": {"name": "s3"}, "spec": {"pxcCluster": "test"}, "status": {"destination": "s3://bucket/s3"}}
+			]}`,
+			"pvc/": `{"items": [
+				{"metadata": {"name": "xb-fs1"}, "status": {"capacity": {"storage": "6Gi"}}},
+				{"metadata": {"name": "xb-fs2"}, "status": {}}
+			]}`,
+		}}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		backups, err := client.ListXtraDBClusterBackups(ctx, "", "test")
+		require.NoError(t, err)
+		require.Len(t, backups, 3)
+		assert.Equal(t, uint64(6<<30), backups[0].SizeBytes)
+		assert.Zero(t, backups[1].SizeBytes)
+		assert.Zero(t, backups[2].SizeBytes)
+	})
+}
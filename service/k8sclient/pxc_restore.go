@@ -0,0 +1,294 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+const (
+	perconaXtraDBClusterRestoreKind = "PerconaXtraDBClusterRestore"
+	pxcRestoreAPIVersion            = "pxc.percona.com/v1"
+)
+
+// pxcRestoreStatesMap matches pxc restore states to restore states.
+var pxcRestoreStatesMap = map[pxc.BcpRestoreStates]RestoreState{ //nolint:gochecknoglobals
+	pxc.RestoreNew:          RestoreStateRunning,
+	pxc.RestoreStarting:     RestoreStateRunning,
+	pxc.RestoreStopCluster:  RestoreStateRunning,
+	pxc.RestoreRestore:      RestoreStateRunning,
+	pxc.RestoreStartCluster: RestoreStateRunning,
+	pxc.RestorePITR:         RestoreStateRunning,
+	pxc.RestoreFailed:       RestoreStateFailed,
+	pxc.RestoreSucceeded:    RestoreStateSucceeded,
+}
+
+// PITRType represents a type of point-in-time recovery.
+type PITRType string
+
+const (
+	// PITRTypeDate recovers transactions committed before a given date.
+	PITRTypeDate PITRType = "date"
+	// PITRTypeTransaction recovers transactions before a given GTID.
+	PITRTypeTransaction PITRType = "transaction"
+	// PITRTypeLatest recovers all available transactions.
+	PITRTypeLatest PITRType = "latest"
+)
+
+// pitrDateFormat is a date format of point-in-time recovery expected by the operator.
+const pitrDateFormat = "2006-01-02 15:04:05"
+
+// XtraDBRestorePITR contains parameters of point-in-time recovery applied after the backup is restored.
+type XtraDBRestorePITR struct {
+	Type PITRType
+	// Date is used by PITRTypeDate only.
+	Date time.Time
+	// GTID is used by PITRTypeTransaction only.
+	GTID string
+}
+
+// XtraDBRestoreParams contains all parameters required to restore XtraDB cluster from a backup.
+type XtraDBRestoreParams struct {
+	// Namespace of the backup and the cluster, empty namespace means the default one.
+	Namespace string
+	// Name of the restore, it is generated if empty.
+	Name        string
+	BackupName  string
+	ClusterName string
+	// NewCluster contains parameters of a cluster to create and restore the backup into.
+	// Backup is restored into existing cluster ClusterName if it is nil.
+	NewCluster *XtraDBParams
+	// PITR contains parameters of point-in-time recovery, the backup is restored as is if it is nil.
+	PITR *XtraDBRestorePITR
+}
+
+// XtraDBClusterRestore contains information related to XtraDB cluster restore.
+type XtraDBClusterRestore struct {
+	Name        string
+	ClusterName string
+	BackupName  string
+	State       RestoreState
+	// Message contains the operator state of the restore and an error message if any.
+	Message   string
+	StartTime time.Time
+	// FinishTime is zero if restore is not finished.
+	FinishTime time.Time
+}
+
+// RestoreXtraDBCluster restores a given backup into existing XtraDB cluster, or into a new cluster.
+// Restore stops the cluster, so the cluster is reported as changing until restore is finished.
+// The new cluster is deleted if the restore can't be created.
+func (c *K8sClient) RestoreXtraDBCluster(ctx context.Context, params *XtraDBRestoreParams) error {
+	var backup pxc.PerconaXtraDBClusterBackup
+	err := c.kube.Get(ctx, params.Namespace, perconaXtraDBClusterBackupKind, params.BackupName, &backup)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get backup %s", params.BackupName)
+	}
+	if backup.Status.State != pxc.BackupStateSucceeded {
+		return errors.Errorf("backup %s is not succeeded, state is %q", params.BackupName, backup.Status.State)
+	}
+
+	pitr, err := pxcRestorePITR(params.PITR, &backup, params.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	restores, err := c.ListXtraDBClusterRestores(ctx, params.Namespace, params.ClusterName)
+	if err != nil {
+		return err
+	}
+	for _, restore := range restores {
+		if restore.State == RestoreStateRunning {
+			return errors.Errorf("XtraDB cluster %s is being restored from backup %s already", params.ClusterName, restore.BackupName)
+		}
+	}
+
+	name := params.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", params.ClusterName, time.Now().UTC().Format("20060102150405"))
+	}
+	var existing pxc.PerconaXtraDBClusterRestore
+	err = c.kube.Get(ctx, params.Namespace, perconaXtraDBClusterRestoreKind, name, &existing)
+	if err == nil {
+		return errors.Errorf("restore %s already exists", name)
+	}
+	if !errors.Is(err, kubectl.ErrNotFound) {
+		return err
+	}
+
+	if params.NewCluster != nil {
+		newCluster := *params.NewCluster
+		newCluster.Name = params.ClusterName
+		newCluster.Namespace = params.Namespace
+		if err = c.CreateXtraDBCluster(ctx, &newCluster); err != nil {
+			return errors.Wrapf(err, "cannot create XtraDB cluster %s for restore", params.ClusterName)
+		}
+	} else {
+		var cluster pxc.PerconaXtraDBCluster
+		err = c.kube.Get(ctx, params.Namespace, string(perconaXtraDBClusterKind), params.ClusterName, &cluster)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get XtraDB cluster %s", params.ClusterName)
+		}
+	}
+
+	res := &pxc.PerconaXtraDBClusterRestore{
+		TypeMeta: common.TypeMeta{
+			APIVersion: pxcRestoreAPIVersion,
+			Kind:       perconaXtraDBClusterRestoreKind,
+		},
+		ObjectMeta: common.ObjectMeta{
+			Name: name,
+		},
+		Spec: pxc.PerconaXtraDBClusterRestoreSpec{
+			PXCCluster: params.ClusterName,
+			BackupName: params.BackupName,
+			PITR:       pitr,
+		},
+	}
+	// The operator looks for backup storage in the restored cluster by name,
+	// so the backup of another cluster should be referenced by its location.
+	if backup.Spec.PXCCluster != params.ClusterName {
+		source := backup.Status
+		res.Spec.BackupSource = &source
+	}
+	if err = c.kube.Apply(ctx, params.Namespace, res); err != nil {
+		// don't leave an empty cluster created for the restore
+		if params.NewCluster != nil {
+			if deleteErr := c.DeleteXtraDBCluster(ctx, params.Namespace, params.ClusterName); deleteErr != nil {
+				c.l.Errorf("Cannot delete XtraDB cluster %s created for failed restore: %s.", params.ClusterName, deleteErr)
+			}
+		}
+		return errors.Wrapf(err, "cannot create restore %s", name)
+	}
+	return nil
+}
+
+// pxcRestorePITR returns point-in-time recovery of a restore from a given backup into a given cluster,
+// nil if params are nil.
+func pxcRestorePITR(params *XtraDBRestorePITR, backup *pxc.PerconaXtraDBClusterBackup, clusterName string) (*pxc.PITR, error) {
+	if params == nil {
+		return nil, nil
+	}
+	if strings.HasPrefix(backup.Status.Destination, pvcBackupDestinationPrefix) {
+		return nil, errors.Errorf("point-in-time recovery is not supported for filesystem backup %s", backup.Name)
+	}
+
+	res := &pxc.PITR{Type: string(params.Type)}
+	switch params.Type {
+	case PITRTypeDate:
+		if params.Date.IsZero() {
+			return nil, errors.New("date of point-in-time recovery is required")
+		}
+		res.Date = params.Date.UTC().Format(pitrDateFormat)
+	case PITRTypeTransaction:
+		if params.GTID == "" {
+			return nil, errors.New("GTID of point-in-time recovery is required")
+		}
+		res.GTID = params.GTID
+	case PITRTypeLatest:
+	default:
+		return nil, errors.Errorf("unknown point-in-time recovery type %q", params.Type)
+	}
+
+	// Binary logs are looked up in the restored cluster storage too,
+	// so logs of another cluster are expected next to its backup.
+	if backup.Spec.PXCCluster != clusterName {
+		res.BackupSource = &pxc.PXCBackupStatus{
+			StorageName: backup.Status.StorageName,
+			S3:          backup.Status.S3,
+			StorageType: backup.Status.StorageType,
+		}
+	}
+	return res, nil
+}
+
+// ListXtraDBClusterRestores returns restores of XtraDB clusters in a given namespace sorted by start time.
+// Empty clusterName means restores of all clusters.
+func (c *K8sClient) ListXtraDBClusterRestores(ctx context.Context, namespace, clusterName string) ([]XtraDBClusterRestore, error) {
+	var list pxc.PerconaXtraDBClusterRestoreList
+	err := c.kube.Get(ctx, namespace, perconaXtraDBClusterRestoreKind, "", &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get Percona XtraDB cluster restores")
+	}
+
+	res := make([]XtraDBClusterRestore, 0, len(list.Items))
+	for i := range list.Items {
+		restore := &list.Items[i]
+		if clusterName != "" && restore.Spec.PXCCluster != clusterName {
+			continue
+		}
+		res = append(res, getXtraDBClusterRestore(restore))
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].StartTime.Before(res[j].StartTime)
+	})
+	return res, nil
+}
+
+// getXtraDBClusterRestore converts XtraDB cluster restore custom resource.
+func getXtraDBClusterRestore(restore *pxc.PerconaXtraDBClusterRestore) XtraDBClusterRestore {
+	res := XtraDBClusterRestore{
+		Name:        restore.Name,
+		ClusterName: restore.Spec.PXCCluster,
+		BackupName:  restore.Spec.BackupName,
+		State:       getPXCRestoreState(restore.Status.State),
+		Message:     restoreMessage(string(restore.Status.State), restore.Status.State == pxc.RestoreNew, restore.Status.Comments),
+	}
+	if restore.CreationTimestamp != nil {
+		res.StartTime = *restore.CreationTimestamp
+	}
+	if restore.Status.Completed != nil {
+		res.FinishTime = *restore.Status.Completed
+	}
+	return res
+}
+
+func getPXCRestoreState(state pxc.BcpRestoreStates) RestoreState {
+	restoreState, ok := pxcRestoreStatesMap[state]
+	if !ok {
+		return RestoreStateInvalid
+	}
+	return restoreState
+}
+
+// setXtraDBRestoreStates changes state and message of clusters according to their latest restores.
+func setXtraDBRestoreStates(clusters []XtraDBCluster, restores []XtraDBClusterRestore) {
+	latest := make(map[string]XtraDBClusterRestore, len(restores))
+	for _, restore := range restores {
+		// restores are sorted by start time
+		latest[restore.ClusterName] = restore
+	}
+
+	for i, cluster := range clusters {
+		restore, ok := latest[cluster.Name]
+		if !ok {
+			continue
+		}
+		clusters[i].State, clusters[i].Message = clusterRestoreState(cluster.State, cluster.Message, restore.State, restore.BackupName, restore.Message)
+	}
+}
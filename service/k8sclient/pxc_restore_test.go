@@ -0,0 +1,203 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakeRestoreClient returns given objects and empty lists, applies restores with a given error
+// and records applied restore, applied and deleted clusters.
+type fakeRestoreClient struct {
+	fakeDetailsClient
+	restoreErr error
+	restore    *pxc.PerconaXtraDBClusterRestore
+	applied    []string
+	deleted    []string
+}
+
+func (f *fakeRestoreClient) Apply(ctx context.Context, namespace string, res interface{}) error {
+	if restore, ok := res.(*pxc.PerconaXtraDBClusterRestore); ok {
+		f.restore = restore
+		return f.restoreErr
+	}
+	if cluster, ok := res.(*pxc.PerconaXtraDBCluster); ok {
+		f.applied = append(f.applied, cluster.Kind+"/"+cluster.Name)
+	}
+	return nil
+}
+
+func (f *fakeRestoreClient) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
+	return []byte(`{"items": []}`), nil
+}
+
+func (f *fakeRestoreClient) Delete(ctx context.Context, namespace string, res interface{}) error {
+	if cluster, ok := res.(*pxc.PerconaXtraDBCluster); ok {
+		f.deleted = append(f.deleted, cluster.Kind+"/"+cluster.Name)
+	}
+	return nil
+}
+
+func TestRestoreXtraDBClusterCleanup(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	kube := &fakeRestoreClient{fakeDetailsClient: fakeDetailsClient{
+		objects: map[string]string{
+			perconaXtraDBClusterBackupKind + "/backup": `{
+				"metadata": {"name": "backup"},
+				"spec": {"pxcCluster": "source"},
+				"status": {"state": "Succeeded", "destination": "s3://bucket/backup"}
+			}`,
+		},
+	}, restoreErr: errors.New("quota exceeded")}
+	client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+	err := client.RestoreXtraDBCluster(ctx, &XtraDBRestoreParams{
+		Name:        "restore",
+		BackupName:  "backup",
+		ClusterName: "restored",
+		NewCluster: &XtraDBParams{
+			Size:    3,
+			PXC:     &PXC{DiskSize: "1G"},
+			HAProxy: &HAProxy{},
+		},
+	})
+	require.EqualError(t, err, "cannot create restore restore: quota exceeded")
+	assert.Equal(t, []string{"PerconaXtraDBCluster/restored"}, kube.applied)
+	assert.Equal(t, []string{"PerconaXtraDBCluster/restored"}, kube.deleted)
+}
+
+func TestRestoreXtraDBClusterPITR(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Date", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeRestoreClient{fakeDetailsClient: fakeDetailsClient{
+			objects: map[string]string{
+				perconaXtraDBClusterBackupKind + "/backup": `{
+					"metadata": {"name": "backup"},
+					"spec": {"pxcCluster": "source"},
+					"status": {
+						"state": "Succeeded",
+						"destination": "s3://bucket/backup",
+						"storageName": "s3-us-west",
+						"storage_type": "s3",
+						"s3": {"bucket": "bucket", "credentialsSecret": "s3-secret"}
+					}
+				}`,
+				string(perconaXtraDBClusterKind) + "/restored": `{"metadata": {"name": "restored"}}`,
+			},
+		}}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		err := client.RestoreXtraDBCluster(ctx, &XtraDBRestoreParams{
+			Name:        "restore",
+			BackupName:  "backup",
+			ClusterName: "restored",
+			PITR: &XtraDBRestorePITR{
+				Type: PITRTypeDate,
+				Date: time.Date(2021, 6, 1, 10, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, kube.restore)
+		pitr := kube.restore.Spec.PITR
+		require.NotNil(t, pitr)
+		assert.Equal(t, "date", pitr.Type)
+		assert.Equal(t, "2021-06-01 08:30:00", pitr.Date)
+		require.NotNil(t, pitr.BackupSource)
+		assert.Equal(t, "s3-us-west", pitr.BackupSource.StorageName)
+		assert.Equal(t, "bucket", pitr.BackupSource.S3.Bucket)
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		t.Parallel()
+
+		backup := &pxc.PerconaXtraDBClusterBackup{
+			Spec:   pxc.PXCBackupSpec{PXCCluster: "test"},
+			Status: pxc.PXCBackupStatus{Destination: "s3://bucket/backup"},
+		}
+		pitr, err := pxcRestorePITR(nil, backup, "test")
+		require.NoError(t, err)
+		assert.Nil(t, pitr)
+
+		pitr, err = pxcRestorePITR(&XtraDBRestorePITR{Type: PITRTypeTransaction, GTID: "uuid:1-100"}, backup, "test")
+		require.NoError(t, err)
+		assert.Equal(t, &pxc.PITR{Type: "transaction", GTID: "uuid:1-100"}, pitr)
+
+		_, err = pxcRestorePITR(&XtraDBRestorePITR{Type: PITRTypeTransaction}, backup, "test")
+		assert.EqualError(t, err, "GTID of point-in-time recovery is required")
+
+		_, err = pxcRestorePITR(&XtraDBRestorePITR{Type: PITRTypeDate}, backup, "test")
+		assert.EqualError(t, err, "date of point-in-time recovery is required")
+
+		_, err = pxcRestorePITR(&XtraDBRestorePITR{Type: "skip"}, backup, "test")
+		assert.EqualError(t, err, `unknown point-in-time recovery type "skip"`)
+
+		backup.Name = "fs"
+		backup.Status.Destination = "pvc/xb-fs"
+		_, err = pxcRestorePITR(&XtraDBRestorePITR{Type: PITRTypeLatest}, backup, "test")
+		assert.EqualError(t, err, "point-in-time recovery is not supported for filesystem backup fs")
+	})
+}
+
+func TestSetXtraDBRestoreStates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	clusters := []XtraDBCluster{
+		{Name: "restoring", State: ClusterStateReady},
+		{Name: "failed", State: ClusterStateChanging},
+		{Name: "failed-ready", State: ClusterStateReady},
+		{Name: "restored", State: ClusterStateReady},
+		{Name: "other", State: ClusterStateChanging, Message: "initializing"},
+	}
+	restores := []XtraDBClusterRestore{
+		{ClusterName: "restoring", BackupName: "b1", State: RestoreStateFailed, Message: "Failed", StartTime: now.Add(-time.Hour)},
+		{ClusterName: "restoring", BackupName: "b2", State: RestoreStateRunning, Message: "Restoring", StartTime: now},
+		{ClusterName: "failed", BackupName: "b3", State: RestoreStateFailed, Message: "Failed: no backup", StartTime: now},
+		{ClusterName: "failed-ready", BackupName: "b4", State: RestoreStateFailed, Message: "Failed", StartTime: now},
+		{ClusterName: "restored", BackupName: "b5", State: RestoreStateSucceeded, Message: "Succeeded", StartTime: now},
+	}
+	setXtraDBRestoreStates(clusters, restores)
+
+	assert.Equal(t, []XtraDBCluster{
+		{Name: "restoring", State: ClusterStateChanging, Message: "Restoring from backup b2: Restoring"},
+		{Name: "failed", State: ClusterStateFailed, Message: "Restore from backup b3 failed: Failed: no backup"},
+		{Name: "failed-ready", State: ClusterStateReady},
+		{Name: "restored", State: ClusterStateReady},
+		{Name: "other", State: ClusterStateChanging, Message: "initializing"},
+	}, clusters)
+}
+
+func TestRestoreMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Pending", restoreMessage("", true, ""))
+	assert.Equal(t, "Restore", restoreMessage("Restore", false, ""))
+	assert.Equal(t, "Failed: no backup", restoreMessage("Failed", false, "no backup"))
+}
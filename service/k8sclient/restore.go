@@ -0,0 +1,64 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import "fmt"
+
+// restorePendingMessage is a message of a restore which is not picked up by the operator yet.
+const restorePendingMessage = "Pending"
+
+// RestoreState represents database cluster restore state.
+type RestoreState int32
+
+const (
+	// RestoreStateInvalid represents unknown state.
+	RestoreStateInvalid RestoreState = 0
+	// RestoreStateRunning represents a restore in progress.
+	RestoreStateRunning RestoreState = 1
+	// RestoreStateSucceeded represents a finished restore.
+	RestoreStateSucceeded RestoreState = 2
+	// RestoreStateFailed represents a failed restore.
+	RestoreStateFailed RestoreState = 3
+)
+
+// restoreMessage returns a restore message from the operator state of the restore and its error message.
+// State of a new restore is reported as pending.
+func restoreMessage(state string, isNew bool, errorMessage string) string {
+	message := state
+	if isNew {
+		message = restorePendingMessage
+	}
+	if errorMessage != "" {
+		message += ": " + errorMessage
+	}
+	return message
+}
+
+// clusterRestoreState returns state and message of a cluster according to its latest restore.
+// Running restore makes cluster changing, failed restore makes not ready cluster failed.
+func clusterRestoreState(state ClusterState, message string, restore RestoreState, backupName, restoreMessage string) (ClusterState, string) {
+	switch restore {
+	case RestoreStateRunning:
+		return ClusterStateChanging, fmt.Sprintf("Restoring from backup %s: %s", backupName, restoreMessage)
+	case RestoreStateFailed:
+		if state != ClusterStateReady {
+			return ClusterStateFailed, fmt.Sprintf("Restore from backup %s failed: %s", backupName, restoreMessage)
+		}
+	case RestoreStateInvalid, RestoreStateSucceeded:
+	}
+	return state, message
+}
@@ -0,0 +1,133 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+)
+
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+
+	defaultReclaimPolicy     = "Delete"
+	defaultVolumeBindingMode = "Immediate"
+)
+
+// StorageClassInfo contains information about a storage class of Kubernetes cluster.
+type StorageClassInfo struct {
+	Name        string
+	Provisioner string
+	// Default is true for the storage class used by PVCs without storage class name.
+	Default bool
+	// ReclaimPolicy is Delete or Retain.
+	ReclaimPolicy string
+	// VolumeBindingMode is Immediate or WaitForFirstConsumer.
+	VolumeBindingMode    string
+	AllowVolumeExpansion bool
+}
+
+// isDefaultStorageClass returns true if a given storage class is marked as the default one.
+func isDefaultStorageClass(sc *common.StorageClass) bool {
+	return sc.Annotations[defaultStorageClassAnnotation] == "true" || sc.Annotations[betaDefaultStorageClassAnnotation] == "true"
+}
+
+// getStorageClassName returns storage class name of given volumes, empty name means the default one.
+func getStorageClassName(spec *common.VolumeSpec) string {
+	if spec == nil || spec.PersistentVolumeClaim == nil || spec.PersistentVolumeClaim.StorageClassName == nil {
+		return ""
+	}
+	return *spec.PersistentVolumeClaim.StorageClassName
+}
+
+// ListStorageClasses returns storage classes of Kubernetes cluster sorted by name.
+func (c *K8sClient) ListStorageClasses(ctx context.Context) ([]StorageClassInfo, error) {
+	var list common.StorageClassList
+	if err := c.kube.Get(ctx, "", "storageclass", "", &list); err != nil {
+		return nil, errors.Wrap(err, "cannot get storage classes")
+	}
+
+	res := make([]StorageClassInfo, 0, len(list.Items))
+	for i := range list.Items {
+		sc := &list.Items[i]
+		info := StorageClassInfo{
+			Name:              sc.Name,
+			Provisioner:       sc.Provisioner,
+			Default:           isDefaultStorageClass(sc),
+			ReclaimPolicy:     sc.ReclaimPolicy,
+			VolumeBindingMode: sc.VolumeBindingMode,
+		}
+		if info.ReclaimPolicy == "" {
+			info.ReclaimPolicy = defaultReclaimPolicy
+		}
+		if info.VolumeBindingMode == "" {
+			info.VolumeBindingMode = defaultVolumeBindingMode
+		}
+		if sc.AllowVolumeExpansion != nil {
+			info.AllowVolumeExpansion = *sc.AllowVolumeExpansion
+		}
+		res = append(res, info)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// getStorageClassByName returns storage class with a given name, nil name means the default storage class.
+func (c *K8sClient) getStorageClassByName(ctx context.Context, name *string) (*common.StorageClass, error) {
+	if name != nil {
+		var sc common.StorageClass
+		if err := c.kube.Get(ctx, "", "storageclass", *name, &sc); err != nil {
+			return nil, errors.Wrapf(err, "cannot get storage class %s", *name)
+		}
+		return &sc, nil
+	}
+
+	var list common.StorageClassList
+	if err := c.kube.Get(ctx, "", "storageclass", "", &list); err != nil {
+		return nil, errors.Wrap(err, "cannot get storage classes")
+	}
+	for i := range list.Items {
+		if isDefaultStorageClass(&list.Items[i]) {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, errors.New("there is no default storage class")
+}
+
+// checkStorageClasses checks that storage classes with given names exist, empty names are skipped.
+func (c *K8sClient) checkStorageClasses(ctx context.Context, names ...string) error {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		var sc common.StorageClass
+		err := c.kube.Get(ctx, "", "storageclass", name, &sc)
+		if errors.Is(err, kubectl.ErrNotFound) {
+			return errors.Errorf("storage class %s doesn't exist", name)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot get storage class %s", name)
+		}
+	}
+	return nil
+}
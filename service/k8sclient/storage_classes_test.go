@@ -0,0 +1,99 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+func TestStorageClasses(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	kube := &fakeDetailsClient{
+		objects: map[string]string{
+			"storageclass/": `{"items": [
+				{
+					"metadata": {"name": "standard", "annotations": {"storageclass.kubernetes.io/is-default-class": "true"}},
+					"provisioner": "kubernetes.io/gce-pd",
+					"reclaimPolicy": "Delete",
+					"volumeBindingMode": "Immediate",
+					"allowVolumeExpansion": true
+				},
+				{
+					"metadata": {"name": "premium-rwo"},
+					"provisioner": "pd.csi.storage.gke.io",
+					"reclaimPolicy": "Retain",
+					"volumeBindingMode": "WaitForFirstConsumer"
+				}
+			]}`,
+			"storageclass/standard": `{"metadata": {"name": "standard"}}`,
+		},
+	}
+	client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+
+		classes, err := client.ListStorageClasses(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []StorageClassInfo{
+			{
+				Name:              "premium-rwo",
+				Provisioner:       "pd.csi.storage.gke.io",
+				ReclaimPolicy:     "Retain",
+				VolumeBindingMode: "WaitForFirstConsumer",
+			},
+			{
+				Name:                 "standard",
+				Provisioner:          "kubernetes.io/gce-pd",
+				Default:              true,
+				ReclaimPolicy:        "Delete",
+				VolumeBindingMode:    "Immediate",
+				AllowVolumeExpansion: true,
+			},
+		}, classes)
+
+		sc, err := client.getStorageClassByName(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "standard", sc.Name)
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, client.checkStorageClasses(ctx, "", "standard"))
+		assert.EqualError(t, client.checkStorageClasses(ctx, "fast"), "storage class fast doesn't exist")
+	})
+
+	t.Run("VolumeSpec", func(t *testing.T) {
+		t.Parallel()
+
+		spec := client.volumeSpec("10Gi", "premium-rwo")
+		assert.Equal(t, "premium-rwo", getStorageClassName(spec))
+		assert.Equal(t, "10Gi", client.getDiskSize(spec))
+
+		assert.Nil(t, client.volumeSpec("10Gi", "").PersistentVolumeClaim.StorageClassName)
+		assert.Empty(t, getStorageClassName(nil))
+	})
+}
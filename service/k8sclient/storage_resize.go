@@ -0,0 +1,326 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+	"github.com/percona-platform/dbaas-controller/utils/convertors"
+)
+
+// Names of volume claim templates of operators' statefulsets.
+const (
+	pxcDataClaimName      = "datadir"
+	proxySQLDataClaimName = "proxydata"
+	psmdbDataClaimName    = "mongod-data"
+)
+
+var (
+	// ErrDiskShrink is returned when requested disk size is less than the current one.
+	ErrDiskShrink = errors.New("disk size can't be decreased")
+	// ErrVolumeExpansionNotAllowed is returned when storage class of cluster volumes doesn't allow expansion.
+	ErrVolumeExpansionNotAllowed = errors.New("storage class doesn't allow volume expansion")
+)
+
+// VolumeResizeStatus contains resize progress of a cluster volume.
+type VolumeResizeStatus struct {
+	PVC string
+	// Requested is a requested size of the volume.
+	Requested string
+	// Capacity is an actual size of the volume.
+	Capacity string
+	// Message explains why the resize is not done yet, like a pending file system resize.
+	Message string
+	// Done is true when the volume has requested size.
+	Done bool
+}
+
+// volumeResize is a resize of volumes of a cluster statefulset.
+type volumeResize struct {
+	statefulSet string
+	size        string
+	pvcs        []string
+}
+
+// pvcNameRe returns a regexp matching names of PVCs created by a given statefulset from a given claim template.
+// Pod ordinal suffix is matched exactly, so PVCs of other clusters with names starting with the same prefix are skipped.
+func pvcNameRe(claim, statefulSet string) *regexp.Regexp {
+	return regexp.MustCompile("^" + regexp.QuoteMeta(claim+"-"+statefulSet) + `-\d+$`)
+}
+
+// needsResize returns true if requested disk size is greater than the current one.
+func needsResize(current, requested string) (bool, error) {
+	currentBytes, err := convertors.StrToBytes(current)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot parse current disk size %s", current)
+	}
+	requestedBytes, err := convertors.StrToBytes(requested)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot parse disk size %s", requested)
+	}
+	if requestedBytes < currentBytes {
+		return false, errors.Wrapf(ErrDiskShrink, "requested %s, current %s", requested, current)
+	}
+	return requestedBytes > currentBytes, nil
+}
+
+// getPVCs returns PVCs of a given statefulset created from a given claim template sorted by name.
+func (c *K8sClient) getPVCs(ctx context.Context, namespace, claim, statefulSet string) ([]common.PersistentVolumeClaim, error) {
+	var list common.PersistentVolumeClaimList
+	if err := c.kube.Get(ctx, namespace, "pvc", "", &list); err != nil {
+		return nil, errors.Wrap(err, "cannot get persistent volume claims")
+	}
+	re := pvcNameRe(claim, statefulSet)
+	var res []common.PersistentVolumeClaim
+	for _, pvc := range list.Items {
+		if re.MatchString(pvc.Name) {
+			res = append(res, pvc)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// prepareVolumeResize checks that volumes of a given statefulset can be resized to a given size
+// and sets that size in a given volume spec. Volumes are compared with the size by their requests,
+// not by the spec, so resize of volumes left behind by a failed update is retried.
+// It returns nil if all volumes have that size already.
+func (c *K8sClient) prepareVolumeResize(ctx context.Context, namespace, statefulSet, claim string, spec *common.VolumeSpec, size string) (*volumeResize, error) {
+	if spec == nil || spec.PersistentVolumeClaim == nil {
+		return nil, errors.Errorf("%s doesn't use persistent volumes", statefulSet)
+	}
+	if _, err := needsResize(c.getDiskSize(spec), size); err != nil {
+		return nil, err
+	}
+
+	pvcs, err := c.getPVCs(ctx, namespace, claim, statefulSet)
+	if err != nil {
+		return nil, err
+	}
+	if len(pvcs) == 0 {
+		return nil, errors.Errorf("no persistent volume claims of %s", statefulSet)
+	}
+
+	res := &volumeResize{statefulSet: statefulSet, size: size}
+	checked := make(map[string]struct{})
+	for _, pvc := range pvcs {
+		ok, err := needsResize(pvc.Spec.Resources.Requests[common.ResourceStorage], size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot resize %s", pvc.Name)
+		}
+		if !ok {
+			continue
+		}
+		res.pvcs = append(res.pvcs, pvc.Name)
+
+		var className string
+		if pvc.Spec.StorageClassName != nil {
+			className = *pvc.Spec.StorageClassName
+		}
+		if _, ok := checked[className]; ok {
+			continue
+		}
+		sc, err := c.getStorageClassByName(ctx, pvc.Spec.StorageClassName)
+		if err != nil {
+			return nil, err
+		}
+		if sc.AllowVolumeExpansion == nil || !*sc.AllowVolumeExpansion {
+			return nil, errors.Wrapf(ErrVolumeExpansionNotAllowed, "storage class %s of %s", sc.Name, pvc.Name)
+		}
+		checked[className] = struct{}{}
+	}
+
+	spec.PersistentVolumeClaim.Resources.Requests[common.ResourceStorage] = size
+	if len(res.pvcs) == 0 {
+		return nil, nil
+	}
+	return res, nil
+}
+
+// resizeVolumes resizes PVCs after the cluster custom resource is updated.
+// Volume claim templates of a statefulset can't be changed, so the statefulset is deleted
+// leaving its pods running, and the operator creates it again with the new size.
+// --cascade=false is used instead of --cascade=orphan supported by kubectl 1.20+ only.
+func (c *K8sClient) resizeVolumes(ctx context.Context, namespace string, resizes []*volumeResize) error {
+	for _, resize := range resizes {
+		args := withNamespace([]string{"delete", "statefulset", resize.statefulSet, "--cascade=false"}, namespace)
+		if _, err := c.kube.Run(ctx, args, nil); err != nil {
+			return errors.Wrapf(err, "cannot delete statefulset %s", resize.statefulSet)
+		}
+
+		patch, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"resources": map[string]interface{}{
+					"requests": map[string]string{string(common.ResourceStorage): resize.size},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		for _, pvc := range resize.pvcs {
+			args = withNamespace([]string{"patch", "pvc", pvc, "--type=merge", "--patch=" + string(patch)}, namespace)
+			if _, err = c.kube.Run(ctx, args, nil); err != nil {
+				return errors.Wrapf(err, "cannot resize %s", pvc)
+			}
+			c.l.Infof("Resizing %s to %s.", pvc, resize.size)
+		}
+	}
+	return nil
+}
+
+// getVolumesResizeStatus returns resize progress of PVCs of a given statefulset created from a given claim template.
+func (c *K8sClient) getVolumesResizeStatus(ctx context.Context, namespace, claim, statefulSet string) ([]VolumeResizeStatus, error) {
+	pvcs, err := c.getPVCs(ctx, namespace, claim, statefulSet)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]VolumeResizeStatus, 0, len(pvcs))
+	for _, pvc := range pvcs {
+		status := VolumeResizeStatus{
+			PVC:       pvc.Name,
+			Requested: pvc.Spec.Resources.Requests[common.ResourceStorage],
+			Capacity:  pvc.Status.Capacity.Storage,
+		}
+		for _, condition := range pvc.Status.Conditions {
+			if condition.Status != "True" {
+				continue
+			}
+			switch condition.Type {
+			case common.PersistentVolumeClaimResizing:
+				status.Message = "Volume is being resized."
+			case common.PersistentVolumeClaimFileSystemResizePending:
+				status.Message = "Waiting for file system resize on pod restart."
+			}
+			if condition.Message != "" {
+				status.Message = condition.Message
+			}
+		}
+		if status.Message == "" {
+			ok, err := needsResize(status.Capacity, status.Requested)
+			switch {
+			case err != nil:
+				status.Message = err.Error()
+			case ok:
+				status.Message = "Waiting for volume resize to start."
+			default:
+				status.Done = true
+			}
+		}
+		res = append(res, status)
+	}
+	return res, nil
+}
+
+// prepareXtraDBVolumesResize checks and sets new disk sizes of PXC and ProxySQL.
+func (c *K8sClient) prepareXtraDBVolumesResize(ctx context.Context, cluster *pxc.PerconaXtraDBCluster, params *XtraDBParams) ([]*volumeResize, error) {
+	var res []*volumeResize
+	if params.PXC != nil && params.PXC.DiskSize != "" {
+		resize, err := c.prepareVolumeResize(ctx, params.Namespace, params.Name+"-pxc", pxcDataClaimName,
+			cluster.Spec.PXC.VolumeSpec, params.PXC.DiskSize)
+		if err != nil {
+			return nil, err
+		}
+		if resize != nil {
+			res = append(res, resize)
+		}
+	}
+	if params.ProxySQL != nil && params.ProxySQL.DiskSize != "" && cluster.Spec.ProxySQL != nil {
+		resize, err := c.prepareVolumeResize(ctx, params.Namespace, params.Name+"-proxysql", proxySQLDataClaimName,
+			cluster.Spec.ProxySQL.VolumeSpec, params.ProxySQL.DiskSize)
+		if err != nil {
+			return nil, err
+		}
+		if resize != nil {
+			res = append(res, resize)
+		}
+	}
+	return res, nil
+}
+
+// preparePSMDBVolumesResize checks and sets new disk sizes of existing replica sets.
+// Without Shards, Replicaset disk size is applied to all replica sets.
+func (c *K8sClient) preparePSMDBVolumesResize(ctx context.Context, cluster *psmdb.PerconaServerMongoDB, params *PSMDBParams) ([]*volumeResize, error) {
+	sizes := make(map[string]string)
+	for _, rs := range cluster.Spec.Replsets {
+		if len(params.Shards) == 0 && params.Replicaset != nil && params.Replicaset.DiskSize != "" {
+			sizes[rs.Name] = params.Replicaset.DiskSize
+		}
+	}
+	for _, shard := range params.Shards {
+		if shard.DiskSize != "" {
+			sizes[shard.Name] = shard.DiskSize
+		}
+	}
+
+	var res []*volumeResize
+	for _, rs := range cluster.Spec.Replsets {
+		size, ok := sizes[rs.Name]
+		if !ok {
+			continue
+		}
+		resize, err := c.prepareVolumeResize(ctx, params.Namespace, fmt.Sprintf("%s-%s", params.Name, rs.Name), psmdbDataClaimName,
+			rs.VolumeSpec, size)
+		if err != nil {
+			return nil, err
+		}
+		if resize != nil {
+			res = append(res, resize)
+		}
+	}
+	return res, nil
+}
+
+// GetXtraDBClusterVolumesResizeStatus returns resize progress of PXC and ProxySQL volumes of a given cluster.
+func (c *K8sClient) GetXtraDBClusterVolumesResizeStatus(ctx context.Context, namespace, name string) ([]VolumeResizeStatus, error) {
+	res, err := c.getVolumesResizeStatus(ctx, namespace, pxcDataClaimName, name+"-pxc")
+	if err != nil {
+		return nil, err
+	}
+	proxySQL, err := c.getVolumesResizeStatus(ctx, namespace, proxySQLDataClaimName, name+"-proxysql")
+	if err != nil {
+		return nil, err
+	}
+	return append(res, proxySQL...), nil
+}
+
+// GetPSMDBClusterVolumesResizeStatus returns resize progress of replica set volumes of a given cluster.
+func (c *K8sClient) GetPSMDBClusterVolumesResizeStatus(ctx context.Context, namespace, name string) ([]VolumeResizeStatus, error) {
+	var cluster psmdb.PerconaServerMongoDB
+	if err := c.kube.Get(ctx, namespace, string(perconaServerMongoDBKind), name, &cluster); err != nil {
+		return nil, errors.Wrap(err, "cannot get PSMDB cluster")
+	}
+	var res []VolumeResizeStatus
+	for _, rs := range cluster.Spec.Replsets {
+		statuses, err := c.getVolumesResizeStatus(ctx, namespace, psmdbDataClaimName, fmt.Sprintf("%s-%s", name, rs.Name))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, statuses...)
+	}
+	return res, nil
+}
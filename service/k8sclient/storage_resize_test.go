@@ -0,0 +1,182 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// fakeResizeClient returns given objects and records applied objects and commands.
+type fakeResizeClient struct {
+	fakeDetailsClient
+	applied []interface{}
+	cmds    []string
+}
+
+func (f *fakeResizeClient) Apply(ctx context.Context, namespace string, res interface{}) error {
+	f.applied = append(f.applied, res)
+	return nil
+}
+
+func (f *fakeResizeClient) Run(ctx context.Context, args []string, stdin interface{}) ([]byte, error) {
+	f.cmds = append(f.cmds, strings.Join(args, " "))
+	return nil, nil
+}
+
+func newFakeResizeClient(allowVolumeExpansion, diskSize string) *fakeResizeClient {
+	return &fakeResizeClient{
+		fakeDetailsClient: fakeDetailsClient{
+			objects: map[string]string{
+				string(perconaXtraDBClusterKind) + "/test": `{
+					"metadata": {"name": "test"},
+					"spec": {
+						"pxc": {"size": 1, "volumeSpec": {"persistentVolumeClaim": {"resources": {"requests": {"storage": "` + diskSize + `"}}}}},
+						"haproxy": {"enabled": true, "size": 1}
+					},
+					"status": {"state": "ready", "pxc": {"status": "ready"}}
+				}`,
+				"pvc/": `{"items": [
+					{"metadata": {"name": "datadir-test-pxc-0"}, "spec": {"storageClassName": "gp2", "resources": {"requests": {"storage": "10Gi"}}}},
+					{"metadata": {"name": "datadir-other-pxc-0"}, "spec": {"storageClassName": "gp2", "resources": {"requests": {"storage": "10Gi"}}}},
+					{"metadata": {"name": "datadir-test-pxc-2-pxc-0"}, "spec": {"storageClassName": "gp2", "resources": {"requests": {"storage": "10Gi"}}}}
+				]}`,
+				"storageclass/gp2": `{"metadata": {"name": "gp2"}, "allowVolumeExpansion": ` + allowVolumeExpansion + `}`,
+			},
+		},
+	}
+}
+
+func TestStorageResize(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("NeedsResize", func(t *testing.T) {
+		t.Parallel()
+
+		ok, err := needsResize("10Gi", "20Gi")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = needsResize("10Gi", "10Gi")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = needsResize("10Gi", "5Gi")
+		assert.True(t, errors.Is(err, ErrDiskShrink))
+	})
+
+	t.Run("Resize", func(t *testing.T) {
+		t.Parallel()
+
+		kube := newFakeResizeClient("true", "10Gi")
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		err := client.UpdateXtraDBCluster(ctx, &XtraDBParams{Name: "test", PXC: &PXC{DiskSize: "20Gi"}})
+		require.NoError(t, err)
+
+		require.Len(t, kube.applied, 1)
+		cluster := kube.applied[0].(*pxc.PerconaXtraDBCluster)
+		assert.Equal(t, "20Gi", client.getDiskSize(cluster.Spec.PXC.VolumeSpec))
+		assert.Equal(t, []string{
+			"delete statefulset test-pxc --cascade=false",
+			`patch pvc datadir-test-pxc-0 --type=merge --patch={"spec":{"resources":{"requests":{"storage":"20Gi"}}}}`,
+		}, kube.cmds)
+	})
+
+	t.Run("RetryFailedResize", func(t *testing.T) {
+		t.Parallel()
+
+		// the custom resource has the new size already, but volumes don't
+		kube := newFakeResizeClient("true", "20Gi")
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		err := client.UpdateXtraDBCluster(ctx, &XtraDBParams{Name: "test", PXC: &PXC{DiskSize: "20Gi"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"delete statefulset test-pxc --cascade=false",
+			`patch pvc datadir-test-pxc-0 --type=merge --patch={"spec":{"resources":{"requests":{"storage":"20Gi"}}}}`,
+		}, kube.cmds)
+	})
+
+	t.Run("NoResize", func(t *testing.T) {
+		t.Parallel()
+
+		kube := newFakeResizeClient("true", "10Gi")
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		err := client.UpdateXtraDBCluster(ctx, &XtraDBParams{Name: "test", PXC: &PXC{DiskSize: "10Gi"}})
+		require.NoError(t, err)
+		assert.Empty(t, kube.cmds)
+	})
+
+	t.Run("Shrink", func(t *testing.T) {
+		t.Parallel()
+
+		kube := newFakeResizeClient("true", "10Gi")
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		err := client.UpdateXtraDBCluster(ctx, &XtraDBParams{Name: "test", PXC: &PXC{DiskSize: "5Gi"}})
+		assert.True(t, errors.Is(err, ErrDiskShrink))
+		assert.Empty(t, kube.applied)
+		assert.Empty(t, kube.cmds)
+	})
+
+	t.Run("ExpansionNotAllowed", func(t *testing.T) {
+		t.Parallel()
+
+		kube := newFakeResizeClient("false", "10Gi")
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		err := client.UpdateXtraDBCluster(ctx, &XtraDBParams{Name: "test", PXC: &PXC{DiskSize: "20Gi"}})
+		assert.True(t, errors.Is(err, ErrVolumeExpansionNotAllowed))
+		assert.Empty(t, kube.applied)
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		t.Parallel()
+
+		kube := newFakeResizeClient("true", "10Gi")
+		kube.objects["pvc/"] = `{"items": [
+			{
+				"metadata": {"name": "datadir-test-pxc-0"},
+				"spec": {"resources": {"requests": {"storage": "20Gi"}}},
+				"status": {"capacity": {"storage": "10Gi"}, "conditions": [{"type": "FileSystemResizePending", "status": "True"}]}
+			},
+			{
+				"metadata": {"name": "datadir-test-pxc-1"},
+				"spec": {"resources": {"requests": {"storage": "20Gi"}}},
+				"status": {"capacity": {"storage": "20Gi"}}
+			}
+		]}`
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		statuses, err := client.GetXtraDBClusterVolumesResizeStatus(ctx, "", "test")
+		require.NoError(t, err)
+		assert.Equal(t, []VolumeResizeStatus{
+			{
+				PVC:       "datadir-test-pxc-0",
+				Requested: "20Gi",
+				Capacity:  "10Gi",
+				Message:   "Waiting for file system resize on pod restart.",
+			},
+			{PVC: "datadir-test-pxc-1", Requested: "20Gi", Capacity: "20Gi", Done: true},
+		}, statuses)
+	})
+}
@@ -0,0 +1,125 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/kubectl"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+const (
+	// Operators use these secret names by default and generate certificates if the secrets do not exist.
+	sslSecretNameTmpl         = "%s-ssl"
+	sslInternalSecretNameTmpl = "%s-ssl-internal"
+
+	tlsCAKey   = "ca.crt"
+	tlsCertKey = "tls.crt"
+	tlsKeyKey  = "tls.key"
+)
+
+// TLSIssuer is a reference to a cert-manager issuer.
+type TLSIssuer struct {
+	Name string
+	// Kind is Issuer or ClusterIssuer, empty kind means Issuer.
+	Kind string
+	// Group of the issuer, empty group means cert-manager.io.
+	Group string
+}
+
+// TLSParams contains TLS configuration of a cluster.
+// Certificates are generated by the operator if neither certificates nor issuer are set.
+type TLSParams struct {
+	// CA, Cert and Key are PEM encoded user supplied certificates.
+	// They are used for both client and internal cluster connections.
+	CA   []byte
+	Cert []byte
+	Key  []byte
+	// Issuer is used to issue certificates by cert-manager.
+	Issuer *TLSIssuer
+	// SANs are additional subject alternative names of certificates issued by cert-manager.
+	SANs []string
+}
+
+// validate checks that TLS parameters are consistent.
+func (p *TLSParams) validate() error {
+	userSupplied := len(p.CA) != 0 || len(p.Cert) != 0 || len(p.Key) != 0
+	if userSupplied && (len(p.CA) == 0 || len(p.Cert) == 0 || len(p.Key) == 0) {
+		return errors.New("TLS CA, certificate and key should be set together")
+	}
+	if userSupplied && p.Issuer != nil {
+		return errors.New("TLS certificates and cert-manager issuer can't be set together")
+	}
+	if p.Issuer != nil && p.Issuer.Name == "" {
+		return errors.New("cert-manager issuer name should be set")
+	}
+	return nil
+}
+
+// userSupplied returns true if certificates are supplied by user.
+func (p *TLSParams) userSupplied() bool {
+	return len(p.Cert) != 0
+}
+
+// pxcSpec returns XtraDB cluster TLS spec, it is nil if cert-manager is not used.
+func (p *TLSParams) pxcSpec() *pxc.TLSSpec {
+	if p.Issuer == nil {
+		return nil
+	}
+	return &pxc.TLSSpec{
+		SANs: p.SANs,
+		IssuerConf: &pxc.ObjectReference{
+			Name:  p.Issuer.Name,
+			Kind:  p.Issuer.Kind,
+			Group: p.Issuer.Group,
+		},
+	}
+}
+
+// createTLSSecrets creates secrets with user supplied certificates for a given cluster.
+func (c *K8sClient) createTLSSecrets(ctx context.Context, namespace, name string, p *TLSParams) error {
+	data := map[string][]byte{
+		tlsCAKey:   p.CA,
+		tlsCertKey: p.Cert,
+		tlsKeyKey:  p.Key,
+	}
+	for _, tmpl := range []string{sslSecretNameTmpl, sslInternalSecretNameTmpl} {
+		if err := c.CreateSecret(ctx, namespace, fmt.Sprintf(tmpl, name), data); err != nil {
+			return errors.Wrap(err, "cannot create secret for TLS certificates")
+		}
+	}
+	return nil
+}
+
+// getCABundle returns PEM encoded CA certificate from a given TLS secret.
+// Empty bundle is returned if the secret does not exist yet.
+func (c *K8sClient) getCABundle(ctx context.Context, namespace, secretName string) (string, error) {
+	var secret common.Secret
+	err := c.kube.Get(ctx, namespace, k8sMetaKindSecret, secretName, &secret)
+	if errors.Is(err, kubectl.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "cannot get TLS secret")
+	}
+	return string(secret.Data[tlsCAKey]), nil
+}
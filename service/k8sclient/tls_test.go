@@ -0,0 +1,104 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Validate", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tt := range []struct {
+			name   string
+			params TLSParams
+			err    string
+		}{
+			{"OperatorGenerated", TLSParams{}, ""},
+			{"UserSupplied", TLSParams{CA: []byte("ca"), Cert: []byte("cert"), Key: []byte("key")}, ""},
+			{"Issuer", TLSParams{Issuer: &TLSIssuer{Name: "issuer"}, SANs: []string{"db.example.com"}}, ""},
+			{"NoKey", TLSParams{CA: []byte("ca"), Cert: []byte("cert")}, "TLS CA, certificate and key should be set together"},
+			{
+				"CertsAndIssuer",
+				TLSParams{CA: []byte("ca"), Cert: []byte("cert"), Key: []byte("key"), Issuer: &TLSIssuer{Name: "issuer"}},
+				"TLS certificates and cert-manager issuer can't be set together",
+			},
+			{"NoIssuerName", TLSParams{Issuer: &TLSIssuer{Kind: "ClusterIssuer"}}, "cert-manager issuer name should be set"},
+		} {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+				err := tt.params.validate()
+				if tt.err == "" {
+					assert.NoError(t, err)
+					return
+				}
+				assert.EqualError(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("PXCSpec", func(t *testing.T) {
+		t.Parallel()
+
+		params := &TLSParams{CA: []byte("ca"), Cert: []byte("cert"), Key: []byte("key")}
+		assert.Nil(t, params.pxcSpec())
+
+		params = &TLSParams{Issuer: &TLSIssuer{Name: "issuer", Kind: "ClusterIssuer"}, SANs: []string{"db.example.com"}}
+		assert.Equal(t, &pxc.TLSSpec{
+			SANs:       []string{"db.example.com"},
+			IssuerConf: &pxc.ObjectReference{Name: "issuer", Kind: "ClusterIssuer"},
+		}, params.pxcSpec())
+	})
+
+	t.Run("CredentialsCABundle", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeDetailsClient{
+			objects: map[string]string{
+				string(perconaXtraDBClusterKind) + "/test": `{
+					"metadata": {"name": "test"},
+					"spec": {"sslSecretName": "test-ssl"},
+					"status": {"state": "ready", "host": "test-haproxy.default"}
+				}`,
+				k8sMetaKindSecret + "/dbaas-test-pxc-secrets": `{"data": {"root": "cm9vdA=="}}`,
+				k8sMetaKindSecret + "/test-ssl":               `{"data": {"ca.crt": "Y2E="}}`,
+			},
+		}
+		client := &K8sClient{kube: kube, l: logger.Get(ctx)}
+		credentials, err := client.GetXtraDBClusterCredentials(ctx, "", "test")
+		require.NoError(t, err)
+		assert.Equal(t, "root", credentials.Password)
+		assert.Equal(t, "ca", credentials.CABundle)
+
+		delete(kube.objects, k8sMetaKindSecret+"/test-ssl")
+		credentials, err = client.GetXtraDBClusterCredentials(ctx, "", "test")
+		require.NoError(t, err)
+		assert.Empty(t, credentials.CABundle)
+	})
+}
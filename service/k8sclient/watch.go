@@ -0,0 +1,376 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/psmdb"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/internal/pxc"
+)
+
+// ClusterEventType is a type of cluster event.
+type ClusterEventType string
+
+const (
+	// ClusterEventChanged is sent for all clusters when the watch starts
+	// and when cluster state or running operation progress is changed.
+	ClusterEventChanged ClusterEventType = "changed"
+	// ClusterEventDeleted is sent when cluster and all its pods are deleted.
+	ClusterEventDeleted ClusterEventType = "deleted"
+)
+
+// ClusterEvent contains cluster state and progress of a running operation.
+type ClusterEvent struct {
+	Type  ClusterEventType
+	Name  string
+	State ClusterState
+	Pause bool
+	// FinishedSteps and TotalSteps are ready and total pods of the cluster, the same as in cluster lists.
+	FinishedSteps int32
+	TotalSteps    int32
+	Message       string
+}
+
+// clusterEventFunc returns cluster event for a given custom resource and its pods.
+type clusterEventFunc func(cluster json.RawMessage, pods []common.Pod) (*ClusterEvent, error)
+
+// watchedRestore contains fields of a cluster restore which change cluster events.
+type watchedRestore struct {
+	name        string
+	clusterName string
+	backupName  string
+	state       RestoreState
+	message     string
+	startTime   time.Time
+}
+
+// clusterRestoreFunc returns restore for a given restore custom resource.
+type clusterRestoreFunc func(restore json.RawMessage) (*watchedRestore, error)
+
+// clusterWatcher keeps the last known state of cluster custom resources, their pods and restores,
+// and returns cluster events when they are changed.
+type clusterWatcher struct {
+	clusterEvent   clusterEventFunc
+	clusterRestore clusterRestoreFunc
+	clusters       map[string]json.RawMessage
+	// seen contains names of clusters which custom resources were seen, including deleted ones.
+	seen     map[string]struct{}
+	pods     map[string]map[string]common.Pod
+	restores map[string]watchedRestore
+	sent     map[string]ClusterEvent
+}
+
+// newClusterWatcher returns new clusterWatcher.
+func newClusterWatcher(clusterEvent clusterEventFunc, clusterRestore clusterRestoreFunc) *clusterWatcher {
+	return &clusterWatcher{
+		clusterEvent:   clusterEvent,
+		clusterRestore: clusterRestore,
+		clusters:       make(map[string]json.RawMessage),
+		seen:           make(map[string]struct{}),
+		pods:           make(map[string]map[string]common.Pod),
+		restores:       make(map[string]watchedRestore),
+		sent:           make(map[string]ClusterEvent),
+	}
+}
+
+// watchEventError returns an error for ERROR watch events.
+func watchEventError(event common.WatchEvent) error {
+	var status struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(event.Object, &status); err != nil || status.Message == "" {
+		return errors.Errorf("watch failed: %s", event.Object)
+	}
+	return errors.Errorf("watch failed: %s", status.Message)
+}
+
+// onClusterEvent handles watch event of a cluster custom resource.
+func (w *clusterWatcher) onClusterEvent(event common.WatchEvent) (*ClusterEvent, error) {
+	switch event.Type {
+	case common.WatchEventBookmark:
+		return nil, nil
+	case common.WatchEventError:
+		return nil, watchEventError(event)
+	}
+
+	var obj struct {
+		common.ObjectMeta `json:"metadata"`
+	}
+	if err := json.Unmarshal(event.Object, &obj); err != nil {
+		return nil, errors.Wrap(err, "cannot decode cluster")
+	}
+	if event.Type == common.WatchEventDeleted {
+		delete(w.clusters, obj.Name)
+	} else {
+		w.clusters[obj.Name] = event.Object
+		w.seen[obj.Name] = struct{}{}
+	}
+	return w.update(obj.Name)
+}
+
+// onRestoreEvent handles watch event of a cluster restore custom resource.
+func (w *clusterWatcher) onRestoreEvent(event common.WatchEvent) (*ClusterEvent, error) {
+	switch event.Type {
+	case common.WatchEventBookmark:
+		return nil, nil
+	case common.WatchEventError:
+		return nil, watchEventError(event)
+	}
+
+	restore, err := w.clusterRestore(event.Object)
+	if err != nil {
+		return nil, err
+	}
+	if event.Type == common.WatchEventDeleted {
+		delete(w.restores, restore.name)
+	} else {
+		w.restores[restore.name] = *restore
+	}
+	return w.update(restore.clusterName)
+}
+
+// latestRestore returns the latest restore of a given cluster, nil if there are no restores.
+// Restores with the same start time are ordered by name as in restore lists.
+func (w *clusterWatcher) latestRestore(name string) *watchedRestore {
+	var res *watchedRestore
+	for _, restore := range w.restores {
+		if restore.clusterName != name {
+			continue
+		}
+		if res == nil || restore.startTime.After(res.startTime) ||
+			(restore.startTime.Equal(res.startTime) && restore.name > res.name) {
+			restore := restore
+			res = &restore
+		}
+	}
+	return res
+}
+
+// onPodEvent handles watch event of a cluster pod.
+func (w *clusterWatcher) onPodEvent(event common.WatchEvent) (*ClusterEvent, error) {
+	switch event.Type {
+	case common.WatchEventBookmark:
+		return nil, nil
+	case common.WatchEventError:
+		return nil, watchEventError(event)
+	}
+
+	var pod common.Pod
+	if err := json.Unmarshal(event.Object, &pod); err != nil {
+		return nil, errors.Wrap(err, "cannot decode pod")
+	}
+	name := pod.Labels["app.kubernetes.io/instance"]
+	if name == "" {
+		return nil, nil
+	}
+	if event.Type == common.WatchEventDeleted {
+		delete(w.pods[name], pod.Name)
+	} else {
+		if w.pods[name] == nil {
+			w.pods[name] = make(map[string]common.Pod)
+		}
+		w.pods[name][pod.Name] = pod
+	}
+	return w.update(name)
+}
+
+// update returns event for a given cluster if it is changed since the last sent event.
+func (w *clusterWatcher) update(name string) (*ClusterEvent, error) {
+	podNames := make([]string, 0, len(w.pods[name]))
+	for podName := range w.pods[name] {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+	pods := make([]common.Pod, len(podNames))
+	for i, podName := range podNames {
+		pods[i] = w.pods[name][podName]
+	}
+
+	var event *ClusterEvent
+	cluster, ok := w.clusters[name]
+	switch {
+	case ok:
+		var err error
+		if event, err = w.clusterEvent(cluster, pods); err != nil {
+			return nil, err
+		}
+		event.Type = ClusterEventChanged
+		// the same restore state as in cluster lists
+		if restore := w.latestRestore(name); restore != nil {
+			event.State, event.Message = clusterRestoreState(event.State, event.Message, restore.state, restore.backupName, restore.message)
+		}
+	case len(pods) != 0:
+		// pods of a new cluster could be seen before its custom resource
+		if _, ok := w.seen[name]; !ok {
+			return nil, nil
+		}
+		// the same as deleting clusters in cluster lists
+		event = &ClusterEvent{Type: ClusterEventChanged, Name: name, State: ClusterStateDeleting}
+	default:
+		delete(w.pods, name)
+		delete(w.seen, name)
+		if _, ok := w.sent[name]; !ok {
+			return nil, nil
+		}
+		delete(w.sent, name)
+		return &ClusterEvent{Type: ClusterEventDeleted, Name: name, State: ClusterStateDeleting}, nil
+	}
+
+	if w.sent[name] == *event {
+		return nil, nil
+	}
+	w.sent[name] = *event
+	return event, nil
+}
+
+// watchClusters watches custom resources of a given kind and their restores of a given kind,
+// and pods of a given operator, and sends cluster events until one of the watches is closed or ctx is canceled.
+func (c *K8sClient) watchClusters(ctx context.Context, namespace, kind, restoreKind, managedBy string, w *clusterWatcher, events chan<- ClusterEvent) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	clusterEvents := make(chan common.WatchEvent)
+	restoreEvents := make(chan common.WatchEvent)
+	podEvents := make(chan common.WatchEvent)
+	errs := make(chan error, 3)
+	go func() {
+		errs <- c.kube.Watch(ctx, namespace, kind, "", clusterEvents)
+	}()
+	go func() {
+		errs <- c.kube.Watch(ctx, namespace, restoreKind, "", restoreEvents)
+	}()
+	go func() {
+		errs <- c.kube.Watch(ctx, namespace, "pods", "app.kubernetes.io/managed-by="+managedBy, podEvents)
+	}()
+
+	for {
+		var event *ClusterEvent
+		var err error
+		select {
+		case e := <-clusterEvents:
+			event, err = w.onClusterEvent(e)
+		case e := <-restoreEvents:
+			event, err = w.onRestoreEvent(e)
+		case e := <-podEvents:
+			event, err = w.onPodEvent(e)
+		case err = <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			continue
+		}
+
+		select {
+		case events <- *event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// WatchXtraDBClusters sends events of Percona XtraDB clusters in a given namespace:
+// state changes, progress of running operations and deletion completion.
+// States are changed by restores the same way as in ListXtraDBClusters.
+// It blocks until ctx is canceled or the watch is closed by Kubernetes.
+// In the latter case nil is returned and the caller should start a new watch.
+// Empty namespace means the default one.
+func (c *K8sClient) WatchXtraDBClusters(ctx context.Context, namespace string, events chan<- ClusterEvent) error {
+	w := newClusterWatcher(
+		func(obj json.RawMessage, pods []common.Pod) (*ClusterEvent, error) {
+			var cluster pxc.PerconaXtraDBCluster
+			if err := json.Unmarshal(obj, &cluster); err != nil {
+				return nil, errors.Wrap(err, "cannot decode Percona XtraDB cluster")
+			}
+			val := c.getXtraDBCluster(&cluster, pods)
+			return &ClusterEvent{
+				Name:          val.Name,
+				State:         val.State,
+				Pause:         val.Pause,
+				FinishedSteps: val.DetailedState.CountReadyPods(),
+				TotalSteps:    val.DetailedState.CountAllPods(),
+				Message:       val.Message,
+			}, nil
+		},
+		func(obj json.RawMessage) (*watchedRestore, error) {
+			var restore pxc.PerconaXtraDBClusterRestore
+			if err := json.Unmarshal(obj, &restore); err != nil {
+				return nil, errors.Wrap(err, "cannot decode Percona XtraDB cluster restore")
+			}
+			val := getXtraDBClusterRestore(&restore)
+			return &watchedRestore{
+				name:        val.Name,
+				clusterName: val.ClusterName,
+				backupName:  val.BackupName,
+				state:       val.State,
+				message:     val.Message,
+				startTime:   val.StartTime,
+			}, nil
+		})
+	return c.watchClusters(ctx, namespace, string(perconaXtraDBClusterKind), perconaXtraDBClusterRestoreKind, pxcOperatorDeploymentName, w, events)
+}
+
+// WatchPSMDBClusters sends events of Percona Server for MongoDB clusters in a given namespace
+// the same way as WatchXtraDBClusters does.
+func (c *K8sClient) WatchPSMDBClusters(ctx context.Context, namespace string, events chan<- ClusterEvent) error {
+	w := newClusterWatcher(
+		func(obj json.RawMessage, pods []common.Pod) (*ClusterEvent, error) {
+			var cluster psmdb.PerconaServerMongoDB
+			if err := json.Unmarshal(obj, &cluster); err != nil {
+				return nil, errors.Wrap(err, "cannot decode percona server MongoDB cluster")
+			}
+			if len(cluster.Spec.Replsets) == 0 {
+				return nil, errors.Errorf("percona server MongoDB cluster %s has no replica sets", cluster.Name)
+			}
+			val := c.getPSMDBCluster(&cluster, pods)
+			return &ClusterEvent{
+				Name:          val.Name,
+				State:         val.State,
+				Pause:         val.Pause,
+				FinishedSteps: val.DetailedState.CountReadyPods(),
+				TotalSteps:    val.DetailedState.CountAllPods(),
+				Message:       val.Message,
+			}, nil
+		},
+		func(obj json.RawMessage) (*watchedRestore, error) {
+			var restore psmdb.PerconaServerMongoDBRestore
+			if err := json.Unmarshal(obj, &restore); err != nil {
+				return nil, errors.Wrap(err, "cannot decode percona server MongoDB cluster restore")
+			}
+			val := getPSMDBClusterRestore(&restore)
+			return &watchedRestore{
+				name:        val.Name,
+				clusterName: val.ClusterName,
+				backupName:  val.BackupName,
+				state:       val.State,
+				message:     val.Message,
+				startTime:   val.StartTime,
+			}, nil
+		})
+	return c.watchClusters(ctx, namespace, string(perconaServerMongoDBKind), perconaServerMongoDBRestoreKind, psmdbOperatorDeploymentName, w, events)
+}
@@ -0,0 +1,257 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+// fakeWatchClient sends given events for cluster custom resources and pods, and no restore events.
+type fakeWatchClient struct {
+	kubeClient
+	clusterEvents []common.WatchEvent
+	podEvents     []common.WatchEvent
+}
+
+func (f *fakeWatchClient) Watch(ctx context.Context, namespace, kind, selector string, events chan<- common.WatchEvent) error {
+	var list []common.WatchEvent
+	switch kind {
+	case "test":
+		list = f.clusterEvents
+	case "pods":
+		list = f.podEvents
+	}
+	for _, event := range list {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestClusterWatcher(t *testing.T) {
+	t.Parallel()
+
+	clusterEvent := func(obj json.RawMessage, pods []common.Pod) (*ClusterEvent, error) {
+		var cluster struct {
+			common.ObjectMeta `json:"metadata"`
+			Status            struct {
+				State string `json:"state"`
+			} `json:"status"`
+		}
+		require.NoError(t, json.Unmarshal(obj, &cluster))
+
+		res := &ClusterEvent{Name: cluster.Name, State: ClusterStateChanging, TotalSteps: 2}
+		for _, pod := range pods {
+			if common.IsPodReady(pod) {
+				res.FinishedSteps++
+			}
+		}
+		if cluster.Status.State == "ready" {
+			res.State = ClusterStateReady
+		}
+		return res, nil
+	}
+	clusterRestore := func(obj json.RawMessage) (*watchedRestore, error) {
+		var restore struct {
+			common.ObjectMeta `json:"metadata"`
+			Spec              struct {
+				ClusterName string `json:"clusterName"`
+				BackupName  string `json:"backupName"`
+			} `json:"spec"`
+			Status struct {
+				State string `json:"state"`
+			} `json:"status"`
+		}
+		require.NoError(t, json.Unmarshal(obj, &restore))
+
+		res := &watchedRestore{
+			name:        restore.Name,
+			clusterName: restore.Spec.ClusterName,
+			backupName:  restore.Spec.BackupName,
+			state:       RestoreStateSucceeded,
+			message:     restore.Status.State,
+		}
+		if restore.CreationTimestamp != nil {
+			res.startTime = *restore.CreationTimestamp
+		}
+		switch restore.Status.State {
+		case "Restoring":
+			res.state = RestoreStateRunning
+		case "Failed":
+			res.state = RestoreStateFailed
+		}
+		return res, nil
+	}
+	cluster := func(eventType common.WatchEventType, state string) common.WatchEvent {
+		return common.WatchEvent{
+			Type:   eventType,
+			Object: json.RawMessage(`{"metadata": {"name": "test"}, "status": {"state": "` + state + `"}}`),
+		}
+	}
+	pod := func(eventType common.WatchEventType, name, phase string) common.WatchEvent {
+		return common.WatchEvent{
+			Type: eventType,
+			Object: json.RawMessage(`{
+				"metadata": {"name": "` + name + `", "labels": {"app.kubernetes.io/instance": "test"}},
+				"status": {"phase": "` + phase + `", "containerStatuses": [{"name": "db", "ready": true}]}
+			}`),
+		}
+	}
+
+	t.Run("Events", func(t *testing.T) {
+		t.Parallel()
+
+		w := newClusterWatcher(clusterEvent, clusterRestore)
+		steps := []struct {
+			event    common.WatchEvent
+			isPod    bool
+			expected *ClusterEvent
+		}{
+			{cluster(common.WatchEventAdded, "initializing"), false, &ClusterEvent{Type: ClusterEventChanged, Name: "test", State: ClusterStateChanging, TotalSteps: 2}},
+			{pod(common.WatchEventAdded, "test-0", "Running"), true, &ClusterEvent{Type: ClusterEventChanged, Name: "test", State: ClusterStateChanging, FinishedSteps: 1, TotalSteps: 2}},
+			{pod(common.WatchEventAdded, "test-1", "Pending"), true, nil},
+			{pod(common.WatchEventModified, "test-1", "Running"), true, &ClusterEvent{Type: ClusterEventChanged, Name: "test", State: ClusterStateChanging, FinishedSteps: 2, TotalSteps: 2}},
+			{cluster(common.WatchEventModified, "ready"), false, &ClusterEvent{Type: ClusterEventChanged, Name: "test", State: ClusterStateReady, FinishedSteps: 2, TotalSteps: 2}},
+			{common.WatchEvent{Type: common.WatchEventBookmark}, false, nil},
+			{cluster(common.WatchEventDeleted, "ready"), false, &ClusterEvent{Type: ClusterEventChanged, Name: "test", State: ClusterStateDeleting}},
+			{pod(common.WatchEventDeleted, "test-0", "Running"), true, nil},
+			{pod(common.WatchEventDeleted, "test-1", "Running"), true, &ClusterEvent{Type: ClusterEventDeleted, Name: "test", State: ClusterStateDeleting}},
+		}
+		for i, step := range steps {
+			var actual *ClusterEvent
+			var err error
+			if step.isPod {
+				actual, err = w.onPodEvent(step.event)
+			} else {
+				actual, err = w.onClusterEvent(step.event)
+			}
+			require.NoError(t, err)
+			assert.Equal(t, step.expected, actual, "step %d", i)
+		}
+
+		_, err := w.onClusterEvent(common.WatchEvent{
+			Type:   common.WatchEventError,
+			Object: json.RawMessage(`{"kind": "Status", "message": "too old resource version"}`),
+		})
+		assert.EqualError(t, err, "watch failed: too old resource version")
+	})
+
+	t.Run("NewCluster", func(t *testing.T) {
+		t.Parallel()
+
+		// pods are seen before the cluster custom resource
+		w := newClusterWatcher(clusterEvent, clusterRestore)
+		event, err := w.onPodEvent(pod(common.WatchEventAdded, "test-0", "Running"))
+		require.NoError(t, err)
+		assert.Nil(t, event)
+
+		event, err = w.onClusterEvent(cluster(common.WatchEventAdded, "initializing"))
+		require.NoError(t, err)
+		assert.Equal(t, &ClusterEvent{Type: ClusterEventChanged, Name: "test", State: ClusterStateChanging, FinishedSteps: 1, TotalSteps: 2}, event)
+	})
+
+	t.Run("Restores", func(t *testing.T) {
+		t.Parallel()
+
+		restore := func(eventType common.WatchEventType, name, startTime, state string) common.WatchEvent {
+			return common.WatchEvent{
+				Type: eventType,
+				Object: json.RawMessage(`{
+					"metadata": {"name": "` + name + `", "creationTimestamp": "` + startTime + `"},
+					"spec": {"clusterName": "test", "backupName": "backup-` + name + `"},
+					"status": {"state": "` + state + `"}
+				}`),
+			}
+		}
+
+		w := newClusterWatcher(clusterEvent, clusterRestore)
+		steps := []struct {
+			event     common.WatchEvent
+			isRestore bool
+			expected  *ClusterEvent
+		}{
+			{restore(common.WatchEventAdded, "r1", "2021-06-01T10:00:00Z", "Failed"), true, nil},
+			{cluster(common.WatchEventAdded, "initializing"), false, &ClusterEvent{
+				Type: ClusterEventChanged, Name: "test", State: ClusterStateFailed, TotalSteps: 2,
+				Message: "Restore from backup backup-r1 failed: Failed",
+			}},
+			{restore(common.WatchEventAdded, "r2", "2021-06-01T11:00:00Z", "Restoring"), true, &ClusterEvent{
+				Type: ClusterEventChanged, Name: "test", State: ClusterStateChanging, TotalSteps: 2,
+				Message: "Restoring from backup backup-r2: Restoring",
+			}},
+			{restore(common.WatchEventModified, "r2", "2021-06-01T11:00:00Z", "Succeeded"), true, &ClusterEvent{
+				Type: ClusterEventChanged, Name: "test", State: ClusterStateChanging, TotalSteps: 2,
+			}},
+			{cluster(common.WatchEventModified, "ready"), false, &ClusterEvent{
+				Type: ClusterEventChanged, Name: "test", State: ClusterStateReady, TotalSteps: 2,
+			}},
+			// failed restore doesn't change ready cluster
+			{restore(common.WatchEventDeleted, "r2", "2021-06-01T11:00:00Z", "Succeeded"), true, nil},
+		}
+		for i, step := range steps {
+			var actual *ClusterEvent
+			var err error
+			if step.isRestore {
+				actual, err = w.onRestoreEvent(step.event)
+			} else {
+				actual, err = w.onClusterEvent(step.event)
+			}
+			require.NoError(t, err)
+			assert.Equal(t, step.expected, actual, "step %d", i)
+		}
+	})
+
+	t.Run("WatchClusters", func(t *testing.T) {
+		t.Parallel()
+
+		kube := &fakeWatchClient{
+			clusterEvents: []common.WatchEvent{cluster(common.WatchEventAdded, "ready")},
+			podEvents: []common.WatchEvent{
+				pod(common.WatchEventAdded, "test-0", "Running"),
+				pod(common.WatchEventAdded, "test-1", "Running"),
+			},
+		}
+		c := &K8sClient{kube: kube}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events := make(chan ClusterEvent)
+		errs := make(chan error, 1)
+		go func() {
+			w := newClusterWatcher(clusterEvent, clusterRestore)
+			errs <- c.watchClusters(ctx, "", "test", "test-restore", "test-operator", w, events)
+		}()
+
+		var last ClusterEvent
+		for last.FinishedSteps != 2 || last.Name == "" || last.State != ClusterStateReady {
+			last = <-events
+			assert.Equal(t, ClusterEventChanged, last.Type)
+		}
+		cancel()
+		assert.Equal(t, context.Canceled, <-errs)
+	})
+}
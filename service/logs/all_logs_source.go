@@ -47,9 +47,10 @@ type tuple struct {
 func (a *allLogsSource) getLogs(
 	ctx context.Context,
 	client *k8sclient.K8sClient,
+	namespace,
 	clusterName string,
 ) ([]*controllerv1beta1.Logs, error) {
-	pods, err := client.GetPods(ctx, "-lapp.kubernetes.io/instance="+clusterName)
+	pods, err := client.GetPods(ctx, namespace, "-lapp.kubernetes.io/instance="+clusterName)
 	if err != nil {
 		return nil, status.Error(
 			codes.Internal,
@@ -73,7 +74,7 @@ func (a *allLogsSource) getLogs(
 		for _, t := range tuples {
 			for _, container := range t.containers {
 				logs, err := client.GetLogs(
-					ctx, t.statuses, pod.Name, container.Name)
+					ctx, t.statuses, namespace, pod.Name, container.Name)
 				if err != nil {
 					return nil, status.Error(
 						codes.Internal,
@@ -89,7 +90,7 @@ func (a *allLogsSource) getLogs(
 		}
 
 		// Get pod's events.
-		events, err := client.GetEvents(ctx, pod.Name)
+		events, err := client.GetEvents(ctx, namespace, pod.Name)
 		if err != nil {
 			return nil, status.Error(codes.Internal, errors.Wrap(err, "failed to get events").Error())
 		}
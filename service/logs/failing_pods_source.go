@@ -0,0 +1,138 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package logs
+
+import (
+	"context"
+
+	controllerv1beta1 "github.com/percona-platform/dbaas-api/gen/controller"
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient"
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+// previousLogsSuffix is added to container name of logs of the previous container instance.
+const previousLogsSuffix = " (previous)"
+
+// failingPodsSource implements source interface, it gets logs of failing
+// containers and events of pods which are not ready.
+type failingPodsSource struct{}
+
+// failingContainer is a container which logs should be returned.
+type failingContainer struct {
+	name     string
+	statuses []common.ContainerStatus
+	// previous is true if the container was restarted and has logs of the previous instance.
+	previous bool
+}
+
+// getLogs gets current and previous logs of failing containers and events of pods which are not ready.
+// Previous logs are skipped if they are not available, for example after the node restart.
+func (f *failingPodsSource) getLogs(
+	ctx context.Context,
+	client *k8sclient.K8sClient,
+	namespace,
+	clusterName string,
+) ([]*controllerv1beta1.Logs, error) {
+	pods, err := client.GetPods(ctx, namespace, "-lapp.kubernetes.io/instance="+clusterName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get pods")
+	}
+
+	response := []*controllerv1beta1.Logs{}
+	for _, pod := range pods.Items {
+		containers := failingContainers(pod)
+		if len(containers) == 0 && common.IsPodReady(pod) {
+			continue
+		}
+
+		for _, container := range containers {
+			logs, err := client.GetLogs(ctx, container.statuses, namespace, pod.Name, container.name)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get logs")
+			}
+			response = append(response, &controllerv1beta1.Logs{
+				Pod:       pod.Name,
+				Container: container.name,
+				Logs:      logs,
+			})
+
+			if !container.previous {
+				continue
+			}
+			logs, err = client.GetPreviousLogs(ctx, namespace, pod.Name, container.name)
+			if err != nil {
+				l := logger.Get(ctx).WithField("component", "failingPodsSource")
+				l.Warnf("Skipping previous logs of %s/%s: %s.", pod.Name, container.name, err)
+				continue
+			}
+			response = append(response, &controllerv1beta1.Logs{
+				Pod:       pod.Name,
+				Container: container.name + previousLogsSuffix,
+				Logs:      logs,
+			})
+		}
+
+		events, err := client.GetEvents(ctx, namespace, pod.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get events")
+		}
+		response = append(response, &controllerv1beta1.Logs{
+			Pod:       pod.Name,
+			Container: "",
+			Logs:      events,
+		})
+	}
+
+	limitLines(response, overallLinesLimit)
+	return response, nil
+}
+
+// failingContainers returns containers of a given pod which are not ready, restarted or terminated
+// with non-zero exit code, and init containers which are stuck in waiting, restarted or failed.
+func failingContainers(pod common.Pod) []failingContainer {
+	var res []failingContainer
+	for _, status := range pod.Status.InitContainerStatuses {
+		// init containers waiting for previous ones are in PodInitializing state
+		waiting, ok := status.State[string(common.ContainerStateWaiting)]
+		if (ok && waiting.Reason != "PodInitializing") || status.RestartCount > 0 || isTerminatedWithError(status) {
+			res = append(res, failingContainer{
+				name:     status.Name,
+				statuses: pod.Status.InitContainerStatuses,
+				previous: status.RestartCount > 0,
+			})
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if !status.Ready || status.RestartCount > 0 || isTerminatedWithError(status) {
+			res = append(res, failingContainer{
+				name:     status.Name,
+				statuses: pod.Status.ContainerStatuses,
+				previous: status.RestartCount > 0,
+			})
+		}
+	}
+	return res
+}
+
+// isTerminatedWithError returns true if a given container is terminated with non-zero exit code.
+func isTerminatedWithError(status common.ContainerStatus) bool {
+	terminated, ok := status.State[string(common.ContainerStateTerminated)]
+	return ok && terminated.ExitCode != 0
+}
@@ -0,0 +1,99 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package logs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient/common"
+)
+
+func TestFailingContainers(t *testing.T) {
+	t.Parallel()
+
+	names := func(containers []failingContainer) map[string]bool {
+		res := make(map[string]bool, len(containers))
+		for _, container := range containers {
+			res[container.name] = container.previous
+		}
+		return res
+	}
+
+	type testCase struct {
+		name     string
+		status   string
+		expected map[string]bool
+	}
+	testCases := []testCase{
+		{
+			name: "Ready",
+			status: `{
+				"phase": "Running",
+				"initContainerStatuses": [{"name": "pxc-init", "state": {"terminated": {"reason": "Completed"}}}],
+				"containerStatuses": [{"name": "pxc", "ready": true, "state": {"running": {}}}]
+			}`,
+			expected: map[string]bool{},
+		},
+		{
+			name: "CrashLoop",
+			status: `{
+				"phase": "Running",
+				"containerStatuses": [
+					{"name": "pxc", "restartCount": 3, "state": {"waiting": {"reason": "CrashLoopBackOff"}}},
+					{"name": "pmm-client", "ready": true, "state": {"running": {}}},
+					{"name": "logs", "ready": true, "restartCount": 1, "state": {"running": {}}}
+				]
+			}`,
+			expected: map[string]bool{"pxc": true, "logs": true},
+		},
+		{
+			name: "Terminated",
+			status: `{
+				"phase": "Failed",
+				"containerStatuses": [{"name": "backup", "ready": true, "state": {"terminated": {"reason": "Error", "exitCode": 1}}}]
+			}`,
+			expected: map[string]bool{"backup": false},
+		},
+		{
+			name: "InitStuck",
+			status: `{
+				"phase": "Pending",
+				"initContainerStatuses": [
+					{"name": "init", "state": {"waiting": {"reason": "ImagePullBackOff"}}},
+					{"name": "init-next", "state": {"waiting": {"reason": "PodInitializing"}}}
+				],
+				"containerStatuses": [{"name": "mongod", "state": {"waiting": {"reason": "PodInitializing"}}}]
+			}`,
+			expected: map[string]bool{"init": false, "mongod": false},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var pod common.Pod
+			require.NoError(t, json.Unmarshal([]byte(tc.status), &pod.Status))
+			assert.Equal(t, tc.expected, names(failingContainers(pod)))
+		})
+	}
+}
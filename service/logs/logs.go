@@ -40,7 +40,7 @@ type Service struct {
 
 // Thanks to source interface we can get logs from different sources.
 type source interface {
-	getLogs(ctx context.Context, client *k8sclient.K8sClient, clusterName string) ([]*controllerv1beta1.Logs, error)
+	getLogs(ctx context.Context, client *k8sclient.K8sClient, namespace, clusterName string) ([]*controllerv1beta1.Logs, error)
 }
 
 // NewService creates a new instance of Service.
@@ -48,7 +48,7 @@ func NewService(p *message.Printer) *Service {
 	return &Service{
 		p:             p,
 		defaultSource: source(new(allLogsSource)),
-		sources:       []source{},
+		sources:       []source{new(failingPodsSource)},
 	}
 }
 
@@ -63,14 +63,14 @@ func (s *Service) GetLogs(ctx context.Context, req *controllerv1beta1.GetLogsReq
 
 	response := []*controllerv1beta1.Logs{}
 	for _, source := range s.sources {
-		logs, err := source.getLogs(ctx, client, req.ClusterName)
+		logs, err := source.getLogs(ctx, client, k8sclient.DefaultNamespace, req.ClusterName)
 		if err != nil {
 			return nil, status.Error(codes.Internal, errors.Wrap(err, "failed to get logs").Error())
 		}
 		response = append(response, logs...)
 	}
 	if len(response) == 0 {
-		logs, err := s.defaultSource.getLogs(ctx, client, req.ClusterName)
+		logs, err := s.defaultSource.getLogs(ctx, client, k8sclient.DefaultNamespace, req.ClusterName)
 		if err != nil {
 			return nil, status.Error(codes.Internal, errors.Wrap(err, "failed to get logs").Error())
 		}
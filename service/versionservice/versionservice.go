@@ -0,0 +1,303 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package versionservice provides database, proxy and backup images compatible with operators
+// from Percona version service or a local file.
+package versionservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient"
+	"github.com/percona-platform/dbaas-controller/utils/logger"
+)
+
+const (
+	// DefaultURL is a default Percona version service address.
+	DefaultURL = "https://check.percona.com"
+	// DefaultCacheTTL is a default time after which cached versions are requested again.
+	DefaultCacheTTL = time.Hour
+
+	// failureCacheTTL is a time during which version service is not requested again after a failure,
+	// so cluster creation is not delayed by each request to unavailable service.
+	failureCacheTTL = time.Minute
+	requestTimeout  = 3 * time.Second
+)
+
+// Product is an operator name in version service.
+type Product string
+
+const (
+	// ProductPXCOperator is Percona Kubernetes Operator for Percona XtraDB Cluster.
+	ProductPXCOperator Product = "pxc-operator"
+	// ProductPSMDBOperator is Percona Kubernetes Operator for Percona Server for MongoDB.
+	ProductPSMDBOperator Product = "psmdb-operator"
+)
+
+// Component names in version service matrix.
+const (
+	ComponentPXC      = "pxc"
+	ComponentProxySQL = "proxysql"
+	ComponentHAProxy  = "haproxy"
+	ComponentBackup   = "backup"
+	ComponentMongod   = "mongod"
+	ComponentPMM      = "pmm"
+	ComponentOperator = "operator"
+)
+
+// statusRecommended marks the recommended version of a component.
+const statusRecommended = "recommended"
+
+// ErrNotFound is returned when there are no versions for a given operator.
+var ErrNotFound = errors.New("operator version is not found in version service")
+
+// Default is used by dbaas-controller services. It is replaced once on startup.
+var Default = New(&Opts{URL: DefaultURL, CacheTTL: DefaultCacheTTL}) //nolint:gochecknoglobals
+
+// Image represents an image of a component compatible with an operator version.
+type Image struct {
+	Component   string
+	Version     string
+	ImagePath   string
+	ImageHash   string
+	Recommended bool
+	Critical    bool
+}
+
+// versionsResponse is a version service response, local file has the same format.
+type versionsResponse struct {
+	Versions []struct {
+		Product  Product                                `json:"product"`
+		Operator string                                 `json:"operator"`
+		Matrix   map[string]map[string]imageDescription `json:"matrix"`
+	} `json:"versions"`
+}
+
+// imageDescription describes a single component version in version service matrix.
+type imageDescription struct {
+	ImagePath string `json:"imagePath"`
+	ImageHash string `json:"imageHash"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+}
+
+// cacheEntry contains images cached for a given operator version, or the last error if there are no images.
+type cacheEntry struct {
+	images  []Image
+	err     error
+	expires time.Time
+}
+
+// Opts contains options of Client.
+type Opts struct {
+	// URL of version service, it is used if File is not set.
+	URL string
+	// File is a local JSON file in version service response format for offline installs.
+	File string
+	// CacheTTL is a time after which cached versions are requested again.
+	CacheTTL time.Duration
+}
+
+// Client returns images compatible with operators and caches them.
+type Client struct {
+	url      string
+	file     string
+	cacheTTL time.Duration
+	http     *http.Client
+
+	m     sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// New returns new Client.
+func New(opts *Opts) *Client {
+	return &Client{
+		url:      strings.TrimSuffix(opts.URL, "/"),
+		file:     opts.File,
+		cacheTTL: opts.CacheTTL,
+		http:     &http.Client{Timeout: requestTimeout},
+		cache:    make(map[string]cacheEntry),
+	}
+}
+
+// Images returns images of all components compatible with a given operator version
+// sorted by component name and newest version first.
+// Stale cached images are returned if version service is not available.
+// Failures are cached for a short time too.
+func (c *Client) Images(ctx context.Context, product Product, operatorVersion string) ([]Image, error) {
+	key := string(product) + "/" + operatorVersion
+
+	c.m.Lock()
+	entry, ok := c.cache[key]
+	c.m.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.images, entry.err
+	}
+
+	images, err := c.fetch(ctx, product, operatorVersion)
+	if err != nil {
+		if ok && entry.images != nil {
+			l := logger.Get(ctx).WithField("component", "versionservice")
+			l.Warnf("Using cached versions of %s: %s.", key, err)
+			entry.expires = time.Now().Add(failureCacheTTL)
+		} else {
+			entry = cacheEntry{err: err, expires: time.Now().Add(failureCacheTTL)}
+		}
+		c.m.Lock()
+		c.cache[key] = entry
+		c.m.Unlock()
+		return entry.images, entry.err
+	}
+
+	c.m.Lock()
+	c.cache[key] = cacheEntry{images: images, expires: time.Now().Add(c.cacheTTL)}
+	c.m.Unlock()
+	return images, nil
+}
+
+// RecommendedImages returns paths of recommended images of given components compatible with a given operator
+// keyed by component. Images are not returned for an operator that is not installed or for components
+// without images. Errors are logged, callers use their default images then.
+func (c *Client) RecommendedImages(ctx context.Context, product Product, operator k8sclient.Operator, components ...string) map[string]string {
+	res := make(map[string]string, len(components))
+	if operator.Status != k8sclient.OperatorStatusOK || operator.Version == "" {
+		return res
+	}
+
+	l := logger.Get(ctx).WithField("component", "versionservice")
+	images, err := c.Images(ctx, product, operator.Version)
+	if err != nil {
+		l.Warnf("Using default images: %s.", err)
+		return res
+	}
+	for _, component := range components {
+		if image := recommendedImage(images, component); image != nil {
+			res[component] = image.ImagePath
+		}
+	}
+	return res
+}
+
+// recommendedImage returns recommended image of a given component, or the newest one
+// if there is no recommended image. It returns nil if there are no images of the component.
+func recommendedImage(images []Image, component string) *Image {
+	var res *Image
+	for i, image := range images {
+		if image.Component != component {
+			continue
+		}
+		if image.Recommended {
+			return &images[i]
+		}
+		if res == nil {
+			res = &images[i]
+		}
+	}
+	return res
+}
+
+// fetch reads versions from a local file or version service.
+func (c *Client) fetch(ctx context.Context, product Product, operatorVersion string) ([]Image, error) {
+	var b []byte
+	var err error
+	if c.file != "" {
+		b, err = ioutil.ReadFile(c.file)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read versions file")
+		}
+	} else {
+		b, err = c.request(ctx, fmt.Sprintf("%s/versions/v1/%s/%s", c.url, product, operatorVersion))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var resp versionsResponse
+	if err = json.Unmarshal(b, &resp); err != nil {
+		return nil, errors.Wrap(err, "cannot parse versions")
+	}
+	for _, v := range resp.Versions {
+		if v.Product == product && v.Operator == operatorVersion {
+			return matrixImages(v.Matrix), nil
+		}
+	}
+	return nil, errors.Wrapf(ErrNotFound, "%s %s", product, operatorVersion)
+}
+
+// request does HTTP GET request to version service.
+func (c *Client) request(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get versions")
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get versions")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("version service returned status %d: %s", resp.StatusCode, b)
+	}
+	return b, nil
+}
+
+// matrixImages converts version service matrix to a sorted list of images.
+func matrixImages(matrix map[string]map[string]imageDescription) []Image {
+	var res []Image
+	for component, versions := range matrix {
+		for v, desc := range versions {
+			res = append(res, Image{
+				Component:   component,
+				Version:     v,
+				ImagePath:   desc.ImagePath,
+				ImageHash:   desc.ImageHash,
+				Recommended: desc.Status == statusRecommended,
+				Critical:    desc.Critical,
+			})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Component != res[j].Component {
+			return res[i].Component < res[j].Component
+		}
+		vi, erri := version.NewVersion(res[i].Version)
+		vj, errj := version.NewVersion(res[j].Version)
+		if erri != nil || errj != nil {
+			return res[i].Version > res[j].Version
+		}
+		return vi.GreaterThan(vj)
+	})
+	return res
+}
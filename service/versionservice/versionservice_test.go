@@ -0,0 +1,128 @@
+// dbaas-controller
+// Copyright (C) 2020 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package versionservice
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona-platform/dbaas-controller/service/k8sclient"
+)
+
+const testFile = "testdata/versions.json"
+
+func TestVersionService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+
+		c := New(&Opts{File: testFile, CacheTTL: time.Hour})
+		images, err := c.Images(ctx, ProductPXCOperator, "1.8.0")
+		require.NoError(t, err)
+		require.Len(t, images, 4)
+		assert.Equal(t, []string{"backup", "haproxy", "pxc", "pxc"}, []string{
+			images[0].Component, images[1].Component, images[2].Component, images[3].Component,
+		})
+		assert.Equal(t, "8.0.22-13.1", images[2].Version)
+		assert.True(t, images[2].Recommended)
+		assert.Equal(t, "8.0.20-11.1", images[3].Version)
+		assert.False(t, images[3].Recommended)
+
+		// the newest image is used if there is no recommended one
+		operator := k8sclient.Operator{Status: k8sclient.OperatorStatusOK, Version: "1.8.0"}
+		assert.Equal(t, map[string]string{ComponentMongod: "percona/percona-server-mongodb:4.4.5-7"},
+			c.RecommendedImages(ctx, ProductPSMDBOperator, operator, ComponentMongod))
+
+		_, err = c.Images(ctx, ProductPXCOperator, "1.7.0")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("RecommendedImages", func(t *testing.T) {
+		t.Parallel()
+
+		c := New(&Opts{File: testFile, CacheTTL: time.Hour})
+		operator := k8sclient.Operator{Status: k8sclient.OperatorStatusOK, Version: "1.8.0"}
+		images := c.RecommendedImages(ctx, ProductPXCOperator, operator, ComponentPXC, ComponentProxySQL, ComponentBackup)
+		assert.Equal(t, map[string]string{
+			ComponentPXC:    "percona/percona-xtradb-cluster:8.0.22-13.1",
+			ComponentBackup: "percona/percona-xtradb-cluster-operator:1.8.0-pxc8.0-backup",
+		}, images)
+
+		operator.Version = "1.7.0"
+		assert.Empty(t, c.RecommendedImages(ctx, ProductPXCOperator, operator, ComponentPXC))
+
+		operator = k8sclient.Operator{Status: k8sclient.OperatorStatusUnsupported, Version: "1.8.0"}
+		assert.Empty(t, c.RecommendedImages(ctx, ProductPXCOperator, operator, ComponentPXC))
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := ioutil.ReadFile(testFile)
+		require.NoError(t, err)
+
+		var requests, failing int32
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			if atomic.LoadInt32(&failing) == 1 {
+				rw.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			assert.Equal(t, "/versions/v1/psmdb-operator/1.8.0", req.URL.Path)
+			_, _ = rw.Write(b)
+		}))
+		defer server.Close()
+
+		c := New(&Opts{URL: server.URL, CacheTTL: time.Hour})
+		_, err = c.Images(ctx, ProductPSMDBOperator, "1.8.0")
+		require.NoError(t, err)
+		images, err := c.Images(ctx, ProductPSMDBOperator, "1.8.0")
+		require.NoError(t, err)
+		assert.Len(t, images, 2)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+		// stale cache is used if version service fails, and it is not requested again for a while
+		c.cacheTTL = 0
+		c.cache["psmdb-operator/1.8.0"] = cacheEntry{images: images}
+		atomic.StoreInt32(&failing, 1)
+		for i := 0; i < 2; i++ {
+			images, err = c.Images(ctx, ProductPSMDBOperator, "1.8.0")
+			require.NoError(t, err)
+			assert.Len(t, images, 2)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+		// failures are cached too
+		for i := 0; i < 2; i++ {
+			_, err = c.Images(ctx, ProductPXCOperator, "1.8.0")
+			assert.EqualError(t, err, "version service returned status 500: ")
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+}
@@ -19,6 +19,7 @@ package app
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/percona/pmm/version"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -33,6 +34,18 @@ type Flags struct {
 	GRPCAddr string
 	// Debug listen address
 	DebugAddr string
+	// Kubernetes API client backend
+	KubeBackend string
+	// Maximum number of cached Kubernetes clients
+	KubePoolSize int
+	// Time after which unused Kubernetes client is closed
+	KubePoolIdleTimeout time.Duration
+	// Version service URL
+	VersionServiceURL string
+	// Local versions file used instead of version service
+	VersionServiceFile string
+	// Time after which cached versions are requested again
+	VersionServiceCacheTTL time.Duration
 }
 
 // SetupOpts contains options required for app.
@@ -58,6 +71,12 @@ func Setup(opts *SetupOpts) (*Flags, error) {
 	var flags Flags
 	kingpin.Flag("grpc.addr", "gRPC listen address").Default(":20201").StringVar(&flags.GRPCAddr)
 	kingpin.Flag("debug.addr", "Debug listen address").Default(":20203").StringVar(&flags.DebugAddr)
+	kingpin.Flag("kube.backend", "Kubernetes API client backend: native or kubectl").Default("native").EnumVar(&flags.KubeBackend, "native", "kubectl")
+	kingpin.Flag("kube.pool-size", "Maximum number of cached Kubernetes clients").Default("32").IntVar(&flags.KubePoolSize)
+	kingpin.Flag("kube.pool-idle-timeout", "Time after which unused Kubernetes client is closed").Default("10m").DurationVar(&flags.KubePoolIdleTimeout)
+	kingpin.Flag("version-service.url", "Version service URL").Default("https://check.percona.com").StringVar(&flags.VersionServiceURL)
+	kingpin.Flag("version-service.file", "Local versions file used instead of version service").StringVar(&flags.VersionServiceFile)
+	kingpin.Flag("version-service.cache-ttl", "Time after which cached versions are requested again").Default("1h").DurationVar(&flags.VersionServiceCacheTTL)
 
 	return &flags, nil
 }